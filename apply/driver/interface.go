@@ -14,8 +14,15 @@
 
 package driver
 
+import (
+	"net"
+
+	v2 "github.com/sealerio/sealer/types/api/v2"
+)
+
 type Interface interface {
 	Apply() error
 	Delete() error
 	Upgrade(imageName string) error
+	Replace(oldHost v2.Host, newIP net.IP) error
 }
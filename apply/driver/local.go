@@ -25,6 +25,7 @@ import (
 	"github.com/sealerio/sealer/pkg/filesystem/clusterimage"
 	"github.com/sealerio/sealer/pkg/image"
 	"github.com/sealerio/sealer/pkg/image/store"
+	"github.com/sealerio/sealer/pkg/registry"
 	"github.com/sealerio/sealer/pkg/runtime"
 	"github.com/sealerio/sealer/pkg/runtime/kubernetes"
 	v1 "github.com/sealerio/sealer/types/api/v1"
@@ -56,6 +57,9 @@ type Applier struct {
 func (c *Applier) Delete() (err error) {
 	t := metav1.Now()
 	c.ClusterDesired.DeletionTimestamp = &t
+	if err = c.keepRegistryNode(); err != nil {
+		return err
+	}
 	return c.deleteCluster()
 }
 
@@ -68,19 +72,49 @@ func (c *Applier) Apply() (err error) {
 			return err
 		}
 	}
+	if err = c.keepRegistryNode(); err != nil {
+		return err
+	}
 	if !osi.IsFileExist(common.DefaultKubeConfigFile()) {
 		if err = c.initCluster(); err != nil {
 			return err
 		}
 	} else {
+		// the Clusterfile on disk is overwritten by scaling, so the previous one is read first.
+		previous, err := getClusterFromDisk(c.ClusterDesired.Name)
+		if err != nil {
+			return err
+		}
 		if err = c.reconcileCluster(); err != nil {
 			return err
 		}
+		if err = c.reconcileKubeletExtraArgs(previous); err != nil {
+			return err
+		}
+	}
+	if err = c.reconcileNodes(); err != nil {
+		return err
 	}
 
 	return clusterfile.SaveToDisk(c.ClusterDesired, c.ClusterDesired.Name)
 }
 
+// keepRegistryNode keeps the registry node recorded on the running cluster for the desired cluster, which is
+// loaded from the Clusterfile of user.
+func (c *Applier) keepRegistryNode() error {
+	if c.ClusterDesired.GetAnnotationsByKey(registry.NodeAnnotation) != "" {
+		return nil
+	}
+	cluster, err := getClusterFromDisk(c.ClusterDesired.Name)
+	if err != nil || cluster == nil {
+		return err
+	}
+	if node := cluster.GetAnnotationsByKey(registry.NodeAnnotation); node != "" {
+		c.ClusterDesired.SetAnnotations(registry.NodeAnnotation, node)
+	}
+	return nil
+}
+
 func (c *Applier) fillClusterCurrent() error {
 	currentCluster, err := GetCurrentCluster(c.Client)
 	if err != nil {
@@ -89,6 +123,14 @@ func (c *Applier) fillClusterCurrent() error {
 	if currentCluster != nil {
 		c.ClusterCurrent = c.ClusterDesired.DeepCopy()
 		c.ClusterCurrent.Spec.Hosts = currentCluster.Spec.Hosts
+		// etcd hosts are not kubernetes nodes, they are read from the Clusterfile of the running cluster.
+		etcds, err := getCurrentEtcdIPList(c.ClusterDesired.Name)
+		if err != nil {
+			return err
+		}
+		if len(etcds) != 0 {
+			c.ClusterCurrent.Spec.Hosts = append(c.ClusterCurrent.Spec.Hosts, v2.Host{IPS: etcds, Roles: []string{common.ETCD}})
+		}
 	}
 	return nil
 }
@@ -155,17 +197,21 @@ func (c *Applier) reconcileCluster() error {
 
 	mj, md := strings.Diff(c.ClusterCurrent.GetMasterIPList(), c.ClusterDesired.GetMasterIPList())
 	nj, nd := strings.Diff(c.ClusterCurrent.GetNodeIPList(), c.ClusterDesired.GetNodeIPList())
-	if len(mj) == 0 && len(md) == 0 && len(nj) == 0 && len(nd) == 0 {
+	ej, ed := strings.Diff(c.ClusterCurrent.GetEtcdIPList(), c.ClusterDesired.GetEtcdIPList())
+	if (len(c.ClusterCurrent.GetEtcdIPList()) == 0) != (len(c.ClusterDesired.GetEtcdIPList()) == 0) {
+		return fmt.Errorf("failed to switch etcd between stacked and external topology for a running cluster")
+	}
+	if len(mj) == 0 && len(md) == 0 && len(nj) == 0 && len(nd) == 0 && len(ej) == 0 && len(ed) == 0 {
 		return c.upgrade()
 	}
-	return c.scaleCluster(mj, md, nj, nd)
+	return c.scaleCluster(mj, md, nj, nd, ej, ed)
 }
 
-func (c *Applier) scaleCluster(mj, md, nj, nd []net.IP) error {
+func (c *Applier) scaleCluster(mj, md, nj, nd, ej, ed []net.IP) error {
 	logrus.Info("Start to scale this cluster")
-	logrus.Debugf("current cluster: master %s, worker %s", c.ClusterCurrent.GetMasterIPList(), c.ClusterCurrent.GetNodeIPList())
+	logrus.Debugf("current cluster: master %s, worker %s, etcd %s", c.ClusterCurrent.GetMasterIPList(), c.ClusterCurrent.GetNodeIPList(), c.ClusterCurrent.GetEtcdIPList())
 
-	scaleProcessor, err := processor.NewScaleProcessor(c.ClusterFile.GetKubeadmConfig(), c.ClusterFile, mj, md, nj, nd)
+	scaleProcessor, err := processor.NewScaleProcessor(c.ClusterFile.GetKubeadmConfig(), c.ClusterFile, mj, md, nj, nd, ej, ed)
 	if err != nil {
 		return err
 	}
@@ -194,6 +240,9 @@ func (c *Applier) Upgrade(upgradeImgName string) error {
 	if err := c.initClusterfile(); err != nil {
 		return err
 	}
+	if err := c.keepRegistryNode(); err != nil {
+		return err
+	}
 	if err := c.initK8sClient(); err != nil {
 		return err
 	}
@@ -210,6 +259,51 @@ func (c *Applier) Upgrade(upgradeImgName string) error {
 	return c.upgrade()
 }
 
+// Replace removes oldHost from the cluster, which may be unreachable, and joins the host newIP in the same roles,
+// the labels of old node are carried over. ClusterDesired has oldHost replaced by newIP already.
+func (c *Applier) Replace(oldHost v2.Host, newIP net.IP) error {
+	if err := c.initClusterfile(); err != nil {
+		return err
+	}
+	if err := c.keepRegistryNode(); err != nil {
+		return err
+	}
+	if err := c.initK8sClient(); err != nil {
+		return err
+	}
+	labels, err := getNodeLabels(c.Client, oldHost.IPS[0])
+	if err != nil {
+		return fmt.Errorf("failed to get labels of node %s: %v", oldHost.IPS[0], err)
+	}
+
+	if err = c.mountClusterImage(); err != nil {
+		return err
+	}
+	defer func() {
+		if err := c.unMountClusterImage(); err != nil {
+			logrus.Warnf("failed to umount image(%s): %v", c.ClusterDesired.ClusterName, err)
+		}
+	}()
+
+	logrus.Infof("Start to replace host %s with %s", oldHost.IPS[0], newIP)
+	replaceProcessor, err := processor.NewReplaceProcessor(c.ClusterFile, oldHost, newIP)
+	if err != nil {
+		return err
+	}
+	if err = processor.NewExecutor(replaceProcessor).Execute(c.ClusterDesired); err != nil {
+		return err
+	}
+	if err = setNodeLabels(c.Client, newIP, labels); err != nil {
+		return fmt.Errorf("failed to set labels of node %s: %v", newIP, err)
+	}
+	if err = c.reconcileNodes(); err != nil {
+		return err
+	}
+	logrus.Infof("Succeeded in replacing host %s with %s", oldHost.IPS[0], newIP)
+
+	return clusterfile.SaveToDisk(c.ClusterDesired, c.ClusterDesired.Name)
+}
+
 func (c *Applier) upgrade() error {
 	runtimeInterface, err := kubernetes.NewDefaultRuntime(c.ClusterDesired, c.ClusterFile.GetKubeadmConfig())
 	if err != nil {
@@ -224,6 +318,26 @@ func (c *Applier) upgrade() error {
 		logrus.Infof("No upgrade required, image version and cluster version are both %s.", c.CurrentClusterInfo.GitVersion)
 		return nil
 	}
+	// the ClusterRuntime and KubeVersion are only in the metadata file of ClusterImage.
+	imageMeta, err := runtime.LoadMetadata(platform.DefaultMountClusterImageDir(c.ClusterDesired.Name))
+	if err != nil {
+		return err
+	}
+	if imageMeta != nil {
+		upgradeImgMeta.ClusterRuntime = imageMeta.ClusterRuntime
+		upgradeImgMeta.KubeVersion = imageMeta.KubeVersion
+	}
+	report, err := validateUpgrade(c.Client, c.CurrentClusterInfo.GitVersion, upgradeImgMeta)
+	if err != nil {
+		return fmt.Errorf("failed to validate upgrade: %v", err)
+	}
+	report.Print()
+	if UpgradeCheckOnly {
+		return nil
+	}
+	if !report.Go() {
+		return fmt.Errorf("upgrade from %s to %s is not allowed, see the failed checks above", report.From, report.To)
+	}
 	logrus.Infof("Start to upgrade this cluster from version(%s) to version(%s)", c.CurrentClusterInfo.GitVersion, upgradeImgMeta.Version)
 
 	upgradeProcessor, err := processor.NewUpgradeProcessor(platform.DefaultMountClusterImageDir(c.ClusterDesired.Name), runtimeInterface)
@@ -0,0 +1,234 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package driver
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/sealerio/sealer/pkg/client/k8s"
+	"github.com/sealerio/sealer/pkg/runtime/kubernetes"
+	v2 "github.com/sealerio/sealer/types/api/v2"
+)
+
+const (
+	// DeclaredLabelsAnnotation records the keys of labels declared on the host of node by the last apply, so the
+	// labels removed from the host are removed from the node, and the labels set by others are kept.
+	DeclaredLabelsAnnotation = "sealer.io/declared-labels"
+	// DeclaredTaintsAnnotation records the "key:effect" of taints declared on the host of node by the last apply.
+	DeclaredTaintsAnnotation = "sealer.io/declared-taints"
+)
+
+// reconcileNodes sets the labels and taints declared on the hosts of cluster to their nodes, and removes the ones
+// declared by the last apply but not any more.
+func (c *Applier) reconcileNodes() error {
+	if c.Client == nil {
+		client, err := k8s.Newk8sClient()
+		if err != nil {
+			return err
+		}
+		c.Client = client
+	}
+	nodes, err := c.Client.ListNodes()
+	if err != nil {
+		return err
+	}
+	for i := range nodes.Items {
+		name := nodes.Items[i].Name
+		ip := getNodeAddress(nodes.Items[i])
+		if ip == nil {
+			continue
+		}
+		host := c.ClusterDesired.GetHostByIP(ip)
+		if host == nil {
+			continue
+		}
+		// kubelet and controllers update the node too, so it is got again and updated on conflict.
+		changed := false
+		err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			node, err := c.Client.GetNode(name)
+			if err != nil {
+				return err
+			}
+			if changed = reconcileNode(node, host); !changed {
+				return nil
+			}
+			_, err = c.Client.UpdateNode(*node)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update labels and taints of node %s: %v", name, err)
+		}
+		if changed {
+			logrus.Infof("Succeeded in reconciling labels and taints of node %s", name)
+		}
+	}
+	return nil
+}
+
+// reconcileKubeletExtraArgs restarts kubelet of the hosts whose kubelet extra args are changed since the previous
+// apply, the new hosts got them at join time.
+func (c *Applier) reconcileKubeletExtraArgs(previous *v2.Cluster) error {
+	hosts := kubeletExtraArgsChangedHosts(previous, c.ClusterDesired)
+	if len(hosts) == 0 {
+		return nil
+	}
+	rt, err := kubernetes.NewDefaultRuntime(c.ClusterDesired, c.ClusterFile.GetKubeadmConfig())
+	if err != nil {
+		return fmt.Errorf("failed to init runtime: %v", err)
+	}
+	if err = rt.UpdateKubeletExtraArgs(previous, hosts); err != nil {
+		return err
+	}
+	logrus.Infof("Succeeded in updating kubelet extra args of %v", hosts)
+	return nil
+}
+
+// kubeletExtraArgsChangedHosts returns the hosts of desired, which are in previous with different kubelet extra args.
+func kubeletExtraArgsChangedHosts(previous, desired *v2.Cluster) []net.IP {
+	if previous == nil {
+		return nil
+	}
+	var hosts []net.IP
+	for _, host := range desired.Spec.Hosts {
+		for _, ip := range host.IPS {
+			prev := previous.GetHostByIP(ip)
+			if prev == nil || argsEqual(prev.KubeletExtraArgs, host.KubeletExtraArgs) {
+				continue
+			}
+			hosts = append(hosts, ip)
+		}
+	}
+	return hosts
+}
+
+func argsEqual(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, value := range a {
+		if v, ok := b[key]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
+
+// reconcileNode updates the labels, taints and declared annotations of node by host, it returns whether node
+// is changed.
+func reconcileNode(node *corev1.Node, host *v2.Host) bool {
+	changed := false
+	if node.Labels == nil {
+		node.Labels = map[string]string{}
+	}
+	for _, key := range splitDeclared(node.Annotations[DeclaredLabelsAnnotation]) {
+		if _, ok := host.Labels[key]; !ok {
+			if _, ok := node.Labels[key]; ok {
+				delete(node.Labels, key)
+				changed = true
+			}
+		}
+	}
+	var labelKeys []string
+	for key, value := range host.Labels {
+		labelKeys = append(labelKeys, key)
+		if v, ok := node.Labels[key]; !ok || v != value {
+			node.Labels[key] = value
+			changed = true
+		}
+	}
+
+	declaredTaints := map[string]corev1.Taint{}
+	var taintKeys []string
+	for _, taint := range host.Taints {
+		declaredTaints[taintKey(taint)] = taint
+		taintKeys = append(taintKeys, taintKey(taint))
+	}
+	removed := map[string]bool{}
+	for _, key := range splitDeclared(node.Annotations[DeclaredTaintsAnnotation]) {
+		if _, ok := declaredTaints[key]; !ok {
+			removed[key] = true
+		}
+	}
+	var taints []corev1.Taint
+	set := map[string]bool{}
+	for _, taint := range node.Spec.Taints {
+		key := taintKey(taint)
+		if removed[key] {
+			changed = true
+			continue
+		}
+		if declared, ok := declaredTaints[key]; ok {
+			if declared.Value != taint.Value {
+				changed = true
+			}
+			taint = declared
+			set[key] = true
+		}
+		taints = append(taints, taint)
+	}
+	for _, key := range taintKeys {
+		if !set[key] {
+			taints = append(taints, declaredTaints[key])
+			set[key] = true
+			changed = true
+		}
+	}
+	node.Spec.Taints = taints
+
+	if setDeclared(node, DeclaredLabelsAnnotation, labelKeys) {
+		changed = true
+	}
+	if setDeclared(node, DeclaredTaintsAnnotation, taintKeys) {
+		changed = true
+	}
+	return changed
+}
+
+func taintKey(taint corev1.Taint) string {
+	return fmt.Sprintf("%s:%s", taint.Key, taint.Effect)
+}
+
+func splitDeclared(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// setDeclared records keys in annotation of node, the annotation is removed if keys is empty. It returns whether
+// the annotation is changed.
+func setDeclared(node *corev1.Node, annotation string, keys []string) bool {
+	sort.Strings(keys)
+	value := strings.Join(keys, ",")
+	if node.Annotations[annotation] == value {
+		return false
+	}
+	if value == "" {
+		delete(node.Annotations, annotation)
+		return true
+	}
+	if node.Annotations == nil {
+		node.Annotations = map[string]string{}
+	}
+	node.Annotations[annotation] = value
+	return true
+}
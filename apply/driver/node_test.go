@@ -0,0 +1,182 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package driver
+
+import (
+	"net"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v2 "github.com/sealerio/sealer/types/api/v2"
+)
+
+func TestReconcileNode(t *testing.T) {
+	gpu := corev1.Taint{Key: "gpu", Value: "true", Effect: corev1.TaintEffectNoSchedule}
+	master := corev1.Taint{Key: "node-role.kubernetes.io/master", Effect: corev1.TaintEffectNoSchedule}
+	tests := []struct {
+		name        string
+		node        corev1.Node
+		host        v2.Host
+		wantChanged bool
+		wantNode    corev1.Node
+	}{
+		{
+			name: "labels and taints are added",
+			node: corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"kubernetes.io/os": "linux"}},
+				Spec:       corev1.NodeSpec{Taints: []corev1.Taint{master}},
+			},
+			host:        v2.Host{Labels: map[string]string{"zone": "a"}, Taints: []corev1.Taint{gpu}},
+			wantChanged: true,
+			wantNode: corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      map[string]string{"kubernetes.io/os": "linux", "zone": "a"},
+					Annotations: map[string]string{DeclaredLabelsAnnotation: "zone", DeclaredTaintsAnnotation: "gpu:NoSchedule"},
+				},
+				Spec: corev1.NodeSpec{Taints: []corev1.Taint{master, gpu}},
+			},
+		},
+		{
+			name: "undeclared labels and taints are removed, others are kept",
+			node: corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      map[string]string{"zone": "a", "disk": "ssd", "team": "x"},
+					Annotations: map[string]string{DeclaredLabelsAnnotation: "disk,zone", DeclaredTaintsAnnotation: "gpu:NoSchedule"},
+				},
+				Spec: corev1.NodeSpec{Taints: []corev1.Taint{master, gpu}},
+			},
+			host:        v2.Host{Labels: map[string]string{"zone": "a"}},
+			wantChanged: true,
+			wantNode: corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      map[string]string{"zone": "a", "team": "x"},
+					Annotations: map[string]string{DeclaredLabelsAnnotation: "zone"},
+				},
+				Spec: corev1.NodeSpec{Taints: []corev1.Taint{master}},
+			},
+		},
+		{
+			name: "label and taint values are updated",
+			node: corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      map[string]string{"zone": "a"},
+					Annotations: map[string]string{DeclaredLabelsAnnotation: "zone", DeclaredTaintsAnnotation: "gpu:NoSchedule"},
+				},
+				Spec: corev1.NodeSpec{Taints: []corev1.Taint{{Key: "gpu", Value: "false", Effect: corev1.TaintEffectNoSchedule}}},
+			},
+			host:        v2.Host{Labels: map[string]string{"zone": "b"}, Taints: []corev1.Taint{gpu}},
+			wantChanged: true,
+			wantNode: corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      map[string]string{"zone": "b"},
+					Annotations: map[string]string{DeclaredLabelsAnnotation: "zone", DeclaredTaintsAnnotation: "gpu:NoSchedule"},
+				},
+				Spec: corev1.NodeSpec{Taints: []corev1.Taint{gpu}},
+			},
+		},
+		{
+			name: "node is up to date",
+			node: corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      map[string]string{"zone": "a"},
+					Annotations: map[string]string{DeclaredLabelsAnnotation: "zone", DeclaredTaintsAnnotation: "gpu:NoSchedule"},
+				},
+				Spec: corev1.NodeSpec{Taints: []corev1.Taint{gpu}},
+			},
+			host:        v2.Host{Labels: map[string]string{"zone": "a"}, Taints: []corev1.Taint{gpu}},
+			wantChanged: false,
+			wantNode: corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      map[string]string{"zone": "a"},
+					Annotations: map[string]string{DeclaredLabelsAnnotation: "zone", DeclaredTaintsAnnotation: "gpu:NoSchedule"},
+				},
+				Spec: corev1.NodeSpec{Taints: []corev1.Taint{gpu}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := tt.node.DeepCopy()
+			if got := reconcileNode(node, &tt.host); got != tt.wantChanged {
+				t.Errorf("reconcileNode() = %v, want %v", got, tt.wantChanged)
+			}
+			if !reflect.DeepEqual(node.Labels, tt.wantNode.Labels) {
+				t.Errorf("labels = %v, want %v", node.Labels, tt.wantNode.Labels)
+			}
+			if !reflect.DeepEqual(node.Annotations, tt.wantNode.Annotations) {
+				t.Errorf("annotations = %v, want %v", node.Annotations, tt.wantNode.Annotations)
+			}
+			if !reflect.DeepEqual(node.Spec.Taints, tt.wantNode.Spec.Taints) {
+				t.Errorf("taints = %v, want %v", node.Spec.Taints, tt.wantNode.Spec.Taints)
+			}
+		})
+	}
+}
+
+func TestKubeletExtraArgsChangedHosts(t *testing.T) {
+	newCluster := func(hosts ...v2.Host) *v2.Cluster {
+		cluster := &v2.Cluster{}
+		cluster.Spec.Hosts = hosts
+		return cluster
+	}
+	ip := func(s string) []net.IP {
+		return []net.IP{net.ParseIP(s)}
+	}
+	tests := []struct {
+		name     string
+		previous *v2.Cluster
+		desired  *v2.Cluster
+		want     []net.IP
+	}{
+		{
+			name:    "no previous cluster",
+			desired: newCluster(v2.Host{IPS: ip("192.168.0.2"), KubeletExtraArgs: map[string]string{"max-pods": "200"}}),
+		},
+		{
+			name: "changed, added and removed args",
+			previous: newCluster(
+				v2.Host{IPS: ip("192.168.0.2"), KubeletExtraArgs: map[string]string{"max-pods": "110"}},
+				v2.Host{IPS: ip("192.168.0.3")},
+				v2.Host{IPS: ip("192.168.0.4"), KubeletExtraArgs: map[string]string{"max-pods": "110"}},
+				v2.Host{IPS: ip("192.168.0.5"), KubeletExtraArgs: map[string]string{"max-pods": "110"}},
+			),
+			desired: newCluster(
+				v2.Host{IPS: ip("192.168.0.2"), KubeletExtraArgs: map[string]string{"max-pods": "200"}},
+				v2.Host{IPS: ip("192.168.0.3"), KubeletExtraArgs: map[string]string{"max-pods": "200"}},
+				v2.Host{IPS: ip("192.168.0.4")},
+				v2.Host{IPS: ip("192.168.0.5"), KubeletExtraArgs: map[string]string{"max-pods": "110"}},
+			),
+			want: []net.IP{net.ParseIP("192.168.0.2"), net.ParseIP("192.168.0.3"), net.ParseIP("192.168.0.4")},
+		},
+		{
+			name:     "joining host gets args at join time",
+			previous: newCluster(v2.Host{IPS: ip("192.168.0.2")}),
+			desired: newCluster(
+				v2.Host{IPS: ip("192.168.0.2"), KubeletExtraArgs: map[string]string{}},
+				v2.Host{IPS: ip("192.168.0.3"), KubeletExtraArgs: map[string]string{"max-pods": "200"}},
+			),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := kubeletExtraArgsChangedHosts(tt.previous, tt.desired); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("kubeletExtraArgsChangedHosts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,280 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package driver
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/olekukonko/tablewriter"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/sealerio/sealer/common"
+	"github.com/sealerio/sealer/pkg/client/k8s"
+	"github.com/sealerio/sealer/pkg/runtime"
+)
+
+// UpgradeCheckOnly only prints the report of upgrade validation without upgrading the cluster.
+var UpgradeCheckOnly bool
+
+// MaxKubeletSkew is the max minor versions kubelet is allowed to be older than apiserver.
+const MaxKubeletSkew = 2
+
+// removedAPI is an API group version of resource which is removed in Kubernetes minor version RemovedIn.
+type removedAPI struct {
+	schema.GroupVersionResource
+	RemovedIn string
+}
+
+// removedAPIs are the removed APIs of https://kubernetes.io/docs/reference/using-api/deprecation-guide/,
+// events are skipped as they are not long lived.
+var removedAPIs = []removedAPI{
+	{schema.GroupVersionResource{Group: "extensions", Version: "v1beta1", Resource: "deployments"}, "1.16"},
+	{schema.GroupVersionResource{Group: "extensions", Version: "v1beta1", Resource: "daemonsets"}, "1.16"},
+	{schema.GroupVersionResource{Group: "extensions", Version: "v1beta1", Resource: "replicasets"}, "1.16"},
+	{schema.GroupVersionResource{Group: "extensions", Version: "v1beta1", Resource: "networkpolicies"}, "1.16"},
+	{schema.GroupVersionResource{Group: "extensions", Version: "v1beta1", Resource: "podsecuritypolicies"}, "1.16"},
+	{schema.GroupVersionResource{Group: "apps", Version: "v1beta1", Resource: "deployments"}, "1.16"},
+	{schema.GroupVersionResource{Group: "apps", Version: "v1beta1", Resource: "statefulsets"}, "1.16"},
+	{schema.GroupVersionResource{Group: "apps", Version: "v1beta2", Resource: "deployments"}, "1.16"},
+	{schema.GroupVersionResource{Group: "apps", Version: "v1beta2", Resource: "daemonsets"}, "1.16"},
+	{schema.GroupVersionResource{Group: "apps", Version: "v1beta2", Resource: "replicasets"}, "1.16"},
+	{schema.GroupVersionResource{Group: "apps", Version: "v1beta2", Resource: "statefulsets"}, "1.16"},
+	{schema.GroupVersionResource{Group: "admissionregistration.k8s.io", Version: "v1beta1", Resource: "mutatingwebhookconfigurations"}, "1.22"},
+	{schema.GroupVersionResource{Group: "admissionregistration.k8s.io", Version: "v1beta1", Resource: "validatingwebhookconfigurations"}, "1.22"},
+	{schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1beta1", Resource: "customresourcedefinitions"}, "1.22"},
+	{schema.GroupVersionResource{Group: "apiregistration.k8s.io", Version: "v1beta1", Resource: "apiservices"}, "1.22"},
+	{schema.GroupVersionResource{Group: "certificates.k8s.io", Version: "v1beta1", Resource: "certificatesigningrequests"}, "1.22"},
+	{schema.GroupVersionResource{Group: "coordination.k8s.io", Version: "v1beta1", Resource: "leases"}, "1.22"},
+	{schema.GroupVersionResource{Group: "extensions", Version: "v1beta1", Resource: "ingresses"}, "1.22"},
+	{schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1beta1", Resource: "ingresses"}, "1.22"},
+	{schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1beta1", Resource: "ingressclasses"}, "1.22"},
+	{schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1beta1", Resource: "clusterroles"}, "1.22"},
+	{schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1beta1", Resource: "clusterrolebindings"}, "1.22"},
+	{schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1beta1", Resource: "roles"}, "1.22"},
+	{schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1beta1", Resource: "rolebindings"}, "1.22"},
+	{schema.GroupVersionResource{Group: "scheduling.k8s.io", Version: "v1beta1", Resource: "priorityclasses"}, "1.22"},
+	{schema.GroupVersionResource{Group: "storage.k8s.io", Version: "v1beta1", Resource: "csidrivers"}, "1.22"},
+	{schema.GroupVersionResource{Group: "storage.k8s.io", Version: "v1beta1", Resource: "csinodes"}, "1.22"},
+	{schema.GroupVersionResource{Group: "storage.k8s.io", Version: "v1beta1", Resource: "storageclasses"}, "1.22"},
+	{schema.GroupVersionResource{Group: "storage.k8s.io", Version: "v1beta1", Resource: "volumeattachments"}, "1.22"},
+	{schema.GroupVersionResource{Group: "batch", Version: "v1beta1", Resource: "cronjobs"}, "1.25"},
+	{schema.GroupVersionResource{Group: "discovery.k8s.io", Version: "v1beta1", Resource: "endpointslices"}, "1.25"},
+	{schema.GroupVersionResource{Group: "autoscaling", Version: "v2beta1", Resource: "horizontalpodautoscalers"}, "1.25"},
+	{schema.GroupVersionResource{Group: "policy", Version: "v1beta1", Resource: "poddisruptionbudgets"}, "1.25"},
+	{schema.GroupVersionResource{Group: "policy", Version: "v1beta1", Resource: "podsecuritypolicies"}, "1.25"},
+	{schema.GroupVersionResource{Group: "node.k8s.io", Version: "v1beta1", Resource: "runtimeclasses"}, "1.25"},
+	{schema.GroupVersionResource{Group: "flowcontrol.apiserver.k8s.io", Version: "v1beta1", Resource: "flowschemas"}, "1.26"},
+	{schema.GroupVersionResource{Group: "flowcontrol.apiserver.k8s.io", Version: "v1beta1", Resource: "prioritylevelconfigurations"}, "1.26"},
+	{schema.GroupVersionResource{Group: "autoscaling", Version: "v2beta2", Resource: "horizontalpodautoscalers"}, "1.26"},
+	{schema.GroupVersionResource{Group: "storage.k8s.io", Version: "v1beta1", Resource: "csistoragecapacities"}, "1.27"},
+}
+
+// UpgradeCheck is a check of upgrade validation, the upgrade is not allowed unless all checks are passed.
+type UpgradeCheck struct {
+	Name   string
+	Passed bool
+	Detail string
+}
+
+// UpgradeReport is the go/no-go report of upgrading cluster from version From to To.
+type UpgradeReport struct {
+	From   string
+	To     string
+	Checks []UpgradeCheck
+}
+
+func (r *UpgradeReport) add(name string, err error, detail string) {
+	check := UpgradeCheck{Name: name, Passed: err == nil, Detail: detail}
+	if err != nil {
+		check.Detail = err.Error()
+	}
+	r.Checks = append(r.Checks, check)
+}
+
+// Go returns whether all checks are passed.
+func (r *UpgradeReport) Go() bool {
+	for _, c := range r.Checks {
+		if !c.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// Print prints the checks in table and the go/no-go result.
+func (r *UpgradeReport) Print() {
+	_, _ = fmt.Fprintf(common.StdOut, "Upgrade validation from %s to %s:\n", r.From, r.To)
+	table := tablewriter.NewWriter(common.StdOut)
+	table.SetHeader([]string{"CHECK", "RESULT", "DETAIL"})
+	table.SetAutoWrapText(false)
+	for _, c := range r.Checks {
+		result := "PASS"
+		if !c.Passed {
+			result = "FAIL"
+		}
+		table.Append([]string{c.Name, result, c.Detail})
+	}
+	table.Render()
+	if r.Go() {
+		_, _ = fmt.Fprintln(common.StdOut, "Result: GO")
+	} else {
+		_, _ = fmt.Fprintln(common.StdOut, "Result: NO-GO")
+	}
+}
+
+// validateUpgrade compares the running cluster with the metadata of target ClusterImage, and checks the version
+// skew, cluster runtime and the removed APIs still in use.
+func validateUpgrade(client *k8s.Client, currentVersion string, target *runtime.Metadata) (*UpgradeReport, error) {
+	report := &UpgradeReport{From: currentVersion, To: target.Version}
+	current, err := semver.NewVersion(currentVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse cluster version %s: %v", currentVersion, err)
+	}
+	desired, err := semver.NewVersion(target.Version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse target version %s: %v", target.Version, err)
+	}
+
+	report.add("control-plane version skew", checkVersionSkew(current, desired),
+		fmt.Sprintf("%s -> %s", current.Original(), desired.Original()))
+
+	kubeletDetail, err := checkKubeletSkew(client, desired)
+	report.add("kubelet version skew", err, kubeletDetail)
+
+	report.add("cluster runtime", checkClusterRuntime(currentVersion, target.ClusterRuntime),
+		string(clusterRuntimeOf(currentVersion)))
+
+	if target.KubeVersion != "" {
+		var err error
+		if !VersionCompatible(currentVersion, target.KubeVersion) {
+			err = fmt.Errorf("cluster version %s does not satisfy %q required by ClusterImage", currentVersion, target.KubeVersion)
+		}
+		report.add("required kubernetes version", err, target.KubeVersion)
+	}
+
+	apis, err := removedAPIsInUse(client, current, desired)
+	if err != nil {
+		return nil, err
+	}
+	var apiErr error
+	if len(apis) != 0 {
+		apiErr = fmt.Errorf("removed APIs are in use: %s", strings.Join(apis, "; "))
+	}
+	report.add("removed APIs", apiErr, "no removed API in use")
+	return report, nil
+}
+
+// checkVersionSkew only allows upgrading to a newer patch version, or the next minor version, as kubeadm does.
+func checkVersionSkew(current, desired *semver.Version) error {
+	if desired.Major() != current.Major() {
+		return fmt.Errorf("upgrading across major versions is not supported")
+	}
+	if desired.LessThan(current) {
+		return fmt.Errorf("downgrading from %s to %s is not supported", current.Original(), desired.Original())
+	}
+	if desired.Minor() > current.Minor()+1 {
+		return fmt.Errorf("skipping minor versions is not supported, upgrade to v%d.%d first", current.Major(), current.Minor()+1)
+	}
+	return nil
+}
+
+// checkKubeletSkew checks the kubelet of all nodes is not older than desired by MaxKubeletSkew minor versions.
+func checkKubeletSkew(client *k8s.Client, desired *semver.Version) (string, error) {
+	nodes, err := client.ListNodes()
+	if err != nil {
+		return "", err
+	}
+	var tooOld []string
+	for _, node := range nodes.Items {
+		kubelet, err := semver.NewVersion(node.Status.NodeInfo.KubeletVersion)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse kubelet version of node %s: %v", node.Name, err)
+		}
+		if kubelet.Major() != desired.Major() || kubelet.Minor()+MaxKubeletSkew < desired.Minor() {
+			tooOld = append(tooOld, fmt.Sprintf("%s(%s)", node.Name, kubelet.Original()))
+		}
+	}
+	if len(tooOld) != 0 {
+		return "", fmt.Errorf("kubelet of nodes %s is older than %s by more than %d minor versions",
+			strings.Join(tooOld, ", "), desired.Original(), MaxKubeletSkew)
+	}
+	return fmt.Sprintf("kubelet of %d nodes is within %d minor versions", len(nodes.Items), MaxKubeletSkew), nil
+}
+
+// clusterRuntimeOf returns the cluster runtime by the git version of apiserver, like v1.21.4+k3s1.
+func clusterRuntimeOf(gitVersion string) runtime.ClusterRuntime {
+	switch {
+	case strings.Contains(gitVersion, "+"+string(runtime.K3s)):
+		return runtime.K3s
+	case strings.Contains(gitVersion, "+"+string(runtime.K0s)):
+		return runtime.K0s
+	default:
+		return runtime.K8s
+	}
+}
+
+// checkClusterRuntime checks the ClusterImage is of the runtime of cluster, the runtime is k8s if it is not set.
+func checkClusterRuntime(currentVersion string, target runtime.ClusterRuntime) error {
+	if target == "" {
+		target = runtime.K8s
+	}
+	if current := clusterRuntimeOf(currentVersion); current != target {
+		return fmt.Errorf("cluster runtime %s cannot be upgraded by ClusterImage of runtime %s", current, target)
+	}
+	return nil
+}
+
+// removedAPIsInUse returns the removed APIs between current and desired, with the objects written in them.
+func removedAPIsInUse(client *k8s.Client, current, desired *semver.Version) ([]string, error) {
+	apis, err := apisRemovedBetween(current, desired)
+	if err != nil {
+		return nil, err
+	}
+	var inUse []string
+	for _, api := range apis {
+		objects, err := client.ListObjectsInAPIVersion(api.GroupVersionResource)
+		if err != nil {
+			return nil, err
+		}
+		if len(objects) != 0 {
+			inUse = append(inUse, fmt.Sprintf("%s %s removed in %s: %s",
+				api.GroupVersion(), api.Resource, api.RemovedIn, strings.Join(objects, ", ")))
+		}
+	}
+	return inUse, nil
+}
+
+// apisRemovedBetween returns the APIs which are served by current but removed by desired.
+func apisRemovedBetween(current, desired *semver.Version) ([]removedAPI, error) {
+	var apis []removedAPI
+	for _, api := range removedAPIs {
+		removedIn, err := semver.NewVersion(api.RemovedIn)
+		if err != nil {
+			return nil, err
+		}
+		if isRemovedBetween(removedIn, current, desired) {
+			apis = append(apis, api)
+		}
+	}
+	return apis, nil
+}
+
+// isRemovedBetween returns whether the API removed in version removedIn is removed by upgrading from current to
+// desired, that is current < removedIn <= desired.
+func isRemovedBetween(removedIn, current, desired *semver.Version) bool {
+	return removedIn.GreaterThan(current) && !removedIn.GreaterThan(desired)
+}
@@ -0,0 +1,142 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package driver
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+
+	"github.com/sealerio/sealer/pkg/runtime"
+)
+
+func TestCheckVersionSkew(t *testing.T) {
+	tests := []struct {
+		name    string
+		current string
+		desired string
+		wantErr bool
+	}{
+		{"same version", "v1.22.3", "v1.22.3", false},
+		{"newer patch version", "v1.22.3", "v1.22.8", false},
+		{"next minor version", "v1.22.3", "v1.23.0", false},
+		{"next minor version with older patch", "v1.22.8", "v1.23.1", false},
+		{"skipping a minor version", "v1.22.3", "v1.24.0", true},
+		{"older patch version", "v1.22.3", "v1.22.2", true},
+		{"older minor version", "v1.22.3", "v1.21.9", true},
+		{"next major version", "v1.22.3", "v2.0.0", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkVersionSkew(semver.MustParse(tt.current), semver.MustParse(tt.desired))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkVersionSkew(%s, %s) error = %v, wantErr %v", tt.current, tt.desired, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClusterRuntimeOf(t *testing.T) {
+	tests := []struct {
+		gitVersion string
+		want       runtime.ClusterRuntime
+	}{
+		{"v1.22.3", runtime.K8s},
+		{"v1.21.4+k3s1", runtime.K3s},
+		{"v1.23.6+k0s", runtime.K0s},
+		{"v1.22.3-aliyun.1", runtime.K8s},
+	}
+	for _, tt := range tests {
+		t.Run(tt.gitVersion, func(t *testing.T) {
+			if got := clusterRuntimeOf(tt.gitVersion); got != tt.want {
+				t.Errorf("clusterRuntimeOf(%s) = %v, want %v", tt.gitVersion, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckClusterRuntime(t *testing.T) {
+	tests := []struct {
+		name       string
+		gitVersion string
+		target     runtime.ClusterRuntime
+		wantErr    bool
+	}{
+		{"k8s by default", "v1.22.3", "", false},
+		{"same runtime", "v1.21.4+k3s1", runtime.K3s, false},
+		{"k3s cluster with k8s image", "v1.21.4+k3s1", "", true},
+		{"k8s cluster with k0s image", "v1.22.3", runtime.K0s, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkClusterRuntime(tt.gitVersion, tt.target); (err != nil) != tt.wantErr {
+				t.Errorf("checkClusterRuntime() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIsRemovedBetween(t *testing.T) {
+	tests := []struct {
+		name      string
+		removedIn string
+		current   string
+		desired   string
+		want      bool
+	}{
+		{"removed by the upgrade", "1.22", "v1.21.9", "v1.22.0", true},
+		{"removed by a newer patch of target", "1.22", "v1.21.9", "v1.22.3", true},
+		{"already removed", "1.22", "v1.22.0", "v1.23.0", false},
+		{"removed after target", "1.25", "v1.22.3", "v1.23.0", false},
+		{"patch upgrade", "1.22", "v1.21.2", "v1.21.9", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := isRemovedBetween(semver.MustParse(tt.removedIn), semver.MustParse(tt.current), semver.MustParse(tt.desired))
+			if got != tt.want {
+				t.Errorf("isRemovedBetween(%s, %s, %s) = %v, want %v", tt.removedIn, tt.current, tt.desired, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIsRemovedBetween(t *testing.T) {
+	tests := []struct {
+		name          string
+		current       string
+		desired       string
+		wantRemovedIn []string
+	}{
+		{"1.24 to 1.25", "v1.24.6", "v1.25.2", []string{"1.25", "1.25", "1.25", "1.25", "1.25", "1.25"}},
+		{"1.25 to 1.26", "v1.25.2", "v1.26.0", []string{"1.26", "1.26", "1.26"}},
+		{"1.23 to 1.24", "v1.23.1", "v1.24.0", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apis, err := apisRemovedBetween(semver.MustParse(tt.current), semver.MustParse(tt.desired))
+			if err != nil {
+				t.Fatalf("apisRemovedBetween() error = %v", err)
+			}
+			var got []string
+			for _, api := range apis {
+				got = append(got, api.RemovedIn)
+			}
+			if !reflect.DeepEqual(got, tt.wantRemovedIn) {
+				t.Errorf("apisRemovedBetween() removed in %v, want %v", got, tt.wantRemovedIn)
+			}
+		})
+	}
+}
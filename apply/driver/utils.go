@@ -17,6 +17,8 @@ package driver
 import (
 	"fmt"
 	"net"
+	"strings"
+	"time"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/sirupsen/logrus"
@@ -25,7 +27,9 @@ import (
 	"github.com/sealerio/sealer/common"
 	"github.com/sealerio/sealer/pkg/client/k8s"
 	v2 "github.com/sealerio/sealer/types/api/v2"
+	"github.com/sealerio/sealer/utils"
 	utilsnet "github.com/sealerio/sealer/utils/net"
+	osi "github.com/sealerio/sealer/utils/os"
 )
 
 const MasterRoleLabel = "node-role.kubernetes.io/master"
@@ -59,6 +63,31 @@ func GetCurrentCluster(client *k8s.Client) (*v2.Cluster, error) {
 	return cluster, nil
 }
 
+// getCurrentEtcdIPList returns the external etcd hosts of the running cluster recorded in its Clusterfile.
+func getCurrentEtcdIPList(clusterName string) ([]net.IP, error) {
+	cluster, err := getClusterFromDisk(clusterName)
+	if err != nil || cluster == nil {
+		return nil, err
+	}
+	return cluster.GetEtcdIPList(), nil
+}
+
+// getClusterFromDisk loads the Clusterfile of the running cluster, nil is returned if there is none.
+func getClusterFromDisk(clusterName string) (*v2.Cluster, error) {
+	clusterfile := common.GetClusterWorkClusterfile(clusterName)
+	if !osi.IsFileExist(clusterfile) {
+		return nil, nil
+	}
+	obj, err := utils.DecodeCRDFromFile(clusterfile, common.Cluster)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load current cluster from %s: %v", clusterfile, err)
+	}
+	if obj == nil {
+		return nil, nil
+	}
+	return obj.(*v2.Cluster), nil
+}
+
 func DeleteNodes(client *k8s.Client, nodeIPs []net.IP) error {
 	logrus.Infof("delete nodes %s", nodeIPs)
 	nodes, err := client.ListNodes()
@@ -77,6 +106,61 @@ func DeleteNodes(client *k8s.Client, nodeIPs []net.IP) error {
 	return nil
 }
 
+// getNodeLabels returns the labels of node ip set by users, the labels of kubernetes.io and k8s.io are skipped,
+// which are managed by kubelet and kubeadm.
+func getNodeLabels(client *k8s.Client, ip net.IP) (map[string]string, error) {
+	node, err := getNodeByIP(client, ip)
+	if err != nil || node == nil {
+		return nil, err
+	}
+	labels := map[string]string{}
+	for k, v := range node.Labels {
+		prefix := strings.Split(k, "/")[0]
+		if strings.Contains(k, "/") && (strings.HasSuffix(prefix, "kubernetes.io") || strings.HasSuffix(prefix, "k8s.io")) {
+			continue
+		}
+		labels[k] = v
+	}
+	return labels, nil
+}
+
+// setNodeLabels adds labels to node ip, it waits for the node to be registered.
+func setNodeLabels(client *k8s.Client, ip net.IP, labels map[string]string) error {
+	if len(labels) == 0 {
+		return nil
+	}
+	return utils.Retry(10, 3*time.Second, func() error {
+		node, err := getNodeByIP(client, ip)
+		if err != nil {
+			return err
+		}
+		if node == nil {
+			return fmt.Errorf("node %s is not registered", ip)
+		}
+		if node.Labels == nil {
+			node.Labels = map[string]string{}
+		}
+		for k, v := range labels {
+			node.Labels[k] = v
+		}
+		_, err = client.UpdateNode(*node)
+		return err
+	})
+}
+
+func getNodeByIP(client *k8s.Client, ip net.IP) (*corev1.Node, error) {
+	nodes, err := client.ListNodes()
+	if err != nil {
+		return nil, err
+	}
+	for i := range nodes.Items {
+		if addr := getNodeAddress(nodes.Items[i]); addr != nil && addr.Equal(ip) {
+			return &nodes.Items[i], nil
+		}
+	}
+	return nil, nil
+}
+
 func getNodeAddress(node corev1.Node) net.IP {
 	if len(node.Status.Addresses) < 1 {
 		return nil
@@ -30,7 +30,6 @@ import (
 	"github.com/sealerio/sealer/pkg/runtime/kubernetes"
 	v1 "github.com/sealerio/sealer/types/api/v1"
 	v2 "github.com/sealerio/sealer/types/api/v2"
-	"github.com/sealerio/sealer/utils/net"
 	"github.com/sealerio/sealer/utils/platform"
 	"github.com/sealerio/sealer/utils/ssh"
 )
@@ -65,6 +64,7 @@ func (c *CreateProcessor) GetPipeLine() ([]func(cluster *v2.Cluster) error, erro
 }
 
 func (c *CreateProcessor) PreProcess(cluster *v2.Cluster) error {
+	registry.RecordNode(cluster)
 	c.Config = config.NewConfiguration(cluster)
 	if err := c.initPlugin(cluster); err != nil {
 		return err
@@ -106,12 +106,7 @@ func (c *CreateProcessor) RunConfig(cluster *v2.Cluster) error {
 }
 
 func (c *CreateProcessor) MountRootfs(cluster *v2.Cluster) error {
-	hosts := cluster.GetAllIPList()
-	regConfig := registry.GetConfig(platform.DefaultMountClusterImageDir(cluster.Name), cluster.GetMaster0IP())
-	if net.NotInIPList(regConfig.IP, hosts) {
-		hosts = append(hosts, regConfig.IP)
-	}
-
+	hosts := getRootfsHosts(cluster, platform.DefaultMountClusterImageDir(cluster.Name))
 	fs, err := filesystem.NewFilesystem(platform.DefaultMountClusterImageDir(cluster.Name))
 	if err != nil {
 		return err
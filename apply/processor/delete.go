@@ -17,8 +17,6 @@ package processor
 import (
 	"fmt"
 
-	"github.com/sealerio/sealer/pkg/registry"
-
 	"github.com/sealerio/sealer/common"
 	"github.com/sealerio/sealer/pkg/clusterfile"
 	"github.com/sealerio/sealer/pkg/filesystem"
@@ -27,7 +25,6 @@ import (
 	"github.com/sealerio/sealer/pkg/plugin"
 	"github.com/sealerio/sealer/pkg/runtime/kubernetes"
 	v2 "github.com/sealerio/sealer/types/api/v2"
-	utilsnet "github.com/sealerio/sealer/utils/net"
 )
 
 type DeleteProcessor struct {
@@ -66,11 +63,7 @@ func (d *DeleteProcessor) GetPhasePluginFunc(phase plugin.Phase) func(cluster *v
 }
 
 func (d *DeleteProcessor) UnMountRootfs(cluster *v2.Cluster) error {
-	hosts := cluster.GetAllIPList()
-	config := registry.GetConfig(common.DefaultTheClusterRootfsDir(cluster.Name), cluster.GetMaster0IP())
-	if utilsnet.NotInIPList(config.IP, hosts) {
-		hosts = append(hosts, config.IP)
-	}
+	hosts := getRootfsHosts(cluster, common.DefaultTheClusterRootfsDir(cluster.Name))
 	fs, err := filesystem.NewFilesystem(common.DefaultTheClusterRootfsDir(cluster.Name))
 	if err != nil {
 		return err
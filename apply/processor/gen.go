@@ -21,8 +21,6 @@ import (
 	"net"
 	"strconv"
 
-	"github.com/sealerio/sealer/pkg/registry"
-
 	"github.com/sealerio/sealer/common"
 	"github.com/sealerio/sealer/pkg/client/k8s"
 	"github.com/sealerio/sealer/pkg/clusterfile"
@@ -32,7 +30,6 @@ import (
 	"github.com/sealerio/sealer/pkg/runtime/kubernetes"
 	apiv1 "github.com/sealerio/sealer/types/api/v1"
 	v2 "github.com/sealerio/sealer/types/api/v2"
-	utilsnet "github.com/sealerio/sealer/utils/net"
 	"github.com/sealerio/sealer/utils/platform"
 	"github.com/sealerio/sealer/utils/ssh"
 
@@ -147,11 +144,7 @@ func (g *GenerateProcessor) MountRootfs(cluster *v2.Cluster) error {
 	if err != nil {
 		return err
 	}
-	hosts := cluster.GetAllIPList()
-	regConfig := registry.GetConfig(common.DefaultTheClusterRootfsDir(cluster.Name), cluster.GetMaster0IP())
-	if utilsnet.NotInIPList(regConfig.IP, hosts) {
-		hosts = append(hosts, regConfig.IP)
-	}
+	hosts := getRootfsHosts(cluster, common.DefaultTheClusterRootfsDir(cluster.Name))
 	return fs.MountRootfs(cluster, hosts, false)
 }
 
@@ -15,7 +15,11 @@
 package processor
 
 import (
+	"net"
+
+	"github.com/sealerio/sealer/pkg/registry"
 	v2 "github.com/sealerio/sealer/types/api/v2"
+	utilsnet "github.com/sealerio/sealer/utils/net"
 )
 
 type Interface interface {
@@ -48,3 +52,16 @@ func (e *Executor) Execute(cluster *v2.Cluster) error {
 	}
 	return nil
 }
+
+// getRootfsHosts returns the hosts of cluster and the hosts running the built-in registry, which need the
+// rootfs under rootfs mounted, the external registry runs on no host of cluster.
+func getRootfsHosts(cluster *v2.Cluster, rootfs string) []net.IP {
+	hosts := cluster.GetAllIPList()
+	regConfig := registry.GetClusterConfig(rootfs, cluster)
+	for _, ip := range regConfig.GetRegistryNodes(cluster.GetMasterIPList()) {
+		if ip != nil && utilsnet.NotInIPList(ip, hosts) {
+			hosts = append(hosts, ip)
+		}
+	}
+	return hosts
+}
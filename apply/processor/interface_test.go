@@ -0,0 +1,98 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/sealerio/sealer/common"
+	"github.com/sealerio/sealer/pkg/registry"
+	v2 "github.com/sealerio/sealer/types/api/v2"
+)
+
+func TestGetRootfsHosts(t *testing.T) {
+	ips := func(ss ...string) []net.IP {
+		var res []net.IP
+		for _, s := range ss {
+			res = append(res, net.ParseIP(s))
+		}
+		return res
+	}
+	newCluster := func(annotations map[string]string) *v2.Cluster {
+		cluster := &v2.Cluster{}
+		cluster.Annotations = annotations
+		cluster.Spec.Hosts = []v2.Host{
+			{Roles: []string{common.MASTER}, IPS: ips("192.168.0.2", "192.168.0.3")},
+			{Roles: []string{common.NODE}, IPS: ips("192.168.0.4")},
+		}
+		return cluster
+	}
+	tests := []struct {
+		name    string
+		config  string
+		cluster *v2.Cluster
+		want    []net.IP
+	}{
+		{
+			name:    "external registry",
+			config:  "domain: harbor.example.com\nexternal: true\nproject: sealer\n",
+			cluster: newCluster(nil),
+			want:    ips("192.168.0.2", "192.168.0.3", "192.168.0.4"),
+		},
+		{
+			name:    "built-in registry on master0",
+			cluster: newCluster(nil),
+			want:    ips("192.168.0.2", "192.168.0.3", "192.168.0.4"),
+		},
+		{
+			name:    "built-in registry on removed master",
+			cluster: newCluster(map[string]string{registry.NodeAnnotation: "192.168.0.1"}),
+			want:    ips("192.168.0.2", "192.168.0.3", "192.168.0.4", "192.168.0.1"),
+		},
+		{
+			name:    "HA registry on nodes",
+			config:  "ha: true\nnodes:\n- 192.168.0.3\n- 192.168.0.5\n",
+			cluster: newCluster(nil),
+			want:    ips("192.168.0.2", "192.168.0.3", "192.168.0.4", "192.168.0.5"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rootfs, err := ioutil.TempDir("", "sealer-rootfs")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() {
+				_ = os.RemoveAll(rootfs)
+			}()
+			if tt.config != "" {
+				if err = os.MkdirAll(filepath.Join(rootfs, common.EtcDir), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err = ioutil.WriteFile(filepath.Join(rootfs, common.EtcDir, registry.ConfigFile), []byte(tt.config), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := getRootfsHosts(tt.cluster, rootfs); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getRootfsHosts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
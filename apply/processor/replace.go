@@ -0,0 +1,150 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/sealerio/sealer/common"
+	"github.com/sealerio/sealer/pkg/clusterfile"
+	"github.com/sealerio/sealer/pkg/config"
+	"github.com/sealerio/sealer/pkg/filesystem"
+	"github.com/sealerio/sealer/pkg/filesystem/cloudfilesystem"
+	"github.com/sealerio/sealer/pkg/plugin"
+	"github.com/sealerio/sealer/pkg/runtime"
+	"github.com/sealerio/sealer/pkg/runtime/kubernetes"
+	v2 "github.com/sealerio/sealer/types/api/v2"
+	strUtils "github.com/sealerio/sealer/utils/strings"
+)
+
+// ReplaceProcessor removes the old host, which may be unreachable, from cluster and joins the new host in
+// the same roles.
+type ReplaceProcessor struct {
+	fileSystem  cloudfilesystem.Interface
+	ClusterFile clusterfile.Interface
+	// Runtime joins the new host, it is based on the cluster with the old host replaced.
+	Runtime runtime.Interface
+	// DeleteRuntime deletes the old host, it is based on the cluster with the old host appended, so the ssh
+	// config of the old host is kept and it never becomes master0.
+	DeleteRuntime runtime.Interface
+	Config        config.Interface
+	Plugins       plugin.Plugins
+	OldHost       v2.Host
+	NewHost       net.IP
+}
+
+func (r *ReplaceProcessor) GetPipeLine() ([]func(cluster *v2.Cluster) error, error) {
+	var todoList []func(cluster *v2.Cluster) error
+	todoList = append(todoList,
+		r.PreProcess,
+		r.Delete,
+		r.GetPhasePluginFunc(plugin.PhaseOriginally),
+		r.RunConfig,
+		r.MountRootfs,
+		r.GetPhasePluginFunc(plugin.PhasePreJoin),
+		r.Join,
+		r.GetPhasePluginFunc(plugin.PhasePreGuest),
+		r.GetPhasePluginFunc(plugin.PhasePostJoin),
+	)
+	return todoList, nil
+}
+
+func (r *ReplaceProcessor) PreProcess(cluster *v2.Cluster) error {
+	kubeadmConfig := r.ClusterFile.GetKubeadmConfig()
+	runTime, err := kubernetes.NewDefaultRuntime(cluster, kubeadmConfig)
+	if err != nil {
+		return fmt.Errorf("failed to init default runtime: %v", err)
+	}
+	r.Runtime = runTime
+
+	deleteCluster := cluster.DeepCopy()
+	deleteCluster.Spec.Hosts = append(deleteCluster.Spec.Hosts, r.OldHost)
+	if r.DeleteRuntime, err = kubernetes.NewDefaultRuntime(deleteCluster, kubeadmConfig); err != nil {
+		return fmt.Errorf("failed to init default runtime: %v", err)
+	}
+
+	r.Config = config.NewConfiguration(cluster)
+	r.Plugins = plugin.NewPlugins(cluster, r.ClusterFile.GetPlugins())
+	return r.Plugins.Load()
+}
+
+func (r *ReplaceProcessor) GetPhasePluginFunc(phase plugin.Phase) func(cluster *v2.Cluster) error {
+	return func(cluster *v2.Cluster) error {
+		return r.Plugins.Run([]net.IP{r.NewHost}, phase)
+	}
+}
+
+func (r *ReplaceProcessor) RunConfig(cluster *v2.Cluster) error {
+	return r.Config.Dump(r.ClusterFile.GetConfigs())
+}
+
+func (r *ReplaceProcessor) MountRootfs(cluster *v2.Cluster) error {
+	return r.fileSystem.MountRootfs(cluster, []net.IP{r.NewHost}, true)
+}
+
+func (r *ReplaceProcessor) Delete(cluster *v2.Cluster) error {
+	old := r.OldHost.IPS
+	if r.hasRole(common.MASTER) {
+		if err := r.DeleteRuntime.ForceDeleteMasters(old); err != nil {
+			return err
+		}
+	}
+	if r.hasRole(common.NODE) {
+		if err := r.DeleteRuntime.ForceDeleteNodes(old); err != nil {
+			return err
+		}
+	}
+	if r.hasRole(common.ETCD) {
+		return r.DeleteRuntime.DeleteEtcds(old)
+	}
+	return nil
+}
+
+func (r *ReplaceProcessor) Join(cluster *v2.Cluster) error {
+	hosts := []net.IP{r.NewHost}
+	if r.hasRole(common.ETCD) {
+		if err := r.Runtime.JoinEtcds(hosts); err != nil {
+			return err
+		}
+	}
+	if r.hasRole(common.MASTER) {
+		if err := r.Runtime.JoinMasters(hosts); err != nil {
+			return err
+		}
+	}
+	if r.hasRole(common.NODE) {
+		return r.Runtime.JoinNodes(hosts)
+	}
+	return nil
+}
+
+func (r *ReplaceProcessor) hasRole(role string) bool {
+	return !strUtils.NotIn(role, r.OldHost.Roles)
+}
+
+func NewReplaceProcessor(clusterFile clusterfile.Interface, oldHost v2.Host, newHost net.IP) (Processor, error) {
+	fs, err := filesystem.NewFilesystem(common.DefaultTheClusterRootfsDir(clusterFile.GetCluster().Name))
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReplaceProcessor{
+		ClusterFile: clusterFile,
+		OldHost:     oldHost,
+		NewHost:     newHost,
+		fileSystem:  fs,
+	}, nil
+}
@@ -42,6 +42,8 @@ type ScaleProcessor struct {
 	MastersToDelete []net.IP
 	NodesToJoin     []net.IP
 	NodesToDelete   []net.IP
+	EtcdsToJoin     []net.IP
+	EtcdsToDelete   []net.IP
 	IsScaleUp       bool
 }
 
@@ -113,6 +115,10 @@ func (s *ScaleProcessor) UnMountRootfs(cluster *v2.Cluster) error {
 }
 
 func (s *ScaleProcessor) Join(cluster *v2.Cluster) error {
+	// etcd members are joined first, so the masters to join are pointed to all of them.
+	if err := s.Runtime.JoinEtcds(s.EtcdsToJoin); err != nil {
+		return err
+	}
 	if err := s.Runtime.JoinMasters(s.MastersToJoin); err != nil {
 		return err
 	}
@@ -124,10 +130,13 @@ func (s *ScaleProcessor) Delete(cluster *v2.Cluster) error {
 	if err != nil {
 		return err
 	}
-	return s.Runtime.DeleteNodes(s.NodesToDelete)
+	if err = s.Runtime.DeleteNodes(s.NodesToDelete); err != nil {
+		return err
+	}
+	return s.Runtime.DeleteEtcds(s.EtcdsToDelete)
 }
 
-func NewScaleProcessor(kubeadmConfig *kubeadm.KubeadmConfig, clusterFile clusterfile.Interface, masterToJoin, masterToDelete, nodeToJoin, nodeToDelete []net.IP) (Processor, error) {
+func NewScaleProcessor(kubeadmConfig *kubeadm.KubeadmConfig, clusterFile clusterfile.Interface, masterToJoin, masterToDelete, nodeToJoin, nodeToDelete, etcdToJoin, etcdToDelete []net.IP) (Processor, error) {
 	fs, err := filesystem.NewFilesystem(common.DefaultTheClusterRootfsDir(clusterFile.GetCluster().Name))
 	if err != nil {
 		return nil, err
@@ -135,7 +144,7 @@ func NewScaleProcessor(kubeadmConfig *kubeadm.KubeadmConfig, clusterFile cluster
 
 	var up bool
 	// only scale up or scale down at a time
-	if len(masterToJoin) > 0 || len(nodeToJoin) > 0 {
+	if len(masterToJoin) > 0 || len(nodeToJoin) > 0 || len(etcdToJoin) > 0 {
 		up = true
 	}
 
@@ -144,6 +153,8 @@ func NewScaleProcessor(kubeadmConfig *kubeadm.KubeadmConfig, clusterFile cluster
 		MastersToJoin:   masterToJoin,
 		NodesToDelete:   nodeToDelete,
 		NodesToJoin:     nodeToJoin,
+		EtcdsToDelete:   etcdToDelete,
+		EtcdsToJoin:     etcdToJoin,
 		KubeadmConfig:   kubeadmConfig,
 		ClusterFile:     clusterFile,
 		IsScaleUp:       up,
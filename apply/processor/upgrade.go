@@ -18,10 +18,8 @@ import (
 	"github.com/sealerio/sealer/common"
 	"github.com/sealerio/sealer/pkg/filesystem"
 	"github.com/sealerio/sealer/pkg/filesystem/cloudfilesystem"
-	"github.com/sealerio/sealer/pkg/registry"
 	"github.com/sealerio/sealer/pkg/runtime"
 	v2 "github.com/sealerio/sealer/types/api/v2"
-	"github.com/sealerio/sealer/utils/net"
 )
 
 type UpgradeProcessor struct {
@@ -45,11 +43,7 @@ func (u UpgradeProcessor) Execute(cluster *v2.Cluster) error {
 
 func (u UpgradeProcessor) MountRootfs(cluster *v2.Cluster) error {
 	//some hosts already mounted when scaled cluster.
-	hosts := cluster.GetAllIPList()
-	regConfig := registry.GetConfig(common.DefaultTheClusterRootfsDir(cluster.Name), cluster.GetMaster0IP())
-	if net.NotInIPList(regConfig.IP, hosts) {
-		hosts = append(hosts, regConfig.IP)
-	}
+	hosts := getRootfsHosts(cluster, common.DefaultTheClusterRootfsDir(cluster.Name))
 	return u.fileSystem.MountRootfs(cluster, hosts, false)
 }
 
@@ -0,0 +1,88 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apply
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/sealerio/sealer/common"
+	v2 "github.com/sealerio/sealer/types/api/v2"
+	utilsnet "github.com/sealerio/sealer/utils/net"
+	strUtils "github.com/sealerio/sealer/utils/strings"
+	"github.com/sealerio/sealer/utils/yaml"
+)
+
+// Replace replaces the host oldIP of cluster in clusterfile by the host newIP, and applies it. The Clusterfile
+// is updated only if the new host is joined successfully.
+func Replace(clusterfile string, oldIP, newIP string) error {
+	oIP, nIP := net.ParseIP(oldIP), net.ParseIP(newIP)
+	if oIP == nil || nIP == nil {
+		return fmt.Errorf("invalid ip of host to replace: old %s, new %s", oldIP, newIP)
+	}
+
+	cluster := &v2.Cluster{}
+	if err := yaml.UnmarshalFile(clusterfile, cluster); err != nil {
+		return err
+	}
+	oldHost, err := ReplaceHost(cluster, oIP, nIP)
+	if err != nil {
+		return err
+	}
+
+	applier, err := NewDefaultApplier(cluster)
+	if err != nil {
+		return err
+	}
+	return applier.Replace(oldHost, nIP)
+}
+
+// ReplaceHost removes oldIP from the hosts of cluster and appends newIP with the roles, env, ssh config, labels,
+// taints and kubelet args of oldIP, so the next master becomes master0 if oldIP is master0. It returns the host of oldIP.
+func ReplaceHost(cluster *v2.Cluster, oldIP, newIP net.IP) (v2.Host, error) {
+	for _, host := range cluster.Spec.Hosts {
+		if !utilsnet.NotInIPList(newIP, host.IPS) {
+			return v2.Host{}, fmt.Errorf("host %s is in cluster already", newIP)
+		}
+	}
+
+	var hosts []v2.Host
+	var oldHost *v2.Host
+	for _, host := range cluster.Spec.Hosts {
+		if utilsnet.NotInIPList(oldIP, host.IPS) {
+			hosts = append(hosts, host)
+			continue
+		}
+		oldHost = host.DeepCopy()
+		oldHost.IPS = []net.IP{oldIP}
+		if ips := returnFilteredIPList(host.IPS, []net.IP{oldIP}); len(ips) != 0 {
+			host.IPS = ips
+			hosts = append(hosts, host)
+		}
+	}
+	if oldHost == nil {
+		return v2.Host{}, fmt.Errorf("host %s is not in cluster %s", oldIP, cluster.Name)
+	}
+	for _, role := range []string{common.MASTER, common.ETCD} {
+		if !strUtils.NotIn(role, oldHost.Roles) && len(cluster.GetIPSByRole(role)) == 1 {
+			return v2.Host{}, fmt.Errorf("failed to replace %s: it is the only %s of cluster", oldIP, role)
+		}
+	}
+
+	newHost := *oldHost.DeepCopy()
+	newHost.IPS = []net.IP{newIP}
+	cluster.Spec.Hosts = append(hosts, newHost)
+	return *oldHost, nil
+}
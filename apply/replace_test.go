@@ -0,0 +1,93 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apply
+
+import (
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/sealerio/sealer/common"
+	v1 "github.com/sealerio/sealer/types/api/v1"
+	v2 "github.com/sealerio/sealer/types/api/v2"
+)
+
+func newReplaceTestCluster() *v2.Cluster {
+	cluster := &v2.Cluster{}
+	cluster.Name = "my-cluster"
+	cluster.Spec.Hosts = []v2.Host{
+		{IPS: []net.IP{net.ParseIP("192.168.0.2"), net.ParseIP("192.168.0.3")}, Roles: []string{common.MASTER},
+			SSH: v1.SSH{Port: "2222"}, Env: []string{"key=value"}},
+		{IPS: []net.IP{net.ParseIP("192.168.0.5")}, Roles: []string{common.NODE}},
+	}
+	return cluster
+}
+
+func TestReplaceHost(t *testing.T) {
+	tests := []struct {
+		name        string
+		old         string
+		new         string
+		wantMaster0 string
+		wantMasters []string
+		wantNodes   []string
+		wantErr     bool
+	}{
+		{"replace master0", "192.168.0.2", "192.168.0.10", "192.168.0.3", []string{"192.168.0.3", "192.168.0.10"}, []string{"192.168.0.5"}, false},
+		{"replace node", "192.168.0.5", "192.168.0.10", "192.168.0.2", []string{"192.168.0.2", "192.168.0.3"}, []string{"192.168.0.10"}, false},
+		{"old not in cluster", "192.168.0.9", "192.168.0.10", "", nil, nil, true},
+		{"new in cluster", "192.168.0.2", "192.168.0.5", "", nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := newReplaceTestCluster()
+			oldHost, err := ReplaceHost(cluster, net.ParseIP(tt.old), net.ParseIP(tt.new))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReplaceHost() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !oldHost.IPS[0].Equal(net.ParseIP(tt.old)) {
+				t.Errorf("ReplaceHost() old host = %s, want %s", oldHost.IPS, tt.old)
+			}
+			if got := cluster.GetMaster0IP().String(); got != tt.wantMaster0 {
+				t.Errorf("ReplaceHost() master0 = %s, want %s", got, tt.wantMaster0)
+			}
+			if got := cluster.GetMasterIPStrList(); !reflect.DeepEqual(got, tt.wantMasters) {
+				t.Errorf("ReplaceHost() masters = %v, want %v", got, tt.wantMasters)
+			}
+			var nodes []string
+			for _, ip := range cluster.GetNodeIPList() {
+				nodes = append(nodes, ip.String())
+			}
+			if !reflect.DeepEqual(nodes, tt.wantNodes) {
+				t.Errorf("ReplaceHost() nodes = %v, want %v", nodes, tt.wantNodes)
+			}
+			newHost := cluster.Spec.Hosts[len(cluster.Spec.Hosts)-1]
+			if !reflect.DeepEqual(newHost.SSH, oldHost.SSH) || !reflect.DeepEqual(newHost.Env, oldHost.Env) {
+				t.Errorf("ReplaceHost() new host = %+v, want ssh and env of %+v", newHost, oldHost)
+			}
+		})
+	}
+}
+
+func TestReplaceOnlyMaster(t *testing.T) {
+	cluster := newReplaceTestCluster()
+	cluster.Spec.Hosts[0].IPS = cluster.Spec.Hosts[0].IPS[:1]
+	if _, err := ReplaceHost(cluster, net.ParseIP("192.168.0.2"), net.ParseIP("192.168.0.10")); err == nil {
+		t.Errorf("ReplaceHost() should fail to replace the only master")
+	}
+}
@@ -203,10 +203,10 @@ func deleteBaremetalNodes(cluster *v2.Cluster, scaleArgs *Args) error {
 		return fmt.Errorf("parameter error: current mode should submit iplist")
 	}
 
-	//master0 machine cannot be deleted
+	//at least one master must be kept, any of the remaining masters can drive the cluster
 	scaleMasterIPs := utilsnet.IPStrsToIPs(strings.Split(scaleArgs.Masters, ","))
-	if !utilsnet.NotInIPList(cluster.GetMaster0IP(), scaleMasterIPs) {
-		return fmt.Errorf("master0 machine(%s) cannot be deleted", cluster.GetMaster0IP())
+	if len(returnFilteredIPList(cluster.GetMasterIPList(), scaleMasterIPs)) == 0 {
+		return fmt.Errorf("all masters(%s) of cluster cannot be deleted", cluster.GetMasterIPList())
 	}
 
 	if scaleArgs.Masters != "" && utilsnet.IsIPList(scaleArgs.Masters) {
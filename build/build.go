@@ -16,6 +16,7 @@ package build
 
 import (
 	"github.com/sealerio/sealer/build/buildimage"
+	"github.com/sealerio/sealer/build/buildinstruction"
 	"github.com/sealerio/sealer/common"
 	"github.com/sealerio/sealer/pkg/image/reference"
 	v1 "github.com/sealerio/sealer/types/api/v1"
@@ -27,11 +28,29 @@ type Interface interface {
 }
 
 func NewBuilder(config *Config) (Interface, error) {
+	secrets, err := buildinstruction.ParseSecrets(config.Secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	runner, err := buildinstruction.NewCommandRunner(config.BuildType, config.ContainerImage)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = setSourceDateEpoch(config.Reproducible); err != nil {
+		return nil, err
+	}
+
 	return &liteBuilder{
 		noCache:   config.NoCache,
 		noBase:    config.NoBase,
 		buildArgs: config.BuildArgs,
 		platform:  config.Platform,
+		cacheFrom: config.CacheFrom,
+		cacheTo:   config.CacheTo,
+		secrets:   secrets,
+		runner:    runner,
 	}, nil
 }
 
@@ -47,6 +66,10 @@ type liteBuilder struct {
 	platform     v1.Platform
 	executor     buildimage.Executor
 	saver        buildimage.ImageSaver
+	cacheFrom    []string
+	cacheTo      string
+	secrets      buildinstruction.Secrets
+	runner       buildinstruction.CommandRunner
 }
 
 func (l liteBuilder) Build(name string, context string, kubefileName string) error {
@@ -104,13 +127,22 @@ func (l liteBuilder) Build(name string, context string, kubefileName string) err
 func (l liteBuilder) GetBuildPipeLine() ([]func() error, error) {
 	var buildPipeline []func() error
 	buildPipeline = append(buildPipeline,
+		l.ImportBuildCache,
 		l.ExecBuild,
 		l.SaveBuildImage,
+		l.ExportBuildCache,
 		l.Cleanup,
 	)
 	return buildPipeline, nil
 }
 
+func (l liteBuilder) ImportBuildCache() error {
+	if l.noCache {
+		return nil
+	}
+	return importBuildCache(l.cacheFrom, l.platform)
+}
+
 func (l liteBuilder) ExecBuild() error {
 	// merge args with build context
 	for k, v := range l.buildArgs {
@@ -121,6 +153,8 @@ func (l liteBuilder) ExecBuild() error {
 		BuildContext: l.context,
 		UseCache:     !l.noCache,
 		BuildArgs:    l.rawImage.Spec.ImageConfig.Args.Current,
+		Secrets:      l.secrets,
+		Runner:       l.runner,
 	}
 
 	layers, err := l.executor.Execute(ctx, l.rawImage.Spec.Layers[1:])
@@ -129,19 +163,35 @@ func (l liteBuilder) ExecBuild() error {
 	}
 
 	l.rawImage.Spec.Layers = layers
+
+	// record the kubernetes version of ClusterImage, so it can be shown without pulling the image.
+	md, err := l.executor.Metadata()
+	if err != nil {
+		return err
+	}
+	if md != nil && md.Version != "" {
+		if l.rawImage.Annotations == nil {
+			l.rawImage.Annotations = make(map[string]string)
+		}
+		l.rawImage.Annotations[common.ImageAnnotationForKubeVersion] = md.Version
+	}
 	return nil
 }
 
 func (l liteBuilder) SaveBuildImage() error {
 	l.rawImage.Name = l.imageNamed.CompleteName()
+	// the app image is saved from a copy, the raw image keeps the base layers, so the exported cache chains
+	// are calculated from their base parents like the ones in local store.
+	image := l.rawImage
 	if l.noBase {
-		l.rawImage.Spec.ImageConfig.ImageType = common.AppImage
-		l.rawImage.Spec.ImageConfig.Cmd.Parent = nil
-		l.rawImage.Spec.ImageConfig.Args.Parent = nil
-		l.rawImage.Spec.Layers = l.rawImage.Spec.Layers[len(l.baseLayers):]
+		image = l.rawImage.DeepCopy()
+		image.Spec.ImageConfig.ImageType = common.AppImage
+		image.Spec.ImageConfig.Cmd.Parent = nil
+		image.Spec.ImageConfig.Args.Parent = nil
+		image.Spec.Layers = image.Spec.Layers[len(l.baseLayers):]
 	}
 
-	err := l.saver.Save(l.rawImage)
+	err := l.saver.Save(image)
 	if err != nil {
 		return err
 	}
@@ -149,6 +199,10 @@ func (l liteBuilder) SaveBuildImage() error {
 	return nil
 }
 
+func (l liteBuilder) ExportBuildCache() error {
+	return exportBuildCache(l.cacheTo, l.rawImage, l.saver.Save)
+}
+
 func (l liteBuilder) Cleanup() error {
 	return l.executor.Cleanup()
 }
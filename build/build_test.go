@@ -0,0 +1,88 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sealerio/sealer/common"
+	"github.com/sealerio/sealer/pkg/image/reference"
+	v1 "github.com/sealerio/sealer/types/api/v1"
+)
+
+type fakeSaver struct {
+	saved []*v1.Image
+}
+
+func (f *fakeSaver) Save(image *v1.Image) error {
+	f.saved = append(f.saved, image.DeepCopy())
+	return nil
+}
+
+func TestSaveBuildImageKeepsBaseLayersForCache(t *testing.T) {
+	baseLayers := []v1.Layer{
+		{ID: "sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", Type: common.COPYCOMMAND, Value: "rootfs ."},
+		{Type: common.CMDCOMMAND, Value: "kubectl apply -f etc/tigera-operator.yaml"},
+	}
+	appLayers := []v1.Layer{
+		{ID: "sha256:bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb", Type: common.COPYCOMMAND, Value: "nginx.yaml manifests"},
+		{Type: common.CMDCOMMAND, Value: "kubectl apply -f manifests/nginx.yaml"},
+	}
+	named, err := reference.ParseToNamed("nginx:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		noBase    bool
+		wantSaved []v1.Layer
+	}{
+		{
+			name:      "cluster image",
+			wantSaved: append(append([]v1.Layer{}, baseLayers...), appLayers...),
+		},
+		{
+			name:      "app image",
+			noBase:    true,
+			wantSaved: appLayers,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saver := &fakeSaver{}
+			rawImage := &v1.Image{}
+			rawImage.Spec.Layers = append(append([]v1.Layer{}, baseLayers...), appLayers...)
+			l := liteBuilder{
+				noBase:     tt.noBase,
+				imageNamed: named,
+				baseLayers: baseLayers,
+				rawImage:   rawImage,
+				saver:      saver,
+			}
+			if err := l.SaveBuildImage(); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(saver.saved[0].Spec.Layers, tt.wantSaved) {
+				t.Errorf("saved layers = %v, want %v", saver.saved[0].Spec.Layers, tt.wantSaved)
+			}
+			// the cache is exported from the raw image, its chains must start from the base layers.
+			if !reflect.DeepEqual(rawImage.Spec.Layers[:len(baseLayers)], baseLayers) {
+				t.Errorf("base layers of raw image are dropped: %v", rawImage.Spec.Layers)
+			}
+		})
+	}
+}
@@ -14,11 +14,15 @@
 
 package buildimage
 
+import "github.com/sealerio/sealer/build/buildinstruction"
+
 type Context struct {
 	BuildContext string
 	//cache flag,will change for each layer ctx
 	UseCache  bool
 	BuildArgs map[string]string
+	Secrets   buildinstruction.Secrets
+	Runner    buildinstruction.CommandRunner
 }
 
 type SaveOpts struct {
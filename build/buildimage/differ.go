@@ -20,10 +20,12 @@ import (
 	"io/fs"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	osi "github.com/sealerio/sealer/utils/os"
 
 	"github.com/sealerio/sealer/build/layerutils/charts"
+	"github.com/sealerio/sealer/build/layerutils/kustomize"
 	manifest "github.com/sealerio/sealer/build/layerutils/manifests"
 	"github.com/sealerio/sealer/common"
 	"github.com/sealerio/sealer/pkg/image/save"
@@ -36,6 +38,7 @@ var (
 	copyToManifests = "manifests"
 	copyToChart     = "charts"
 	copyToImageList = "imageList"
+	copyToKustomize = "kustomize"
 	dispatch        map[string]func(srcPath string) ([]string, error)
 )
 
@@ -44,6 +47,7 @@ func init() {
 		copyToManifests: parseYamlImages,
 		copyToChart:     parseChartImages,
 		copyToImageList: parseRawImageList,
+		copyToKustomize: parseKustomizeImages,
 	}
 }
 
@@ -55,7 +59,10 @@ type registry struct {
 func (r registry) Process(srcPath, rootfs string) error {
 	eg, _ := errgroup.WithContext(context.Background())
 
-	var images []string
+	var (
+		images []string
+		mu     sync.Mutex
+	)
 	for t, p := range dispatch {
 		dispatchType := t
 		parse := p
@@ -64,6 +71,8 @@ func (r registry) Process(srcPath, rootfs string) error {
 			if err != nil {
 				return fmt.Errorf("failed to parse images from %s: %v", dispatchType, err)
 			}
+			mu.Lock()
+			defer mu.Unlock()
 			images = append(images, ima...)
 			return nil
 		})
@@ -105,13 +114,15 @@ func parseChartImages(srcPath string) ([]string, error) {
 			return nil
 		}
 
-		if osi.IsFileExist(filepath.Join(path, "Chart.yaml")) && osi.IsFileExist(filepath.Join(path, "values.yaml")) &&
-			osi.IsFileExist(filepath.Join(path, "templates")) {
+		// umbrella charts may have no templates or values, but only dependencies.
+		if osi.IsFileExist(filepath.Join(path, "Chart.yaml")) {
 			ima, err := imageSearcher.ListImages(path)
 			if err != nil {
 				return err
 			}
 			images = append(images, ima...)
+			// subcharts are rendered with their parent chart.
+			return filepath.SkipDir
 		}
 		return nil
 	})
@@ -138,6 +149,10 @@ func parseYamlImages(srcPath string) ([]string, error) {
 			return err
 		}
 		if f.IsDir() {
+			// kustomization dirs are rendered by parseKustomizeImages.
+			if kustomize.KustomizationFile(path) != "" {
+				return filepath.SkipDir
+			}
 			return nil
 		}
 
@@ -161,6 +176,35 @@ func parseYamlImages(srcPath string) ([]string, error) {
 	return FormatImages(images), nil
 }
 
+// parseKustomizeImages renders the kustomization dirs under manifests dir,
+// only the dirs not referenced by other kustomizations are rendered.
+func parseKustomizeImages(srcPath string) ([]string, error) {
+	manifestsPath := filepath.Join(srcPath, copyToManifests)
+	if !osi.IsFileExist(manifestsPath) {
+		return nil, nil
+	}
+
+	dirs, err := kustomize.RootDirs(manifestsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	imageSearcher, err := kustomize.NewKustomize()
+	if err != nil {
+		return nil, err
+	}
+
+	var images []string
+	for _, dir := range dirs {
+		ima, err := imageSearcher.ListImages(dir)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, ima...)
+	}
+	return FormatImages(images), nil
+}
+
 func parseRawImageList(srcPath string) ([]string, error) {
 	imageListFilePath := filepath.Join(srcPath, copyToManifests, copyToImageList)
 	if !osi.IsFileExist(imageListFilePath) {
@@ -23,6 +23,7 @@ import (
 	"github.com/sealerio/sealer/common"
 	"github.com/sealerio/sealer/pkg/image"
 	"github.com/sealerio/sealer/pkg/image/store"
+	"github.com/sealerio/sealer/pkg/runtime"
 	v1 "github.com/sealerio/sealer/types/api/v1"
 	"github.com/sealerio/sealer/utils/maps"
 	"github.com/sealerio/sealer/utils/mount"
@@ -50,13 +51,13 @@ func (l *layerExecutor) Execute(ctx Context, rawLayers []v1.Layer) ([]v1.Layer,
 	)
 
 	// process middleware file
-	err := l.checkMiddleware(ctx.BuildContext)
+	err := l.checkMiddleware(ctx.BuildContext, ctx.Secrets)
 	if err != nil {
 		return []v1.Layer{}, err
 	}
 
-	execCtx = buildinstruction.NewExecContext(ctx.BuildContext, ctx.BuildArgs,
-		ctx.UseCache, l.layerStore)
+	execCtx = buildinstruction.NewExecContext(ctx.BuildContext, ctx.BuildArgs, ctx.Secrets,
+		ctx.Runner, ctx.UseCache, l.layerStore)
 
 	for i := 0; i < len(rawLayers); i++ {
 		//we are to set layer id for each new layers.
@@ -116,10 +117,10 @@ func (l *layerExecutor) Execute(ctx Context, rawLayers []v1.Layer) ([]v1.Layer,
 	return baseLayers, nil
 }
 
-func (l *layerExecutor) checkMiddleware(buildContext string) error {
+func (l *layerExecutor) checkMiddleware(buildContext string, secrets buildinstruction.Secrets) error {
 	var (
 		rootfs      = l.rootfsMountInfo.GetMountTarget()
-		middlewares = []Differ{NewMiddlewarePuller(l.platform)}
+		middlewares = []Differ{NewMiddlewarePuller(l.platform, secrets)}
 	)
 	logrus.Info("start to check the middleware file")
 	eg, _ := errgroup.WithContext(context.Background())
@@ -177,6 +178,10 @@ func (l *layerExecutor) genNewLayer(layerType, layerValue, filepath string) (v1.
 	return imageLayer, nil
 }
 
+func (l *layerExecutor) Metadata() (*runtime.Metadata, error) {
+	return runtime.LoadMetadata(l.rootfsMountInfo.GetMountTarget())
+}
+
 func (l *layerExecutor) Cleanup() error {
 	l.rootfsMountInfo.CleanUp()
 	return nil
@@ -15,12 +15,15 @@
 package buildimage
 
 import (
+	"github.com/sealerio/sealer/pkg/runtime"
 	v1 "github.com/sealerio/sealer/types/api/v1"
 )
 
 type Executor interface {
 	// Execute all raw layers,and merge with base layers.
 	Execute(ctx Context, rawLayers []v1.Layer) ([]v1.Layer, error)
+	// Metadata returns the Metadata file of built rootfs, it is nil if there is no Metadata file.
+	Metadata() (*runtime.Metadata, error)
 	Cleanup() error
 }
 
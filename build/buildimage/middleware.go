@@ -18,7 +18,11 @@ import (
 	"fmt"
 	"path/filepath"
 
-	"github.com/sealerio/sealer/utils/yaml"
+	"github.com/sirupsen/logrus"
+	"sigs.k8s.io/yaml"
+
+	"github.com/sealerio/sealer/build/buildinstruction"
+	yamlUtils "github.com/sealerio/sealer/utils/yaml"
 
 	osi "github.com/sealerio/sealer/utils/os"
 
@@ -32,15 +36,25 @@ var (
 )
 
 type ImageSection struct {
-	Registry string   `json:"registry,omitempty"`
-	Username string   `json:"username,omitempty"`
-	Password string   `json:"password,omitempty"`
-	Images   []string `json:"images,omitempty"`
+	Registry string `json:"registry,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	// Secret is the id of build secret which contains username and password,
+	// set by "sealer build --secret id=<id>,src=<path>".
+	Secret string   `json:"secret,omitempty"`
+	Images []string `json:"images,omitempty"`
+}
+
+// RegistryCredential is the content of the build secret referenced by ImageSection.
+type RegistryCredential struct {
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
 }
 
 type MiddlewarePuller struct {
 	puller   save.DefaultImageSaver
 	platform v1.Platform
+	secrets  buildinstruction.Secrets
 }
 
 func (m MiddlewarePuller) Process(context, rootfs string) error {
@@ -53,7 +67,7 @@ func (m MiddlewarePuller) Process(context, rootfs string) error {
 
 	// pares middleware file: imageListWithAuth.yaml
 	var imageSection []ImageSection
-	err := yaml.UnmarshalFile(filePath, &imageSection)
+	err := yamlUtils.UnmarshalFile(filePath, &imageSection)
 	if err != nil {
 		return err
 	}
@@ -63,8 +77,11 @@ func (m MiddlewarePuller) Process(context, rootfs string) error {
 		if len(section.Images) == 0 {
 			continue
 		}
+		if err = m.resolveCredential(&section); err != nil {
+			return err
+		}
 		if section.Username == "" || section.Password == "" {
-			return fmt.Errorf("must set username and password at imageListWithAuth.yaml")
+			return fmt.Errorf("must set username and password or secret at imageListWithAuth.yaml")
 		}
 
 		domainToImages, err := normalizedImageListWithAuth(section)
@@ -87,6 +104,28 @@ func (m MiddlewarePuller) Process(context, rootfs string) error {
 	return m.puller.SaveImagesWithAuth(ia, filepath.Join(rootfs, common.RegistryDirName), m.platform)
 }
 
+// resolveCredential fills the username and password of section from its build secret.
+func (m MiddlewarePuller) resolveCredential(section *ImageSection) error {
+	if section.Secret == "" {
+		if section.Password != "" {
+			logrus.Warnf("plaintext password of registry %s in %s may be committed with build context, use build secret instead",
+				section.Registry, imageListWithAuth)
+		}
+		return nil
+	}
+
+	value, err := m.secrets.Get(section.Secret)
+	if err != nil {
+		return err
+	}
+	cred := RegistryCredential{}
+	if err = yaml.Unmarshal([]byte(value), &cred); err != nil {
+		return fmt.Errorf("failed to parse secret %s as registry credential: %v", section.Secret, err)
+	}
+	section.Username, section.Password = cred.Username, cred.Password
+	return nil
+}
+
 func normalizedImageListWithAuth(sec ImageSection) (map[string][]save.Named, error) {
 	domainToImages := make(map[string][]save.Named)
 	for _, image := range sec.Images {
@@ -99,9 +138,10 @@ func normalizedImageListWithAuth(sec ImageSection) (map[string][]save.Named, err
 	return domainToImages, nil
 }
 
-func NewMiddlewarePuller(platform v1.Platform) Differ {
+func NewMiddlewarePuller(platform v1.Platform, secrets buildinstruction.Secrets) Differ {
 	return MiddlewarePuller{
 		platform: platform,
 		puller:   save.DefaultImageSaver{},
+		secrets:  secrets,
 	}
 }
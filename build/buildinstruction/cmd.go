@@ -24,9 +24,7 @@ import (
 	"github.com/sealerio/sealer/common"
 	"github.com/sealerio/sealer/pkg/image/cache"
 	v1 "github.com/sealerio/sealer/types/api/v1"
-	"github.com/sealerio/sealer/utils/exec"
 	"github.com/sealerio/sealer/utils/mount"
-	"github.com/sealerio/sealer/utils/os"
 )
 
 type CmdInstruction struct {
@@ -61,24 +59,27 @@ func (c CmdInstruction) Exec(execContext ExecContext) (out Out, err error) {
 	}
 	defer c.mounter.CleanUp()
 
-	err = os.SetRootfsBinToSystemEnv(c.mounter.GetMountTarget())
+	mounts, cmdValue, err := parseRunMounts(c.cmdValue)
 	if err != nil {
-		return out, fmt.Errorf("failed to set temp rootfs %s to system $PATH : %v", c.mounter.GetMountTarget(), err)
+		return out, err
+	}
+	// secrets are passed by environment, so they won't be written into the layer.
+	env, err := secretEnv(mounts, execContext.Secrets)
+	if err != nil {
+		return out, err
 	}
 
 	// if no variable at cmd value,nothing will change.
 	// if no build args is matched at cmd value,then the variable will be null.
-	cmdline, err := c.ex.ProcessWordWithMap(c.cmdValue, execContext.BuildArgs)
+	cmdline, err := c.ex.ProcessWordWithMap(cmdValue, execContext.BuildArgs)
 	if err != nil {
 		return out, fmt.Errorf("failed to render build args: %v", err)
 	}
 
-	cmd := fmt.Sprintf(common.CdAndExecCmd, c.mounter.GetMountTarget(), cmdline)
-	output, err := exec.RunSimpleCmd(cmd)
+	output, err := execContext.Runner.Run(c.mounter.GetMountTarget(), cmdline, env)
 	logrus.Info(output)
-
 	if err != nil {
-		return out, fmt.Errorf("failed to exec %s, err: %v", cmd, err)
+		return out, err
 	}
 
 	// cmd do not contain layer ,so no need to calculate layer
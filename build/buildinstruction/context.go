@@ -27,6 +27,10 @@ import (
 type ExecContext struct {
 	BuildContext string
 	BuildArgs    map[string]string
+	// secrets which could be referenced by RUN instruction
+	Secrets Secrets
+	// runner of RUN instruction, depends on build mode
+	Runner CommandRunner
 	//cache flag,will change for each layer ctx
 	ContinueCache bool
 	//cache chain to hit,will change for each layer ctx
@@ -63,12 +67,14 @@ func NewInstruction(ic InstructionContext) (Interface, error) {
 	return nil, nil
 }
 
-func NewExecContext(buildContext string, buildArgs map[string]string, useCache bool, layerStore store.LayerStore) ExecContext {
+func NewExecContext(buildContext string, buildArgs map[string]string, secrets Secrets, runner CommandRunner, useCache bool, layerStore store.LayerStore) ExecContext {
 	if !useCache {
 		return ExecContext{
 			LayerStore:   layerStore,
 			BuildContext: buildContext,
 			BuildArgs:    buildArgs,
+			Secrets:      secrets,
+			Runner:       runner,
 		}
 	}
 	chainSvc, err := cache.NewService()
@@ -86,6 +92,8 @@ func NewExecContext(buildContext string, buildArgs map[string]string, useCache b
 		LayerStore:    layerStore,
 		BuildContext:  buildContext,
 		BuildArgs:     buildArgs,
+		Secrets:       secrets,
+		Runner:        runner,
 		CacheSvc:      chainSvc,
 		ParentID:      "",
 		Prober:        prober,
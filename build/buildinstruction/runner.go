@@ -0,0 +1,106 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buildinstruction
+
+import (
+	"fmt"
+
+	"github.com/docker/docker/api/types/mount"
+
+	"github.com/sealerio/sealer/common"
+	"github.com/sealerio/sealer/pkg/infra/container/client"
+	"github.com/sealerio/sealer/pkg/infra/container/client/docker"
+	"github.com/sealerio/sealer/utils/exec"
+	"github.com/sealerio/sealer/utils/os"
+)
+
+const (
+	// LiteBuildMode runs RUN instructions on the build host.
+	LiteBuildMode = "lite"
+	// ContainerBuildMode runs RUN instructions in throwaway containers.
+	ContainerBuildMode = "container"
+
+	defaultContainerPath = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+)
+
+// CommandRunner runs the command of RUN instruction against the mounted rootfs,
+// the changes made under rootfs become the content of the new layer.
+type CommandRunner interface {
+	Run(rootfs string, cmdline string, env []string) (string, error)
+}
+
+type hostRunner struct{}
+
+func (h hostRunner) Run(rootfs string, cmdline string, env []string) (string, error) {
+	err := os.SetRootfsBinToSystemEnv(rootfs)
+	if err != nil {
+		return "", fmt.Errorf("failed to set temp rootfs %s to system $PATH : %v", rootfs, err)
+	}
+
+	cmd := fmt.Sprintf(common.CdAndExecCmd, rootfs, cmdline)
+	output, err := exec.RunSimpleCmdWithEnv(cmd, env)
+	if err != nil {
+		return output, fmt.Errorf("failed to exec %s, err: %v", cmd, err)
+	}
+	return output, nil
+}
+
+type containerRunner struct {
+	image    string
+	provider client.ProviderService
+}
+
+// Run mounts the merged rootfs into the container at the same path,
+// so the command sees the same paths as in lite mode, and nothing of the build host is touched.
+func (c containerRunner) Run(rootfs string, cmdline string, env []string) (string, error) {
+	opts := &client.CreateOptsForCommand{
+		ImageName:  c.image,
+		Cmd:        cmdline,
+		Env:        append([]string{fmt.Sprintf("PATH=%s:%s/bin", defaultContainerPath, rootfs)}, env...),
+		WorkingDir: rootfs,
+		Mount: []mount.Mount{
+			{
+				Type:   mount.TypeBind,
+				Source: rootfs,
+				Target: rootfs,
+			},
+		},
+	}
+
+	output, err := c.provider.RunCommand(opts)
+	if err != nil {
+		return output, fmt.Errorf("failed to exec %s in container of image %s, err: %v", cmdline, c.image, err)
+	}
+	return output, nil
+}
+
+// NewCommandRunner returns the runner of build mode, lite mode is used if mode is empty.
+func NewCommandRunner(mode string, containerImage string) (CommandRunner, error) {
+	switch mode {
+	case "", LiteBuildMode:
+		return hostRunner{}, nil
+	case ContainerBuildMode:
+		if containerImage == "" {
+			return nil, fmt.Errorf("container image is required in %s build mode", ContainerBuildMode)
+		}
+		provider, err := docker.NewDockerProvider()
+		if err != nil {
+			return nil, fmt.Errorf("failed to init docker client: %v", err)
+		}
+		return containerRunner{image: containerImage, provider: provider}, nil
+	default:
+		return nil, fmt.Errorf("build mode %s is not supported, use %s or %s instead", mode, LiteBuildMode, ContainerBuildMode)
+	}
+}
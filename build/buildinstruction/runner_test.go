@@ -0,0 +1,109 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buildinstruction
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/api/types/mount"
+
+	"github.com/sealerio/sealer/pkg/infra/container/client"
+)
+
+func TestNewCommandRunner(t *testing.T) {
+	tests := []struct {
+		name           string
+		mode           string
+		containerImage string
+		wantErr        bool
+	}{
+		{"test default mode", "", "", false},
+		{"test lite mode", LiteBuildMode, "", false},
+		{"test container mode without image", ContainerBuildMode, "", true},
+		{"test unsupported mode", "cloud", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewCommandRunner(tt.mode, tt.containerImage); (err != nil) != tt.wantErr {
+				t.Errorf("NewCommandRunner() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+type fakeProvider struct {
+	client.ProviderService
+	opts   *client.CreateOptsForCommand
+	output string
+	err    error
+}
+
+func (f *fakeProvider) RunCommand(opts *client.CreateOptsForCommand) (string, error) {
+	f.opts = opts
+	return f.output, f.err
+}
+
+func TestContainerRunnerRun(t *testing.T) {
+	rootfs := "/var/lib/sealer/tmp/rootfs"
+	tests := []struct {
+		name     string
+		env      []string
+		provider *fakeProvider
+		wantErr  bool
+		wantOpts *client.CreateOptsForCommand
+	}{
+		{
+			name:     "rootfs is mounted as working dir",
+			env:      []string{"Version=v1.22.3"},
+			provider: &fakeProvider{output: "ok"},
+			wantOpts: &client.CreateOptsForCommand{
+				ImageName:  "ubuntu:20.04",
+				Cmd:        "kubectl version --client",
+				Env:        []string{"PATH=" + defaultContainerPath + ":" + rootfs + "/bin", "Version=v1.22.3"},
+				WorkingDir: rootfs,
+				Mount:      []mount.Mount{{Type: mount.TypeBind, Source: rootfs, Target: rootfs}},
+			},
+		},
+		{
+			name:     "command failed in container",
+			provider: &fakeProvider{output: "not found", err: fmt.Errorf("command exited with code 127")},
+			wantErr:  true,
+			wantOpts: &client.CreateOptsForCommand{
+				ImageName:  "ubuntu:20.04",
+				Cmd:        "kubectl version --client",
+				Env:        []string{"PATH=" + defaultContainerPath + ":" + rootfs + "/bin"},
+				WorkingDir: rootfs,
+				Mount:      []mount.Mount{{Type: mount.TypeBind, Source: rootfs, Target: rootfs}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := containerRunner{image: "ubuntu:20.04", provider: tt.provider}
+			output, err := runner.Run(rootfs, "kubectl version --client", tt.env)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if output != tt.provider.output {
+				t.Errorf("Run() output = %v, want %v", output, tt.provider.output)
+			}
+			if !reflect.DeepEqual(tt.provider.opts, tt.wantOpts) {
+				t.Errorf("RunCommand() opts = %+v, want %+v", tt.provider.opts, tt.wantOpts)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buildinstruction
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	strUtils "github.com/sealerio/sealer/utils/strings"
+)
+
+const (
+	runMountFlag   = "--mount="
+	mountTypeKey   = "type"
+	mountTypeValue = "secret"
+	secretIDKey    = "id"
+	secretSrcKey   = "src"
+	secretEnvKey   = "env"
+)
+
+// Secret is a value only available at build time, which is read from a file
+// or an environment variable of the build host, and never saved into layers or image metadata.
+type Secret struct {
+	ID  string
+	Src string
+	Env string
+}
+
+// Value reads the secret content, the trailing newline of the source file is trimmed.
+func (s Secret) Value() (string, error) {
+	if s.Env != "" {
+		v, ok := os.LookupEnv(s.Env)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s of secret %s is not set", s.Env, s.ID)
+		}
+		return v, nil
+	}
+
+	data, err := ioutil.ReadFile(filepath.Clean(s.Src))
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret %s: %v", s.ID, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// Secrets maps secret id to the secret.
+type Secrets map[string]Secret
+
+// Get returns the content of secret id.
+func (s Secrets) Get(id string) (string, error) {
+	secret, ok := s[id]
+	if !ok {
+		return "", fmt.Errorf("secret %s is not provided, use --secret id=%s,src=<path> to set it", id, id)
+	}
+	return secret.Value()
+}
+
+// ParseSecrets parses secret specs like "id=regcred,src=/path/to/file" or "id=token,env=TOKEN".
+func ParseSecrets(specs []string) (Secrets, error) {
+	secrets := Secrets{}
+	for _, spec := range specs {
+		kv, err := parseKeyValues(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid secret %s: %v", spec, err)
+		}
+
+		secret := Secret{ID: kv[secretIDKey], Src: kv[secretSrcKey], Env: kv[secretEnvKey]}
+		if secret.ID == "" {
+			return nil, fmt.Errorf("invalid secret %s: id is required", spec)
+		}
+		if (secret.Src == "") == (secret.Env == "") {
+			return nil, fmt.Errorf("invalid secret %s: exactly one of src and env is required", spec)
+		}
+		if secret.Src != "" {
+			if secret.Src, err = filepath.Abs(secret.Src); err != nil {
+				return nil, err
+			}
+			if _, err = os.Stat(secret.Src); err != nil {
+				return nil, fmt.Errorf("invalid secret %s: %v", spec, err)
+			}
+		}
+		secrets[secret.ID] = secret
+	}
+	return secrets, nil
+}
+
+// secretMount is a secret referenced by RUN instruction,
+// like "RUN --mount=type=secret,id=regcred,env=REG_PASSWORD cmd".
+type secretMount struct {
+	id  string
+	env string
+}
+
+// parseRunMounts splits the leading --mount flags from the RUN value.
+func parseRunMounts(value string) ([]secretMount, string, error) {
+	var mounts []secretMount
+	value = strings.TrimSpace(value)
+	for strings.HasPrefix(value, runMountFlag) {
+		fields := strings.SplitN(value, " ", 2)
+		kv, err := parseKeyValues(strings.TrimPrefix(fields[0], runMountFlag))
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid mount %s: %v", fields[0], err)
+		}
+		if kv[mountTypeKey] != mountTypeValue {
+			return nil, "", fmt.Errorf("invalid mount %s: only type=secret is supported", fields[0])
+		}
+		m := secretMount{id: kv[secretIDKey], env: kv[secretEnvKey]}
+		if m.id == "" {
+			return nil, "", fmt.Errorf("invalid mount %s: id is required", fields[0])
+		}
+		if m.env == "" {
+			m.env = m.id
+		}
+		if !strUtils.IsLetterOrNumber(m.env) {
+			return nil, "", fmt.Errorf("invalid mount %s: env %s must be letter or number", fields[0], m.env)
+		}
+		mounts = append(mounts, m)
+
+		value = ""
+		if len(fields) == 2 {
+			value = strings.TrimSpace(fields[1])
+		}
+	}
+	return mounts, value, nil
+}
+
+// secretEnv reads the secrets referenced by mounts as "KEY=VALUE" environment variables.
+func secretEnv(mounts []secretMount, secrets Secrets) ([]string, error) {
+	var env []string
+	for _, m := range mounts {
+		v, err := secrets.Get(m.id)
+		if err != nil {
+			return nil, err
+		}
+		env = append(env, m.env+"="+v)
+	}
+	return env, nil
+}
+
+func parseKeyValues(s string) (map[string]string, error) {
+	kv := map[string]string{}
+	for _, field := range strings.Split(s, ",") {
+		pair := strings.SplitN(field, "=", 2)
+		if len(pair) != 2 || pair[0] == "" {
+			return nil, fmt.Errorf("%s is not in key=value format", field)
+		}
+		kv[strings.TrimSpace(pair[0])] = strings.TrimSpace(pair[1])
+	}
+	return kv, nil
+}
@@ -0,0 +1,94 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buildinstruction
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_parseRunMounts(t *testing.T) {
+	tests := []struct {
+		name       string
+		value      string
+		wantMounts []secretMount
+		wantCmd    string
+		wantErr    bool
+	}{
+		{
+			"test run without mount",
+			"kubectl apply -f manifests",
+			nil,
+			"kubectl apply -f manifests",
+			false,
+		},
+		{
+			"test run with secret mounts",
+			"--mount=type=secret,id=regcred --mount=type=secret,id=token,env=TOKEN curl -H $TOKEN x.com",
+			[]secretMount{{id: "regcred", env: "regcred"}, {id: "token", env: "TOKEN"}},
+			"curl -H $TOKEN x.com",
+			false,
+		},
+		{
+			"test run with unsupported mount type",
+			"--mount=type=cache,id=go ls",
+			nil,
+			"",
+			true,
+		},
+		{
+			"test run with invalid env name",
+			"--mount=type=secret,id=reg-cred ls",
+			nil,
+			"",
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mounts, cmd, err := parseRunMounts(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseRunMounts() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(mounts, tt.wantMounts) {
+				t.Errorf("parseRunMounts() mounts = %v, want %v", mounts, tt.wantMounts)
+			}
+			if cmd != tt.wantCmd {
+				t.Errorf("parseRunMounts() cmd = %v, want %v", cmd, tt.wantCmd)
+			}
+		})
+	}
+}
+
+func TestParseSecrets(t *testing.T) {
+	tests := []struct {
+		name    string
+		specs   []string
+		wantErr bool
+	}{
+		{"test env secret", []string{"id=token,env=TOKEN"}, false},
+		{"test secret without id", []string{"env=TOKEN"}, true},
+		{"test secret with both src and env", []string{"id=token,env=TOKEN,src=/tmp"}, true},
+		{"test secret with missing src file", []string{"id=regcred,src=/not/exist/regcred"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseSecrets(tt.specs); (err != nil) != tt.wantErr {
+				t.Errorf("ParseSecrets() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/sealerio/sealer/pkg/image"
+	"github.com/sealerio/sealer/pkg/image/cache"
+	"github.com/sealerio/sealer/pkg/image/reference"
+	"github.com/sealerio/sealer/pkg/image/store"
+	v1 "github.com/sealerio/sealer/types/api/v1"
+)
+
+// isLocalCacheRef tells whether the cache ref is a local directory or a registry reference.
+func isLocalCacheRef(ref string) bool {
+	if filepath.IsAbs(ref) || ref == "." || ref == ".." ||
+		strings.HasPrefix(ref, "./") || strings.HasPrefix(ref, "../") {
+		return true
+	}
+	fi, err := os.Stat(ref)
+	return err == nil && fi.IsDir()
+}
+
+func newChainTransfer() (cache.ChainTransfer, error) {
+	ls, err := store.NewDefaultLayerStore()
+	if err != nil {
+		return nil, err
+	}
+	fs, err := store.NewFSStoreBackend()
+	if err != nil {
+		return nil, err
+	}
+	return cache.NewChainTransfer(fs, ls)
+}
+
+// importBuildCache loads cache chains from each of the cache refs,
+// a failed import only means cache miss, so it won't break the build.
+func importBuildCache(cacheFrom []string, platform v1.Platform) error {
+	if len(cacheFrom) == 0 {
+		return nil
+	}
+
+	transfer, err := newChainTransfer()
+	if err != nil {
+		return err
+	}
+
+	for _, ref := range cacheFrom {
+		if isLocalCacheRef(ref) {
+			err = transfer.ImportDir(ref)
+		} else {
+			err = importCacheFromRegistry(transfer, ref, platform)
+		}
+		if err != nil {
+			logrus.Warnf("failed to import build cache from %s: %v", ref, err)
+		}
+	}
+	return nil
+}
+
+func importCacheFromRegistry(transfer cache.ChainTransfer, ref string, platform v1.Platform) error {
+	named, err := reference.ParseToNamed(ref)
+	if err != nil {
+		return err
+	}
+
+	service, err := image.NewImageService()
+	if err != nil {
+		return err
+	}
+	if err = service.Pull(named.Raw(), []*v1.Platform{&platform}); err != nil {
+		return err
+	}
+
+	imageStore, err := store.NewDefaultImageStore()
+	if err != nil {
+		return err
+	}
+	cacheImage, err := imageStore.GetByName(named.Raw(), &platform)
+	if err != nil {
+		return err
+	}
+	return transfer.ImportImage(cacheImage)
+}
+
+// exportBuildCache exports cache chains of the built image to a local directory,
+// or pushes them as a ClusterImage to the registry.
+func exportBuildCache(cacheTo string, builtImage *v1.Image, saveImage func(*v1.Image) error) error {
+	if cacheTo == "" {
+		return nil
+	}
+
+	transfer, err := newChainTransfer()
+	if err != nil {
+		return err
+	}
+
+	if isLocalCacheRef(cacheTo) {
+		if err = transfer.ExportDir(cacheTo, builtImage.Spec.Layers); err != nil {
+			return fmt.Errorf("failed to export build cache to %s: %v", cacheTo, err)
+		}
+		logrus.Infof("succeed in exporting build cache to %s", cacheTo)
+		return nil
+	}
+
+	named, err := reference.ParseToNamed(cacheTo)
+	if err != nil {
+		return err
+	}
+
+	cacheImage := builtImage.DeepCopy()
+	cacheImage.Name = named.CompleteName()
+	if err = transfer.AnnotateImage(cacheImage); err != nil {
+		return err
+	}
+	if err = saveImage(cacheImage); err != nil {
+		return fmt.Errorf("failed to save cache image %s: %v", cacheImage.Name, err)
+	}
+
+	service, err := image.NewImageService()
+	if err != nil {
+		return err
+	}
+	if err = service.Push(named.Raw(), image.PushOptions{}); err != nil {
+		return fmt.Errorf("failed to push build cache to %s: %v", cacheTo, err)
+	}
+	logrus.Infof("succeed in exporting build cache to %s", cacheTo)
+	return nil
+}
@@ -23,4 +23,16 @@ type Config struct {
 	ImageName string
 	BuildArgs map[string]string
 	Platform  v1.Platform
+	// CacheFrom are local dirs or registry refs to import build cache from.
+	CacheFrom []string
+	// CacheTo is a local dir or registry ref to export build cache to.
+	CacheTo string
+	// Secrets are build secret specs like "id=regcred,src=/path/to/file".
+	Secrets []string
+	// Reproducible normalizes timestamps and ownership of layer content,
+	// so identical inputs yield identical layer and image IDs.
+	Reproducible bool
+	// ContainerImage is the image of throwaway containers which run RUN instructions
+	// when BuildType is container.
+	ContainerImage string
 }
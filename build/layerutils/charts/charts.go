@@ -16,16 +16,29 @@ package charts
 
 import (
 	"fmt"
+	"path/filepath"
+
+	"helm.sh/helm/v3/pkg/chartutil"
 
 	"github.com/sealerio/sealer/build/layerutils"
+	osi "github.com/sealerio/sealer/utils/os"
 )
 
 type Charts struct{}
 
+// valuesFileSuffix is the suffix of values overrides file, which is placed beside the chart dir,
+// like charts/mysql.values.yaml for charts/mysql.
+const valuesFileSuffix = ".values.yaml"
+
 // ListImages List all the containers images in helm charts
 func (charts *Charts) ListImages(chartPath string) ([]string, error) {
 	var list []string
-	images, err := GetImageList(chartPath)
+	values, err := loadValuesOverrides(filepath.Clean(chartPath) + valuesFileSuffix)
+	if err != nil {
+		return nil, err
+	}
+
+	images, err := GetImageList(chartPath, values)
 	if err != nil {
 		return list, fmt.Errorf("failed to get images chart path(%s), err: %s", chartPath, err)
 	}
@@ -35,6 +48,17 @@ func (charts *Charts) ListImages(chartPath string) ([]string, error) {
 	return list, nil
 }
 
+func loadValuesOverrides(valuesFile string) (map[string]interface{}, error) {
+	if !osi.IsFileExist(valuesFile) {
+		return nil, nil
+	}
+	values, err := chartutil.ReadValuesFile(valuesFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read values file %s: %v", valuesFile, err)
+	}
+	return values, nil
+}
+
 func NewCharts() (layerutils.Interface, error) {
 	return &Charts{}, nil
 }
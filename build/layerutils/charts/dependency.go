@@ -0,0 +1,253 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package charts
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/distribution/distribution/v3"
+	"github.com/distribution/distribution/v3/manifest/ocischema"
+	"github.com/sirupsen/logrus"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"sigs.k8s.io/yaml"
+
+	"github.com/sealerio/sealer/pkg/image/distributionutil"
+	"github.com/sealerio/sealer/pkg/image/reference"
+)
+
+const (
+	ociScheme  = "oci://"
+	fileScheme = "file://"
+
+	helmChartContentMediaType = "application/vnd.cncf.helm.chart.content.v1.tar+gzip"
+	// helm before v3.7 pushes chart content with this media type.
+	helmChartLegacyContentMediaType = "application/tar+gzip"
+
+	repoIndexFile = "index.yaml"
+)
+
+// repoIndex is the part of helm chart repository index.yaml used to locate a chart archive.
+type repoIndex struct {
+	Entries map[string][]struct {
+		Version string   `json:"version"`
+		URLs    []string `json:"urls"`
+	} `json:"entries"`
+}
+
+// resolveDependencies loads the dependencies declared in Chart.yaml which are not vendored in
+// the charts dir of the chart, from local path, helm chart repository or OCI registry.
+// subcharts are resolved recursively.
+func resolveDependencies(ch *chart.Chart, chartPath string) error {
+	loaded := map[string]bool{}
+	for _, sub := range ch.Dependencies() {
+		loaded[sub.Name()] = true
+	}
+
+	for _, dep := range ch.Metadata.Dependencies {
+		if loaded[dep.Name] {
+			continue
+		}
+		logrus.Infof("fetching dependency %s:%s of chart %s from %s", dep.Name, dep.Version, ch.Name(), dep.Repository)
+		sub, err := fetchDependency(dep, chartPath)
+		if err != nil {
+			return fmt.Errorf("failed to fetch dependency %s: %v", dep.Name, err)
+		}
+		ch.AddDependency(sub)
+		loaded[dep.Name] = true
+	}
+
+	for _, sub := range ch.Dependencies() {
+		// subcharts loaded from archive have no local path, so file:// dependencies of them are not supported.
+		subPath := ""
+		if chartPath != "" {
+			subPath = filepath.Join(chartPath, "charts", sub.Name())
+		}
+		if err := resolveDependencies(sub, subPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func fetchDependency(dep *chart.Dependency, chartPath string) (*chart.Chart, error) {
+	switch {
+	case dep.Repository == "":
+		return nil, fmt.Errorf("it is neither in the charts dir nor has a repository")
+	case strings.HasPrefix(dep.Repository, fileScheme):
+		if chartPath == "" {
+			return nil, fmt.Errorf("%s repository is not supported for packaged subchart", fileScheme)
+		}
+		return loader.Load(filepath.Join(chartPath, strings.TrimPrefix(dep.Repository, fileScheme)))
+	case strings.HasPrefix(dep.Repository, ociScheme):
+		return fetchOCIChart(strings.TrimPrefix(dep.Repository, ociScheme), dep.Name, dep.Version)
+	case strings.HasPrefix(dep.Repository, "http://"), strings.HasPrefix(dep.Repository, "https://"):
+		return fetchRepoChart(dep.Repository, dep.Name, dep.Version)
+	default:
+		return nil, fmt.Errorf("repository %s is not supported, use %s, %s or http(s) url instead",
+			dep.Repository, fileScheme, ociScheme)
+	}
+}
+
+// fetchOCIChart pulls the chart archive from OCI registry, the tag of chart is its version.
+func fetchOCIChart(repo, name, version string) (*chart.Chart, error) {
+	ctx := context.Background()
+	named, err := reference.ParseToNamed(repo + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+
+	repository, err := distributionutil.NewV2Repository(named, "pull")
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := repository.Tags(ctx).All(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags of %s: %v", named.Repo(), err)
+	}
+	// "+" is not allowed in OCI tags, so helm pushes chart version "1.0.0+build" as tag "1.0.0_build".
+	var versions []string
+	for _, t := range tags {
+		versions = append(versions, strings.ReplaceAll(t, "_", "+"))
+	}
+	matched, err := matchVersion(version, versions)
+	if err != nil {
+		return nil, err
+	}
+	tag := strings.ReplaceAll(matched, "+", "_")
+
+	ms, err := repository.Manifests(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m, err := ms.Get(ctx, "", distribution.WithTag(tag))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get manifest of %s:%s: %v", named.Repo(), tag, err)
+	}
+	ociManifest, ok := m.(*ocischema.DeserializedManifest)
+	if !ok {
+		return nil, fmt.Errorf("%s:%s is not a helm chart", named.Repo(), tag)
+	}
+
+	for _, layer := range ociManifest.Layers {
+		if layer.MediaType != helmChartContentMediaType && layer.MediaType != helmChartLegacyContentMediaType {
+			continue
+		}
+		content, err := repository.Blobs(ctx).Get(ctx, layer.Digest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get chart content of %s:%s: %v", named.Repo(), tag, err)
+		}
+		return loader.LoadArchive(bytes.NewReader(content))
+	}
+	return nil, fmt.Errorf("no chart content found in %s:%s", named.Repo(), tag)
+}
+
+// fetchRepoChart downloads the chart archive from helm chart repository.
+func fetchRepoChart(repoURL, name, version string) (*chart.Chart, error) {
+	base, err := url.Parse(strings.TrimSuffix(repoURL, "/") + "/")
+	if err != nil {
+		return nil, err
+	}
+
+	indexBytes, err := httpGet(base.ResolveReference(&url.URL{Path: repoIndexFile}).String())
+	if err != nil {
+		return nil, err
+	}
+	index := repoIndex{}
+	if err = yaml.Unmarshal(indexBytes, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse index of %s: %v", repoURL, err)
+	}
+
+	var versions []string
+	for _, entry := range index.Entries[name] {
+		versions = append(versions, entry.Version)
+	}
+	matched, err := matchVersion(version, versions)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range index.Entries[name] {
+		if entry.Version != matched || len(entry.URLs) == 0 {
+			continue
+		}
+		chartURL, err := base.Parse(entry.URLs[0])
+		if err != nil {
+			return nil, err
+		}
+		content, err := httpGet(chartURL.String())
+		if err != nil {
+			return nil, err
+		}
+		return loader.LoadArchive(bytes.NewReader(content))
+	}
+	return nil, fmt.Errorf("no archive url of %s:%s found in %s", name, matched, repoURL)
+}
+
+func httpGet(u string) ([]byte, error) {
+	// #nosec
+	resp, err := http.Get(u)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get %s: %s", u, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// matchVersion returns the highest version matching the semver constraint,
+// the constraint is returned as is if it is an exact version.
+func matchVersion(constraint string, versions []string) (string, error) {
+	for _, v := range versions {
+		if v == constraint {
+			return v, nil
+		}
+	}
+
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return "", fmt.Errorf("invalid version constraint %s: %v", constraint, err)
+	}
+
+	var (
+		matched    string
+		matchedVer *semver.Version
+	)
+	for _, v := range versions {
+		sv, err := semver.NewVersion(v)
+		if err != nil || !c.Check(sv) {
+			continue
+		}
+		if matchedVer == nil || sv.GreaterThan(matchedVer) {
+			matched, matchedVer = v, sv
+		}
+	}
+	if matched == "" {
+		return "", fmt.Errorf("no version matches %s in %v", constraint, versions)
+	}
+	return matched, nil
+}
@@ -0,0 +1,45 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package charts
+
+import "testing"
+
+func Test_matchVersion(t *testing.T) {
+	versions := []string{"1.0.0", "1.2.0", "1.10.1", "2.0.0", "latest"}
+	tests := []struct {
+		name       string
+		constraint string
+		want       string
+		wantErr    bool
+	}{
+		{"test exact version", "1.2.0", "1.2.0", false},
+		{"test non semver tag", "latest", "latest", false},
+		{"test caret range", "^1.0.0", "1.10.1", false},
+		{"test tilde range", "~1.2", "1.2.0", false},
+		{"test no version matched", ">=3.0.0", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := matchVersion(tt.constraint, versions)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("matchVersion() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("matchVersion() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
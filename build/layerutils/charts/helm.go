@@ -45,16 +45,25 @@ func PackageHelmChart(chartPath string) (string, error) {
 	return name, nil
 }
 
-func RenderHelmChart(chartPath string) (map[string]string, error) {
+// RenderHelmChart renders the chart with its dependencies, values overrides the default values of the chart.
+func RenderHelmChart(chartPath string, values map[string]interface{}) (map[string]string, error) {
 	ch, err := Load(chartPath)
 	if err != nil {
 		return nil, err
 	}
 
+	if err = resolveDependencies(ch, chartPath); err != nil {
+		return nil, fmt.Errorf("failed to resolve dependencies of chart %s: %v", ch.Name(), err)
+	}
+	// enable or disable subcharts by conditions and tags, and import values from subcharts.
+	if err = chartutil.ProcessDependencies(ch, values); err != nil {
+		return nil, fmt.Errorf("failed to process dependencies of chart %s: %v", ch.Name(), err)
+	}
+
 	options := chartutil.ReleaseOptions{
 		Name: "dryrun",
 	}
-	valuesToRender, err := chartutil.ToRenderValues(ch, nil, options, nil)
+	valuesToRender, err := chartutil.ToRenderValues(ch, values, options, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to render values: %v", err)
 	}
@@ -69,9 +78,9 @@ func RenderHelmChart(chartPath string) (map[string]string, error) {
 	return content, nil
 }
 
-func GetImageList(chartPath string) ([]string, error) {
+func GetImageList(chartPath string, values map[string]interface{}) ([]string, error) {
 	var list []string
-	content, err := RenderHelmChart(chartPath)
+	content, err := RenderHelmChart(chartPath, values)
 	if err != nil {
 		return list, fmt.Errorf("failed to render helm chart: %s", err)
 	}
@@ -0,0 +1,124 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kustomize
+
+import (
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"path/filepath"
+
+	"sigs.k8s.io/kustomize/api/filesys"
+	"sigs.k8s.io/kustomize/api/konfig"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/yaml"
+
+	"github.com/sealerio/sealer/build/layerutils"
+	osi "github.com/sealerio/sealer/utils/os"
+)
+
+type Kustomize struct{}
+
+// ListImages renders the kustomization dir, and decodes images from the rendered resources,
+// so the images overridden by "images" field of kustomization are used.
+func (k *Kustomize) ListImages(kustomizationDir string) ([]string, error) {
+	resources, err := krusty.MakeKustomizer(krusty.MakeDefaultOptions()).Run(filesys.MakeFsOnDisk(), kustomizationDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render kustomization %s: %v", kustomizationDir, err)
+	}
+
+	content, err := resources.AsYaml()
+	if err != nil {
+		return nil, err
+	}
+	return layerutils.DecodeImages(string(content)), nil
+}
+
+// KustomizationFile returns the kustomization file of dir, it is empty if dir is not a kustomization dir.
+func KustomizationFile(dir string) string {
+	for _, name := range konfig.RecognizedKustomizationFileNames() {
+		if f := filepath.Join(dir, name); osi.IsFileExist(f) {
+			return f
+		}
+	}
+	return ""
+}
+
+// RootDirs finds the kustomization dirs under root which are not referenced by other kustomizations,
+// like overlays, rendering them only avoids collecting images of bases which are overridden.
+func RootDirs(root string) ([]string, error) {
+	var (
+		dirs       []string
+		referenced = map[string]bool{}
+	)
+
+	err := filepath.Walk(root, func(path string, f fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !f.IsDir() {
+			return nil
+		}
+
+		kf := KustomizationFile(path)
+		if kf == "" {
+			return nil
+		}
+		dirs = append(dirs, path)
+
+		refs, err := references(kf)
+		if err != nil {
+			return err
+		}
+		for _, ref := range refs {
+			referenced[filepath.Join(path, ref)] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var roots []string
+	for _, dir := range dirs {
+		if !referenced[dir] {
+			roots = append(roots, dir)
+		}
+	}
+	return roots, nil
+}
+
+func references(kustomizationFile string) ([]string, error) {
+	data, err := ioutil.ReadFile(filepath.Clean(kustomizationFile))
+	if err != nil {
+		return nil, err
+	}
+
+	k := types.Kustomization{}
+	if err = yaml.Unmarshal(data, &k); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", kustomizationFile, err)
+	}
+
+	var refs []string
+	refs = append(refs, k.Resources...)
+	refs = append(refs, k.Bases...)
+	refs = append(refs, k.Components...)
+	return refs, nil
+}
+
+func NewKustomize() (layerutils.Interface, error) {
+	return &Kustomize{}, nil
+}
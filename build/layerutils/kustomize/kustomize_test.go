@@ -0,0 +1,93 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kustomize
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const (
+	baseDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+spec:
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.19
+`
+	baseKustomization = `resources:
+- deployment.yaml
+`
+	overlayKustomization = `resources:
+- ../../base
+images:
+- name: nginx
+  newName: my-registry.com/library/nginx
+  newTag: "1.21"
+`
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestKustomizeListImages(t *testing.T) {
+	root, err := ioutil.TempDir("", "kustomize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	writeFiles(t, root, map[string]string{
+		"base/deployment.yaml":              baseDeployment,
+		"base/kustomization.yaml":           baseKustomization,
+		"overlays/prod/kustomization.yaml":  overlayKustomization,
+		"overlays/prod/ignored/config.yaml": "key: value\n",
+	})
+
+	dirs, err := RootDirs(root)
+	if err != nil {
+		t.Fatalf("RootDirs() error = %v", err)
+	}
+	wantDirs := []string{filepath.Join(root, "overlays/prod")}
+	if !reflect.DeepEqual(dirs, wantDirs) {
+		t.Fatalf("RootDirs() = %v, want %v", dirs, wantDirs)
+	}
+
+	k, _ := NewKustomize()
+	images, err := k.ListImages(dirs[0])
+	if err != nil {
+		t.Fatalf("ListImages() error = %v", err)
+	}
+	wantImages := []string{"my-registry.com/library/nginx:1.21"}
+	if !reflect.DeepEqual(images, wantImages) {
+		t.Errorf("ListImages() = %v, want %v", images, wantImages)
+	}
+}
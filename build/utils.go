@@ -17,9 +17,14 @@ package build
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"path/filepath"
 	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/sealerio/sealer/utils/archive"
 )
 
 const (
@@ -136,3 +141,23 @@ func ValidateContextDirectory(srcPath string) error {
 		return nil
 	})
 }
+
+// setSourceDateEpoch makes layers reproducible when --reproducible is set or SOURCE_DATE_EPOCH is exported,
+// the timestamps of layer entries are set to SOURCE_DATE_EPOCH, or unix epoch if it is not exported.
+func setSourceDateEpoch(reproducible bool) error {
+	epoch, err := archive.SourceDateEpochFromEnv()
+	if err != nil {
+		return err
+	}
+	if epoch == nil {
+		if !reproducible {
+			return nil
+		}
+		e := time.Unix(0, 0).UTC()
+		epoch = &e
+	}
+
+	logrus.Infof("build reproducible layers with source date epoch %d", epoch.Unix())
+	archive.SetSourceDateEpoch(*epoch)
+	return nil
+}
@@ -17,17 +17,27 @@ package alpha
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/olekukonko/tablewriter"
 	"github.com/sealerio/sealer/utils/ssh"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/spf13/cobra"
 
+	"github.com/sealerio/sealer/common"
 	"github.com/sealerio/sealer/pkg/clusterfile"
+	"github.com/sealerio/sealer/pkg/registry"
+	"github.com/sealerio/sealer/pkg/runtime/kubernetes"
 )
 
-var altNames []string
+var (
+	altNames       []string
+	renewRegistry  bool
+	rotateRegistry bool
+)
 
 var longCertCmdDescription = `This command will add the new domain or IP address in cert to update cluster API server.
 
@@ -78,6 +88,98 @@ func NewCertCmd() *cobra.Command {
 	}
 
 	certCmd.Flags().StringSliceVar(&altNames, "alt-names", []string{}, "add DNS domain or IP in certs, if it is already in the cert subject alternative names list, nothing will be changed")
+	certCmd.AddCommand(NewCertRenewCmd())
+	certCmd.AddCommand(NewCertCheckExpirationCmd())
 
 	return certCmd
 }
+
+var exampleForCertRenewCmd = `
+The following command will reissue the registry cert from the registry CA, and distribute the CA to all nodes:
+
+sealer alpha cert renew --registry
+
+rotate the registry CA as well:
+
+sealer alpha cert renew --registry --rotate-ca
+`
+
+// NewCertRenewCmd returns "sealer alpha cert renew" command.
+func NewCertRenewCmd() *cobra.Command {
+	renewCmd := &cobra.Command{
+		Use:     "renew",
+		Short:   "Renew the certs managed by sealer",
+		Args:    cobra.NoArgs,
+		Example: exampleForCertRenewCmd,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !renewRegistry {
+				return fmt.Errorf("cert to renew is required, only --registry is supported now")
+			}
+			rt, err := newClusterRuntime()
+			if err != nil {
+				return err
+			}
+			return rt.RenewRegistryCert(rotateRegistry)
+		},
+	}
+
+	renewCmd.Flags().BoolVar(&renewRegistry, "registry", false, "reissue the built-in registry cert and distribute it to all nodes")
+	renewCmd.Flags().BoolVar(&rotateRegistry, "rotate-ca", false, "generate a new registry CA before reissuing the registry cert")
+
+	return renewCmd
+}
+
+// NewCertCheckExpirationCmd returns "sealer alpha cert check-expiration" command.
+func NewCertCheckExpirationCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "check-expiration",
+		Short:   "Check the expiration of Kubernetes certs and the built-in registry cert",
+		Args:    cobra.NoArgs,
+		Example: "sealer alpha cert check-expiration",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			rt, err := newClusterRuntime()
+			if err != nil {
+				return err
+			}
+			if err = rt.CheckCertsExpiration(); err != nil {
+				return err
+			}
+
+			expirations, err := rt.RegistryCertsExpiration()
+			if err != nil {
+				return err
+			}
+			if len(expirations) == 0 {
+				return nil
+			}
+			table := tablewriter.NewWriter(common.StdOut)
+			table.SetHeader([]string{"REGISTRY CERTIFICATE", "EXPIRES", "RESIDUAL TIME", "CA"})
+			for _, e := range expirations {
+				residual := time.Until(e.NotAfter)
+				table.Append([]string{e.Name, e.NotAfter.Format("Jan 02, 2006 15:04 MST"),
+					fmt.Sprintf("%dd", int(residual.Hours()/24)), strconv.FormatBool(e.IsCA)})
+			}
+			table.Render()
+			return nil
+		},
+	}
+}
+
+// newClusterRuntime returns the runtime of default cluster, the registry config is read from the cluster rootfs,
+// since the ClusterImage may not be mounted.
+func newClusterRuntime() (*kubernetes.Runtime, error) {
+	cluster, err := clusterfile.GetDefaultCluster()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get default cluster: %v", err)
+	}
+	runt, err := kubernetes.NewDefaultRuntime(cluster, nil)
+	if err != nil {
+		return nil, err
+	}
+	rt, ok := runt.(*kubernetes.Runtime)
+	if !ok {
+		return nil, fmt.Errorf("invalid type")
+	}
+	rt.RegConfig = registry.GetClusterConfig(common.DefaultTheClusterRootfsDir(cluster.Name), cluster)
+	return rt, nil
+}
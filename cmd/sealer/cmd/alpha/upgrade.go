@@ -16,6 +16,7 @@ package alpha
 
 import (
 	"github.com/sealerio/sealer/apply"
+	"github.com/sealerio/sealer/apply/driver"
 	"github.com/spf13/cobra"
 )
 
@@ -23,9 +24,14 @@ var upgradeClusterName string
 
 var exampleForUpgradeCmd = `The following command will upgrade the current cluster to kubernetes:v1.19.9
 sealer alpha upgrade kubernetes:v1.19.9
+
+The following command will only print the go/no-go report of upgrading to kubernetes:v1.19.9
+sealer alpha upgrade kubernetes:v1.19.9 --check
 `
 
-var longUpgradeCmdDescription = `Sealer upgrade command will upgrade the current cluster to the specified version with the ClusterImage using kubeadm upgrade
+var longUpgradeCmdDescription = `Sealer upgrade command will upgrade the current cluster to the specified version with the ClusterImage using kubeadm upgrade.
+Before upgrading, the version skew of control-plane and kubelet, the cluster runtime and the removed APIs still in use
+are validated against the ClusterImage, and the upgrade is refused unless all checks are passed.
 `
 
 // NewUpgradeCmd implement the sealer upgrade command
@@ -40,6 +46,7 @@ func NewUpgradeCmd() *cobra.Command {
 	}
 
 	upgradeCmd.Flags().StringVarP(&upgradeClusterName, "cluster", "c", "", "the name of cluster")
+	upgradeCmd.Flags().BoolVar(&driver.UpgradeCheckOnly, "check", false, "only print the report of upgrade validation without upgrading")
 
 	return upgradeCmd
 }
@@ -21,6 +21,8 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/sealerio/sealer/build"
+	"github.com/sealerio/sealer/build/buildinstruction"
+	"github.com/sealerio/sealer/pkg/infra/container"
 	"github.com/sealerio/sealer/utils/platform"
 	"github.com/sealerio/sealer/utils/strings"
 )
@@ -33,6 +35,12 @@ type BuildFlag struct {
 	Platform     string
 	NoCache      bool
 	Base         bool
+	CacheFrom    []string
+	CacheTo      string
+	Secrets      []string
+	Reproducible bool
+	// ContainerImage is the image to run RUN instructions in container build mode.
+	ContainerImage string
 }
 
 var buildConfig *BuildFlag
@@ -58,6 +66,20 @@ build without base:
 
 build with args:
 	sealer build -f Kubefile -t my-kubernetes:1.19.8 --build-arg MY_ARG=abc,PASSWORD=Sealer123 .
+
+build with secrets, which could be referenced by "secret: regcred" in imageListWithAuth.yaml
+and "RUN --mount=type=secret,id=token,env=TOKEN" in Kubefile:
+	sealer build -f Kubefile -t my-kubernetes:1.19.8 --secret id=regcred,src=/root/regcred.yaml --secret id=token,env=TOKEN .
+
+build reproducible ClusterImage, the timestamps of layer content are set to $SOURCE_DATE_EPOCH or 0:
+	sealer build -f Kubefile -t my-kubernetes:1.19.8 --reproducible .
+
+build in container mode, RUN instructions are executed in throwaway containers instead of the build host:
+	sealer build -f Kubefile -t my-kubernetes:1.19.8 --mode container .
+
+build with cache shared across machines:
+	sealer build -f Kubefile -t my-kubernetes:1.19.8 --cache-from /tmp/sealer-cache --cache-to /tmp/sealer-cache .
+	sealer build -f Kubefile -t my-kubernetes:1.19.8 --cache-from my-registry.com/cache/kubernetes:1.19.8 --cache-to my-registry.com/cache/kubernetes:1.19.8 .
 `,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		buildContext := args[0]
@@ -69,12 +91,17 @@ build with args:
 		for _, tp := range targetPlatforms {
 			p := tp
 			conf := &build.Config{
-				BuildType: buildConfig.BuildType,
-				NoCache:   buildConfig.NoCache,
-				ImageName: buildConfig.ImageName,
-				NoBase:    !buildConfig.Base,
-				BuildArgs: strings.ConvertToMap(buildConfig.BuildArgs),
-				Platform:  *p,
+				BuildType:      buildConfig.BuildType,
+				NoCache:        buildConfig.NoCache,
+				ImageName:      buildConfig.ImageName,
+				NoBase:         !buildConfig.Base,
+				BuildArgs:      strings.ConvertToMap(buildConfig.BuildArgs),
+				Platform:       *p,
+				CacheFrom:      buildConfig.CacheFrom,
+				CacheTo:        buildConfig.CacheTo,
+				Secrets:        buildConfig.Secrets,
+				Reproducible:   buildConfig.Reproducible,
+				ContainerImage: buildConfig.ContainerImage,
 			}
 			builder, err := build.NewBuilder(conf)
 			if err != nil {
@@ -92,13 +119,19 @@ build with args:
 func init() {
 	buildConfig = &BuildFlag{}
 	rootCmd.AddCommand(buildCmd)
-	buildCmd.Flags().StringVarP(&buildConfig.BuildType, "mode", "m", "lite", "ClusterImage build type, default is lite")
+	buildCmd.Flags().StringVarP(&buildConfig.BuildType, "mode", "m", buildinstruction.LiteBuildMode,
+		"ClusterImage build type, lite or container, RUN instructions are executed in throwaway containers in container mode")
+	buildCmd.Flags().StringVar(&buildConfig.ContainerImage, "container-image", container.ImageName, "the image of containers which run RUN instructions in container build mode")
 	buildCmd.Flags().StringVarP(&buildConfig.KubefileName, "kubefile", "f", "Kubefile", "Kubefile filepath")
 	buildCmd.Flags().StringVarP(&buildConfig.ImageName, "imageName", "t", "", "the name of ClusterImage")
 	buildCmd.Flags().BoolVar(&buildConfig.NoCache, "no-cache", false, "build without cache")
 	buildCmd.Flags().BoolVar(&buildConfig.Base, "base", true, "build with base image, default value is true.")
 	buildCmd.Flags().StringSliceVar(&buildConfig.BuildArgs, "build-arg", []string{}, "set custom build args")
 	buildCmd.Flags().StringVar(&buildConfig.Platform, "platform", "", "set ClusterImage platform. If not set, keep same platform with runtime")
+	buildCmd.Flags().StringSliceVar(&buildConfig.CacheFrom, "cache-from", []string{}, "import build cache from local directories or registry references")
+	buildCmd.Flags().StringVar(&buildConfig.CacheTo, "cache-to", "", "export build cache to a local directory or a registry reference")
+	buildCmd.Flags().BoolVar(&buildConfig.Reproducible, "reproducible", false, "build with normalized timestamps and ownership of layer content, honors SOURCE_DATE_EPOCH")
+	buildCmd.Flags().StringArrayVar(&buildConfig.Secrets, "secret", []string{}, "set build secret, like id=regcred,src=/path/to/file or id=token,env=TOKEN")
 
 	if err := buildCmd.MarkFlagRequired("imageName"); err != nil {
 		logrus.Errorf("failed to init flag: %v", err)
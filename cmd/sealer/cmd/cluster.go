@@ -0,0 +1,105 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sealerio/sealer/common"
+	"github.com/sealerio/sealer/pkg/clusterfile"
+	"github.com/sealerio/sealer/pkg/runtime"
+	"github.com/sealerio/sealer/pkg/runtime/kubernetes"
+)
+
+var (
+	hibernateClusterName string
+	clusterSnapshotEtcd  bool
+	clusterStartTimeout  time.Duration
+)
+
+var clusterCmd = &cobra.Command{
+	Use:   "cluster",
+	Short: "stop or start a cluster gracefully",
+	Args:  cobra.NoArgs,
+}
+
+var clusterStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "stop a cluster gracefully, like powering it off nightly",
+	Long: `stop command cordons all nodes, then stops the pods, kubelet and container runtime on workers, then on
+masters, and stops the external etcd at last. The etcd can be snapshot before stopping.`,
+	Args: cobra.NoArgs,
+	Example: `
+stop default cluster and snapshot its etcd:
+	sealer cluster stop --snapshot-etcd
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rt, err := newRuntimeByClusterName(hibernateClusterName)
+		if err != nil {
+			return err
+		}
+		return rt.Stop(clusterSnapshotEtcd)
+	},
+}
+
+var clusterStartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "start a cluster stopped by sealer cluster stop",
+	Long: `start command starts the external etcd, masters and workers in order, waits for the etcd, apiserver and
+nodes to be healthy, then uncordons the nodes cordoned by stop. It fails if the cluster is not healthy
+within the timeout.`,
+	Args: cobra.NoArgs,
+	Example: `
+start cluster my-cluster:
+	sealer cluster start -c my-cluster
+
+start cluster my-cluster, whose hosts take long to power on:
+	sealer cluster start -c my-cluster --timeout 30m
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rt, err := newRuntimeByClusterName(hibernateClusterName)
+		if err != nil {
+			return err
+		}
+		return rt.Start(clusterStartTimeout)
+	},
+}
+
+// newRuntimeByClusterName returns the runtime of cluster name, or of the default cluster if name is empty.
+func newRuntimeByClusterName(name string) (runtime.Interface, error) {
+	if name == "" {
+		cn, err := clusterfile.GetDefaultClusterName()
+		if err != nil {
+			return nil, err
+		}
+		name = cn
+	}
+	cluster, err := clusterfile.GetClusterFromFile(common.GetClusterWorkClusterfile(name))
+	if err != nil {
+		return nil, err
+	}
+	return kubernetes.NewDefaultRuntime(cluster, nil)
+}
+
+func init() {
+	rootCmd.AddCommand(clusterCmd)
+	clusterCmd.AddCommand(clusterStopCmd)
+	clusterCmd.AddCommand(clusterStartCmd)
+	clusterCmd.PersistentFlags().StringVarP(&hibernateClusterName, "cluster-name", "c", "", "specify the name of cluster")
+	clusterStopCmd.Flags().BoolVar(&clusterSnapshotEtcd, "snapshot-etcd", false, "save an etcd snapshot under /var/lib/etcd before stopping")
+	clusterStartCmd.Flags().DurationVar(&clusterStartTimeout, "timeout", kubernetes.DefaultStartTimeout, "timeout of waiting for the etcd, apiserver and nodes to be healthy")
+}
@@ -0,0 +1,82 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sealerio/sealer/pkg/image"
+	"github.com/sealerio/sealer/pkg/image/distributionutil"
+)
+
+var (
+	syncFile    string
+	syncOptions distributionutil.CopyOptions
+)
+
+var imageCmd = &cobra.Command{
+	Use:   "image",
+	Short: "manage ClusterImages in registries",
+}
+
+var imageSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "sync ClusterImages between registries",
+	Long: `sync ClusterImage from SRC registry to DST registry directly, without pulling it into the local store.
+The manifest list, and the manifests, configs and layers of all platforms are copied, and the blobs existing in DST are skipped.
+The sync fails if a platform of the manifest list is missing in SRC, unless --skip-missing-platforms is set, then the
+platform is dropped and the manifest list pushed to DST has another digest.
+The images to sync can be listed in a yaml file:
+
+images:
+- src: build.example.com/sealer/kubernetes:v1.22.8
+  dst: site.example.com/sealer/kubernetes:v1.22.8
+
+Run "sealer login" for registries requiring auth first.`,
+	Example: `sealer image sync build.example.com/sealer/kubernetes:v1.22.8 site.example.com/sealer/kubernetes:v1.22.8
+sealer image sync -f images.yaml`,
+	Args: cobra.MaximumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var items []image.SyncItem
+		switch {
+		case syncFile != "" && len(args) == 0:
+			list, err := image.LoadSyncList(syncFile)
+			if err != nil {
+				return err
+			}
+			items = list
+		case syncFile == "" && len(args) == 2:
+			items = []image.SyncItem{{Src: args[0], Dst: args[1]}}
+		default:
+			return fmt.Errorf("either SRC and DST, or the file of images to sync is required")
+		}
+
+		for _, item := range items {
+			if err := image.Sync(item.Src, item.Dst, syncOptions); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	imageCmd.AddCommand(imageSyncCmd)
+	rootCmd.AddCommand(imageCmd)
+	imageSyncCmd.Flags().StringVarP(&syncFile, "file", "f", "", "yaml file listing the images to sync")
+	imageSyncCmd.Flags().BoolVar(&syncOptions.SkipMissingPlatforms, "skip-missing-platforms", false, "drop the platforms missing in SRC from the manifest list instead of failing")
+}
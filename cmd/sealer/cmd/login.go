@@ -34,8 +34,9 @@ var loginConfig *LoginFlag
 var loginCmd = &cobra.Command{
 	Use:   "login",
 	Short: "login image registry",
-	// TODO: add long description.
-	Long:    "",
+	Long: `login image registry, the credentials are saved in /root/.docker/config.json, or in the credential helper
+configured by "credsStore" or "credHelpers" of it, like pass, secretservice or the path of a custom helper executable.
+the identity token is saved instead of password if the registry uses token auth and returns one.`,
 	Example: `sealer login registry.cn-qingdao.aliyuncs.com -u [username] -p [password]`,
 	Args:    cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -21,21 +21,30 @@ import (
 	"github.com/sealerio/sealer/pkg/image/utils"
 )
 
+var pushOpts image.PushOptions
+
 // pushCmd represents the push command
 var pushCmd = &cobra.Command{
 	Use:   "push",
 	Short: "push ClusterImage to remote registry",
-	// TODO: add long description.
-	Long:    "",
-	Example: `sealer push registry.cn-qingdao.aliyuncs.com/sealer-io/my-kubernetes-cluster-with-dashboard:latest`,
-	Args:    cobra.ExactArgs(1),
+	Long: `push ClusterImage to remote registry as a docker image by default.
+
+With --oci-artifact, ClusterImage is pushed as an OCI artifact, its config media type is
+application/vnd.sealer.clusterimage.config.v1+json and its artifactType is application/vnd.sealer.clusterimage.v1,
+so registries like Harbor list it as a ClusterImage instead of a broken container image.
+sealer pull understands both formats.`,
+	Example: `sealer push registry.cn-qingdao.aliyuncs.com/sealer-io/my-kubernetes-cluster-with-dashboard:latest
+
+push as an OCI artifact:
+  sealer push --oci-artifact registry.cn-qingdao.aliyuncs.com/sealer-io/my-kubernetes-cluster-with-dashboard:latest`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		imgsvc, err := image.NewImageService()
 		if err != nil {
 			return err
 		}
 
-		return imgsvc.Push(args[0])
+		return imgsvc.Push(args[0], pushOpts)
 
 	},
 	ValidArgsFunction: utils.ImageListFuncForCompletion,
@@ -43,4 +52,5 @@ var pushCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(pushCmd)
+	pushCmd.Flags().BoolVar(&pushOpts.OCIArtifact, "oci-artifact", false, "push ClusterImage as an OCI artifact with sealer media types")
 }
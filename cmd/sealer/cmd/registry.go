@@ -0,0 +1,277 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/sealerio/sealer/cmd/sealer/cmd/alpha"
+	"github.com/sealerio/sealer/common"
+	"github.com/sealerio/sealer/pkg/client/k8s"
+	"github.com/sealerio/sealer/pkg/registry"
+	"github.com/sealerio/sealer/pkg/runtime/kubernetes"
+	v2 "github.com/sealerio/sealer/types/api/v2"
+	"github.com/sealerio/sealer/utils"
+	"github.com/sealerio/sealer/utils/ssh"
+)
+
+const (
+	registryRepo  = "REPOSITORY"
+	registryTag   = "TAG"
+	registryInUse = "IN USE"
+	// clusterImageListFile lists the images of ClusterImage under the manifests dir of rootfs.
+	clusterImageListFile = "imageList"
+)
+
+type registryFlag struct {
+	ClusterName string
+	DryRun      bool
+	Force       bool
+}
+
+var registryFlags registryFlag
+
+var registryCmd = &cobra.Command{
+	Use:   "registry",
+	Short: "manage the images in the registry of cluster",
+}
+
+var registryListCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "list the images in the registry of cluster",
+	Long:  "list the repositories, tags and sizes of images in the registry of cluster, and whether running pods use them",
+	Args:  cobra.NoArgs,
+	Example: `sealer registry ls
+sealer registry ls -c my-cluster`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		_, _, nodeImages, err := listRegistryImages(registryFlags.ClusterName)
+		if err != nil {
+			return err
+		}
+
+		table := tablewriter.NewWriter(common.StdOut)
+		table.SetHeader([]string{registryRepo, registryTag, imageSize, registryInUse})
+		for _, image := range mergeRegistryImages(nodeImages) {
+			table.Append([]string{image.Repo, image.Tag, formatSize(image.Size), strconv.FormatBool(image.InUse)})
+		}
+		table.Render()
+		return nil
+	},
+}
+
+var registryGCCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "delete the images not used by any pod from the registry of cluster",
+	Long: `delete the images not used by any running pod from the registry of cluster, and run the blob garbage collection on every registry node.
+the sandbox image and the images listed in the ClusterImage are always kept.
+images used by workloads without running pods, like CronJobs or Deployments scaled to zero, are deleted too, use --dry-run to check them first.
+the registry on each node is stopped during its garbage collection, so images can not be pulled or pushed from it meanwhile.`,
+	Args: cobra.NoArgs,
+	Example: `sealer registry gc --dry-run
+sealer registry gc -c my-cluster --force`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cluster, regConfig, nodeImages, err := listRegistryImages(registryFlags.ClusterName)
+		if err != nil {
+			return err
+		}
+		if regConfig.External {
+			return fmt.Errorf("garbage collection of external registry %s is not supported", regConfig.Host())
+		}
+
+		registryDir := filepath.Join(common.DefaultTheClusterRootfsDir(cluster.Name), common.RegistryDirName)
+		nodes := regConfig.GetRegistryNodes(cluster.GetMasterIPList())
+		nodeUnused := map[string][]registry.Image{}
+		total := 0
+		for _, node := range nodes {
+			nodeUnused[node.String()] = registry.UnusedImages(nodeImages[node.String()])
+			for _, image := range nodeUnused[node.String()] {
+				logrus.Infof("image %s on %s is not used by any pod, it will be deleted", image, node)
+			}
+			total += len(nodeUnused[node.String()])
+		}
+		if registryFlags.DryRun || total == 0 {
+			return nil
+		}
+		if !registryFlags.Force {
+			if pass, err := utils.ConfirmOperation("The registry is stopped during garbage collection, are you sure to delete these images? "); err != nil {
+				return err
+			} else if !pass {
+				return nil
+			}
+		}
+
+		deleted := false
+		for _, node := range nodes {
+			images := nodeImages[node.String()]
+			unused := nodeUnused[node.String()]
+			if len(unused) == 0 {
+				continue
+			}
+
+			sshClient, err := ssh.NewStdoutSSHClient(node, cluster)
+			if err != nil {
+				return fmt.Errorf("failed to new ssh client: %v", err)
+			}
+			if err = sshClient.CmdAsync(node, registry.GCCommands(registryDir, kubernetes.RegistryName, unused, images)...); err != nil {
+				return fmt.Errorf("failed to run garbage collection of registry on %s: %v", node, err)
+			}
+			deleted = true
+		}
+		if !deleted {
+			return nil
+		}
+		return regConfig.SyncReplicas(nodes)
+	},
+}
+
+var registrySyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "sync the images among the registry nodes of cluster",
+	Long: `sync the images among the registry nodes of cluster in HA mode by the registry API, the recorded registry node is
+the source of truth: the images only on other nodes are copied to it, then its images are copied to the other nodes,
+overwriting the tags pointing to other manifests there. only the missing manifests and blobs are transferred.
+run it after pushing images to the registry domain, which is resolved to one of the registry nodes.`,
+	Args:    cobra.NoArgs,
+	Example: `sealer registry sync -c my-cluster`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cluster, err := alpha.GetCurrentClusterByName(registryFlags.ClusterName)
+		if err != nil {
+			return fmt.Errorf("failed to get cluster: %v", err)
+		}
+		regConfig := registry.GetClusterConfig(common.DefaultTheClusterRootfsDir(cluster.Name), cluster)
+		if regConfig.External {
+			return fmt.Errorf("sync of external registry %s is not supported", regConfig.Host())
+		}
+		return regConfig.SyncReplicas(regConfig.GetRegistryNodes(cluster.GetMasterIPList()))
+	},
+}
+
+// listRegistryImages lists the images in the registry of cluster by registry node, or by the external registry
+// host. The images are marked if any pod of cluster uses them or they are kept by the ClusterImage.
+func listRegistryImages(clusterName string) (*v2.Cluster, *registry.Config, map[string][]registry.Image, error) {
+	cluster, err := alpha.GetCurrentClusterByName(clusterName)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to get cluster: %v", err)
+	}
+
+	regConfig := registry.GetClusterConfig(common.DefaultTheClusterRootfsDir(cluster.Name), cluster)
+	client, err := k8s.Newk8sClient()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	namespacePods, err := client.ListAllNamespacesPods()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var pods []v1.Pod
+	for _, namespacePod := range namespacePods {
+		pods = append(pods, namespacePod.PodList.Items...)
+	}
+
+	hosts := map[string]net.IP{}
+	nodes := regConfig.GetRegistryNodes(cluster.GetMasterIPList())
+	if regConfig.External {
+		hosts[regConfig.Host()] = cluster.GetMasterIPList()[0]
+	}
+	for _, node := range nodes {
+		// connect the registry node directly, the registry domain may not be resolved on this host.
+		hosts[node.String()] = node
+	}
+
+	nodeImages := map[string][]registry.Image{}
+	for key, node := range hosts {
+		host := key
+		if !regConfig.External {
+			host = net.JoinHostPort(key, regConfig.Port)
+		}
+		images, err := regConfig.ListImages(host)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		kept, err := clusterImageList(cluster, node)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		registry.MarkInUse(images, pods, kept)
+		sort.Slice(images, func(i, j int) bool {
+			return images[i].String() < images[j].String()
+		})
+		nodeImages[key] = images
+	}
+	return cluster, regConfig, nodeImages, nil
+}
+
+// clusterImageList reads the images listed in the ClusterImage from the cluster rootfs on node.
+func clusterImageList(cluster *v2.Cluster, node net.IP) ([]string, error) {
+	sshClient, err := ssh.NewStdoutSSHClient(node, cluster)
+	if err != nil {
+		return nil, fmt.Errorf("failed to new ssh client: %v", err)
+	}
+	imageList := filepath.Join(common.DefaultTheClusterRootfsDir(cluster.Name), common.RenderManifestsDir, clusterImageListFile)
+	out, err := sshClient.Cmd(node, fmt.Sprintf("cat %s 2>/dev/null || true", imageList))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image list of ClusterImage on %s: %v", node, err)
+	}
+	var images []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			images = append(images, line)
+		}
+	}
+	return images, nil
+}
+
+// mergeRegistryImages merges the images of registry nodes, an image is in use if it is in use on any node.
+func mergeRegistryImages(nodeImages map[string][]registry.Image) []registry.Image {
+	var (
+		images []registry.Image
+		index  = map[string]int{}
+	)
+	for _, nodeImage := range nodeImages {
+		for _, image := range nodeImage {
+			if i, ok := index[image.String()]; ok {
+				images[i].InUse = images[i].InUse || image.InUse
+				continue
+			}
+			index[image.String()] = len(images)
+			images = append(images, image)
+		}
+	}
+	sort.Slice(images, func(i, j int) bool {
+		return images[i].String() < images[j].String()
+	})
+	return images
+}
+
+func init() {
+	registryCmd.PersistentFlags().StringVarP(&registryFlags.ClusterName, "cluster-name", "c", "", "specify the name of cluster")
+	registryGCCmd.Flags().BoolVar(&registryFlags.DryRun, "dry-run", false, "only print the images to delete")
+	registryGCCmd.Flags().BoolVar(&registryFlags.Force, "force", false, "delete the images without confirmation")
+
+	registryCmd.AddCommand(registryListCmd)
+	registryCmd.AddCommand(registryGCCmd)
+	registryCmd.AddCommand(registrySyncCmd)
+	rootCmd.AddCommand(registryCmd)
+}
@@ -0,0 +1,66 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sealerio/sealer/apply"
+	"github.com/sealerio/sealer/common"
+	"github.com/sealerio/sealer/pkg/clusterfile"
+	"github.com/sealerio/sealer/pkg/runtime/kubernetes"
+)
+
+var (
+	replaceClusterName string
+	replaceOldIP       string
+	replaceNewIP       string
+)
+
+var replaceCmd = &cobra.Command{
+	Use:   "replace",
+	Short: "replace a failed host of cluster with a new one",
+	Long: `replace command removes the old host from cluster by force, which may be unreachable, including its etcd member
+and node object, then joins the new host in the same roles. The env, ssh config and node labels of the old host
+are carried over, and the Clusterfile is updated after the new host is joined.`,
+	Args: cobra.NoArgs,
+	Example: `
+replace a host of default cluster:
+	sealer replace --old 192.168.0.2 --new 192.168.0.10
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if replaceOldIP == "" || replaceNewIP == "" {
+			return fmt.Errorf("both the old and new host are required")
+		}
+		if replaceClusterName == "" {
+			cn, err := clusterfile.GetDefaultClusterName()
+			if err != nil {
+				return err
+			}
+			replaceClusterName = cn
+		}
+		return apply.Replace(common.GetClusterWorkClusterfile(replaceClusterName), replaceOldIP, replaceNewIP)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(replaceCmd)
+	replaceCmd.Flags().StringVar(&replaceOldIP, "old", "", "the ip of host to be replaced")
+	replaceCmd.Flags().StringVar(&replaceNewIP, "new", "", "the ip of new host")
+	replaceCmd.Flags().StringVarP(&replaceClusterName, "cluster-name", "c", "", "specify the name of cluster")
+	replaceCmd.Flags().BoolVar(&kubernetes.ForceDelete, "force", false, "replace the host without confirmation")
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/spf13/viper"
 
 	"github.com/sealerio/sealer/common"
+	"github.com/sealerio/sealer/pkg/image/mirror"
 	"github.com/sealerio/sealer/version"
 )
 
@@ -102,6 +103,7 @@ func initConfig() {
 	// if not set config file, Search config in home directory with name ".sealer.json" (without extension).
 	//viper.AddConfigPath(home)
 	viper.SetConfigFile(rootOpt.cfgFile)
+	mirror.SetConfigFile(rootOpt.cfgFile)
 
 	viper.AutomaticEnv() // read in environment variables that match
 
@@ -15,57 +15,79 @@
 package cmd
 
 import (
-	"context"
+	"encoding/json"
 	"fmt"
 
-	"github.com/sealerio/sealer/common"
-	"github.com/sealerio/sealer/pkg/image/reference"
-	save2 "github.com/sealerio/sealer/pkg/image/save"
-
-	reference2 "github.com/distribution/distribution/v3/reference"
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
+
+	"github.com/sealerio/sealer/common"
+	"github.com/sealerio/sealer/pkg/image"
+)
+
+const (
+	searchTag         = "TAG"
+	searchPlatform    = "PLATFORM"
+	searchKubeVersion = "KUBERNETES VERSION"
 )
 
+type searchFlag struct {
+	image.SearchOptions
+	Output string
+}
+
+var searchFlags searchFlag
+
 // searchCmd represents the search command
 var searchCmd = &cobra.Command{
 	Use:   "search",
 	Short: "search ClusterImage in default registry",
-	// TODO: add long description.
-	Long: "",
+	Long: `search the tags of ClusterImage in registry, with the platforms, created time, size and kubernetes version of each tag,
+which are read from the manifest list and image config, so the image is not pulled. The platform of a tag whose
+metadata can not be read is "unknown". The tags can be filtered by semver constraint, regular expression and platform.`,
 	Example: `sealer search <imageDomain>/<imageRepo>/<imageName> ...
 ## default imageDomain: 'registry.cn-qingdao.aliyuncs.com', default imageRepo: 'sealer-io'
 ex.:
   sealer search kubernetes seadent/rootfs docker.io/library/hello-world
+  sealer search kubernetes --semver ">= 1.20, < 1.23" --platform linux/arm64
+  sealer search kubernetes --regex "^v1.22" -o json
 `,
 	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if searchFlags.Output != "table" && searchFlags.Output != "json" {
+			return fmt.Errorf("unsupported output format %s, only table and json are supported", searchFlags.Output)
+		}
 
-		table := tablewriter.NewWriter(common.StdOut)
-		table.SetHeader([]string{imageName, "version"})
+		var infos []image.TagInfo
 		for _, imgName := range args {
-			named, err := reference.ParseToNamed(imgName)
+			tags, err := image.Search(imgName, searchFlags.SearchOptions)
 			if err != nil {
 				return err
 			}
-			ns, err := save2.NewProxyRegistry(context.Background(), "", named.Domain())
-			if err != nil {
-				return err
-			}
-			rNamed, err := reference2.WithName(named.Repo())
-			if err != nil {
-				return fmt.Errorf("failed to get repository name: %v", err)
-			}
-			repo, err := ns.Repository(context.Background(), rNamed)
-			if err != nil {
-				return err
+			infos = append(infos, tags...)
+		}
+
+		if searchFlags.Output == "json" {
+			if infos == nil {
+				infos = []image.TagInfo{}
 			}
-			tags, err := repo.Tags(context.Background()).All(context.Background())
+			data, err := json.MarshalIndent(infos, "", "  ")
 			if err != nil {
 				return err
 			}
-			for _, tag := range tags {
-				table.Append([]string{named.String(), tag})
+			_, err = fmt.Fprintln(common.StdOut, string(data))
+			return err
+		}
+
+		table := tablewriter.NewWriter(common.StdOut)
+		table.SetHeader([]string{imageName, searchTag, searchPlatform, imageCreate, imageSize, searchKubeVersion})
+		for _, info := range infos {
+			for _, p := range info.Platforms {
+				created := "-"
+				if p.Created != nil {
+					created = p.Created.Format(timeDefaultFormat)
+				}
+				table.Append([]string{info.Name, info.Tag, p.Platform, created, formatSize(p.Size), p.KubeVersion})
 			}
 		}
 		table.Render()
@@ -75,4 +97,8 @@ ex.:
 
 func init() {
 	rootCmd.AddCommand(searchCmd)
+	searchCmd.Flags().StringVar(&searchFlags.Semver, "semver", "", "only list the tags matching the semver constraint, like \">= 1.20, < 1.23\"")
+	searchCmd.Flags().StringVar(&searchFlags.Regex, "regex", "", "only list the tags matching the regular expression")
+	searchCmd.Flags().StringVar(&searchFlags.Platform, "platform", "", "only list the tags having the image of platform, like linux/amd64")
+	searchCmd.Flags().StringVarP(&searchFlags.Output, "output", "o", "table", "output format, table or json")
 }
@@ -55,7 +55,7 @@ func NewCertGenCmd() *cobra.Command {
 
 	certsCmd.Flags().StringSliceVar(&flag.AltNames, "alt-names", []string{}, "like sealyun.com or 10.103.97.2")
 	certsCmd.Flags().StringVar(&flag.NodeName, "node-name", "", "like master0")
-	certsCmd.Flags().StringVar(&flag.ServiceCIDR, "service-cidr", "", "like 10.103.97.2/24")
+	certsCmd.Flags().StringVar(&flag.ServiceCIDR, "service-cidr", "", "like 10.103.97.2/24, or 10.103.97.2/24,fd00:10:96::/108 for dual-stack cluster")
 	certsCmd.Flags().StringVar(&flag.NodeIP, "node-ip", "", "like 10.103.97.2")
 	certsCmd.Flags().StringVar(&flag.DNSDomain, "dns-domain", "cluster.local", "cluster dns domain")
 	certsCmd.Flags().StringVar(&flag.CertPath, "cert-path", clustercert.KubeDefaultCertPath, "kubernetes cert file path")
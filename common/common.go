@@ -23,6 +23,7 @@ import (
 const (
 	MASTER  = "master"
 	NODE    = "node"
+	ETCD    = "etcd"
 	MASTER0 = "master0"
 )
 
@@ -49,6 +50,8 @@ const (
 	TarGzSuffix                   = ".tar.gz"
 	YamlSuffix                    = ".yaml"
 	ImageAnnotationForClusterfile = "sea.aliyun.com/ClusterFile"
+	ImageAnnotationForCacheIDs    = "sea.aliyun.com/CacheIDs"
+	ImageAnnotationForKubeVersion = "sea.aliyun.com/KubeVersion"
 	RawClusterfile                = "/var/lib/sealer/Clusterfile"
 	TmpClusterfile                = "/tmp/Clusterfile"
 	DefaultRegistryHostName       = "registry.cn-qingdao.aliyuncs.com"
@@ -130,7 +133,10 @@ const (
 	FileMode0644 = 0644
 )
 
-const APIServerDomain = "apiserver.cluster.local"
+const (
+	APIServerDomain = "apiserver.cluster.local"
+	APIServerPort   = "6443"
+)
 
 const (
 	CdAndExecCmd = "cd %s && %s"
@@ -30,14 +30,39 @@ import (
 )
 
 type Item struct {
-	Auth string `json:"auth"`
+	Auth string `json:"auth,omitempty"`
+	// IdentityToken is the refresh token got from token auth server on login, it is used instead of password.
+	IdentityToken string `json:"identitytoken,omitempty"`
 }
 
 type DockerAuth struct {
 	Auths map[string]Item `json:"auths"`
+	// CredsStore is the default credential helper, like pass or secretservice, which stores the credentials
+	// instead of the auth file.
+	CredsStore string `json:"credsStore,omitempty"`
+	// CredHelpers is the credential helpers of registries, it takes precedence over CredsStore.
+	CredHelpers map[string]string `json:"credHelpers,omitempty"`
+}
+
+// helper returns the credential helper storing the credentials of domain, it is false if they are in the auth file.
+func (d *DockerAuth) helper(domain string) (credentialHelper, bool) {
+	if name, ok := d.CredHelpers[domain]; ok && name != "" {
+		return newCredentialHelper(name), true
+	}
+	if d.CredsStore != "" {
+		return newCredentialHelper(d.CredsStore), true
+	}
+	return credentialHelper{}, false
 }
 
 func (d *DockerAuth) Get(domain string) (string, string, error) {
+	if h, ok := d.helper(domain); ok {
+		return h.get(domain)
+	}
+	if token := d.Auths[domain].IdentityToken; token != "" {
+		return tokenUsername, token, nil
+	}
+
 	auth := d.Auths[domain].Auth
 	if auth == "" {
 		return "", "", fmt.Errorf("auth for %s doesn't exist", domain)
@@ -56,9 +81,20 @@ func (d *DockerAuth) Get(domain string) (string, string, error) {
 	return string(decode[:i]), string(decode[i+1:]), nil
 }
 
-func (d *DockerAuth) Set(hostname, username, password string) {
-	authEncode := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", username, password)))
+// Set saves the credentials of hostname, into the credential helper if there is one, and the auth file keeps
+// an empty entry of hostname like docker does. the secret is an identity token if username is "<token>".
+func (d *DockerAuth) Set(hostname, username, secret string) error {
+	if h, ok := d.helper(hostname); ok {
+		d.Auths[hostname] = Item{}
+		return h.store(credentials{ServerURL: hostname, Username: username, Secret: secret})
+	}
+	if username == tokenUsername {
+		d.Auths[hostname] = Item{IdentityToken: secret}
+		return nil
+	}
+	authEncode := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", username, secret)))
 	d.Auths[hostname] = Item{Auth: authEncode}
+	return nil
 }
 
 type DockerAuthService struct {
@@ -73,7 +109,9 @@ func (s *DockerAuthService) SetAuthInfo(hostname, username, password string) err
 		}
 	}
 
-	s.AuthContent.Set(hostname, username, password)
+	if err := s.AuthContent.Set(hostname, username, password); err != nil {
+		return err
+	}
 	data, err := json.MarshalIndent(s.AuthContent, "", "\t")
 	if err != nil {
 		return err
@@ -85,6 +123,14 @@ func (s *DockerAuthService) SetAuthInfo(hostname, username, password string) err
 	return nil
 }
 
+// SetAuth saves authConfig of hostname, the identity token is saved instead of password if there is one.
+func (s *DockerAuthService) SetAuth(hostname string, authConfig types.AuthConfig) error {
+	if authConfig.IdentityToken != "" {
+		return s.SetAuthInfo(hostname, tokenUsername, authConfig.IdentityToken)
+	}
+	return s.SetAuthInfo(hostname, authConfig.Username, authConfig.Password)
+}
+
 func (s *DockerAuthService) GetAuthInfoByDomain(domain string) (string, string, error) {
 	return s.AuthContent.Get(domain)
 }
@@ -97,6 +143,12 @@ func (s *DockerAuthService) GetAuthByDomain(domain string) (types.AuthConfig, er
 		return defaultAuthConfig, err
 	}
 
+	if user == tokenUsername {
+		return types.AuthConfig{
+			IdentityToken: passwd,
+			ServerAddress: domain,
+		}, nil
+	}
 	return types.AuthConfig{
 		Username:      user,
 		Password:      passwd,
@@ -0,0 +1,100 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// stubHelper is a credential helper keeping the credentials of one server in a file next to it.
+const stubHelper = `#!/bin/sh
+store="$(dirname "$0")/store.json"
+case "$1" in
+store) cat > "$store" ;;
+get)
+  read server
+  if [ -f "$store" ] && grep -q "\"ServerURL\":\"$server\"" "$store"; then cat "$store"; else echo "credentials not found in native keychain"; exit 1; fi ;;
+esac
+`
+
+func TestDockerAuthWithCredentialHelper(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sealer-auth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	helperPath := filepath.Join(dir, "docker-credential-stub")
+	if err = ioutil.WriteFile(helperPath, []byte(stubHelper), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	d := DockerAuth{
+		Auths:       map[string]Item{},
+		CredHelpers: map[string]string{"registry.example.com": helperPath},
+	}
+	if err = d.Set("registry.example.com", "user", "passwd"); err != nil {
+		t.Fatalf("failed to set auth: %v", err)
+	}
+	if d.Auths["registry.example.com"].Auth != "" {
+		t.Errorf("auth should not be saved in auth file if there is a credential helper")
+	}
+
+	user, passwd, err := d.Get("registry.example.com")
+	if err != nil || user != "user" || passwd != "passwd" {
+		t.Errorf("Get() = %s, %s, %v, want user, passwd, nil", user, passwd, err)
+	}
+
+	_, _, err = (&DockerAuth{CredsStore: helperPath}).Get("other.example.com")
+	if err == nil || !strings.Contains(err.Error(), "doesn't exist") {
+		t.Errorf("Get() of unknown server should report the auth doesn't exist, got %v", err)
+	}
+}
+
+func TestDockerAuthWithIdentityToken(t *testing.T) {
+	svc := DockerAuthService{
+		FilePath:    filepath.Join(os.TempDir(), "sealer-auth-token.json"),
+		AuthContent: DockerAuth{Auths: map[string]Item{}},
+	}
+	defer func() {
+		_ = os.Remove(svc.FilePath)
+	}()
+
+	if err := svc.SetAuthInfo("registry.example.com", tokenUsername, "refresh-token"); err != nil {
+		t.Fatalf("failed to set auth: %v", err)
+	}
+	data, err := ioutil.ReadFile(svc.FilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved DockerAuth
+	if err = json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if saved.Auths["registry.example.com"].IdentityToken != "refresh-token" {
+		t.Errorf("identity token is not saved: %s", data)
+	}
+
+	authConfig, err := svc.GetAuthByDomain("registry.example.com")
+	if err != nil || authConfig.IdentityToken != "refresh-token" || authConfig.Password != "" {
+		t.Errorf("GetAuthByDomain() = %+v, %v, want identity token only", authConfig, err)
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+const (
+	// credentialHelperPrefix is the prefix of credential helper binaries, like docker-credential-pass.
+	credentialHelperPrefix = "docker-credential-"
+	// tokenUsername is the username stored by docker for an identity token.
+	tokenUsername = "<token>"
+	// errCredentialsNotFound is the message of credential helpers when there are no credentials of server.
+	errCredentialsNotFound = "credentials not found in native keychain"
+)
+
+// credentials is the message exchanged with credential helpers.
+type credentials struct {
+	ServerURL string `json:"ServerURL"`
+	Username  string `json:"Username"`
+	Secret    string `json:"Secret"`
+}
+
+// credentialHelper talks to a docker credential helper, like pass, secretservice or osxkeychain,
+// or a custom one, which is the path of helper executable.
+type credentialHelper struct {
+	program string
+}
+
+func newCredentialHelper(name string) credentialHelper {
+	if strings.Contains(name, "/") {
+		return credentialHelper{program: name}
+	}
+	return credentialHelper{program: credentialHelperPrefix + name}
+}
+
+// get returns the username and secret of serverURL, the username is "<token>" if the secret is an identity token.
+func (h credentialHelper) get(serverURL string) (string, string, error) {
+	out, err := h.run("get", strings.NewReader(serverURL))
+	if err != nil {
+		if strings.Contains(out, errCredentialsNotFound) {
+			return "", "", fmt.Errorf("auth for %s doesn't exist", serverURL)
+		}
+		return "", "", fmt.Errorf("failed to get auth for %s from %s: %v: %s", serverURL, h.program, err, out)
+	}
+
+	var c credentials
+	if err = json.Unmarshal([]byte(out), &c); err != nil {
+		return "", "", fmt.Errorf("failed to parse the output of %s: %v", h.program, err)
+	}
+	return c.Username, c.Secret, nil
+}
+
+func (h credentialHelper) store(c credentials) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if out, err := h.run("store", bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("failed to store auth for %s to %s: %v: %s", c.ServerURL, h.program, err, out)
+	}
+	return nil
+}
+
+// run returns the stdout of helper, and stderr is appended if it fails.
+func (h credentialHelper) run(action string, input io.Reader) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(h.program, action) // #nosec
+	cmd.Stdin = input
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return strings.TrimSpace(stdout.String() + " " + stderr.String()), err
+	}
+	return stdout.String(), nil
+}
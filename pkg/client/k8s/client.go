@@ -16,13 +16,17 @@ package k8s
 
 import (
 	"context"
+	"encoding/json"
 	"net"
 	"path/filepath"
 
 	"github.com/pkg/errors"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	v12 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/clientcmd"
@@ -32,7 +36,8 @@ import (
 )
 
 type Client struct {
-	client *kubernetes.Clientset
+	client  *kubernetes.Clientset
+	dynamic dynamic.Interface
 }
 
 type NamespacePod struct {
@@ -61,9 +66,14 @@ func Newk8sClient() (*Client, error) {
 	if err != nil {
 		return nil, err
 	}
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
 
 	return &Client{
-		client: clientSet,
+		client:  clientSet,
+		dynamic: dynamicClient,
 	}, nil
 }
 
@@ -79,6 +89,14 @@ func (c *Client) ListNodes() (*v1.NodeList, error) {
 	return nodes, nil
 }
 
+func (c *Client) GetNode(name string) (*v1.Node, error) {
+	node, err := c.client.CoreV1().Nodes().Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get cluster node(%s)", name)
+	}
+	return node, nil
+}
+
 func (c *Client) UpdateNode(node v1.Node) (*v1.Node, error) {
 	n, err := c.client.CoreV1().Nodes().Update(context.TODO(), &node, metav1.UpdateOptions{})
 	if err != nil {
@@ -211,3 +229,57 @@ func (c *Client) ListKubeSystemPodsStatus() (bool, error) {
 	}
 	return true, nil
 }
+
+// ListObjectsInAPIVersion returns the "namespace/name" of objects of resource which are written in the group
+// version of resource, according to their managed fields and last applied configuration. Nothing is returned if
+// the resource is not served in the group version.
+func (c *Client) ListObjectsInAPIVersion(resource schema.GroupVersionResource) ([]string, error) {
+	gv := resource.GroupVersion().String()
+	resources, err := c.client.Discovery().ServerResourcesForGroupVersion(gv)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to discover resources of %s", gv)
+	}
+	served := false
+	for _, r := range resources.APIResources {
+		if r.Name == resource.Resource {
+			served = true
+			break
+		}
+	}
+	if !served {
+		return nil, nil
+	}
+
+	list, err := c.dynamic.Resource(resource).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list %s", resource)
+	}
+	var objects []string
+	for _, obj := range list.Items {
+		inUse := false
+		for _, f := range obj.GetManagedFields() {
+			if f.APIVersion == gv {
+				inUse = true
+				break
+			}
+		}
+		if applied, ok := obj.GetAnnotations()[v1.LastAppliedConfigAnnotation]; ok && !inUse {
+			var typeMeta metav1.TypeMeta
+			if err := json.Unmarshal([]byte(applied), &typeMeta); err == nil && typeMeta.APIVersion == gv {
+				inUse = true
+			}
+		}
+		if !inUse {
+			continue
+		}
+		name := obj.GetName()
+		if obj.GetNamespace() != "" {
+			name = obj.GetNamespace() + "/" + name
+		}
+		objects = append(objects, name)
+	}
+	return objects, nil
+}
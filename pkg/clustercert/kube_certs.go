@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/sealerio/sealer/pkg/clustercert/cert"
@@ -201,15 +202,19 @@ func GenerateAllKubernetesCerts(certPath, etcdCertPath, nodeName, serviceCIRD, D
 
 	clusterCertArgs.APIServerAltNames.IPs[nodeIP.String()] = nodeIP
 
-	_, svcSubnet, err := net.ParseCIDR(serviceCIRD)
-	if err != nil {
-		return errors.Wrapf(err, "unable to parse ServiceSubnet %v", serviceCIRD)
-	}
-	svcFirstIP, err := utilnet.GetIndexedIP(svcSubnet, 1)
-	if err != nil {
-		return err
+	// the service subnet of dual-stack cluster is a pair of comma separated IPv4 and IPv6 CIDRs,
+	// the first IP of each is the kubernetes service IP of the family.
+	for _, cidr := range strings.Split(serviceCIRD, ",") {
+		_, svcSubnet, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			return errors.Wrapf(err, "unable to parse ServiceSubnet %v", serviceCIRD)
+		}
+		svcFirstIP, err := utilnet.GetIndexedIP(svcSubnet, 1)
+		if err != nil {
+			return err
+		}
+		clusterCertArgs.APIServerAltNames.IPs[svcFirstIP.String()] = svcFirstIP
 	}
-	clusterCertArgs.APIServerAltNames.IPs[svcFirstIP.String()] = svcFirstIP
 
 	for _, altName := range altNames {
 		ip := net.ParseIP(altName)
@@ -228,7 +233,7 @@ func GenerateAllKubernetesCerts(certPath, etcdCertPath, nodeName, serviceCIRD, D
 		serviceAccount: cert.NewKeyPairFileGenerator(certPath, "sa"),
 	}
 
-	err = certService.GenerateKubeComponentCert()
+	err := certService.GenerateKubeComponentCert()
 	if err != nil {
 		return err
 	}
@@ -241,6 +246,29 @@ func GenerateAllKubernetesCerts(certPath, etcdCertPath, nodeName, serviceCIRD, D
 	return nil
 }
 
+// GenerateEtcdHostCerts generates the server, peer and healthcheck-client certs of the external etcd member nodeName
+// into hostCertPath, signed by the etcd CA in etcdCertPath, which is generated with the cluster certs.
+func GenerateEtcdHostCerts(etcdCertPath, hostCertPath, nodeName string, nodeIP net.IP) error {
+	if nodeName == "" || nodeIP == nil {
+		return fmt.Errorf("must provide node name and node IP")
+	}
+
+	etcdCert := getEtcdCertificateConfig(hostCertPath, hostCertPath, nodeName, nodeIP)
+	caCert, caKey, err := etcdCert.loadAuthorityCertificate(etcdCertPath, etcdCert.caConfig.certName)
+	if err != nil {
+		return err
+	}
+	// the apiserver-etcd-client cert is only used by masters.
+	var hostConfigs []CertificateConfig
+	for _, config := range etcdCert.commonConfig {
+		if config.certName != "apiserver-etcd-client" {
+			hostConfigs = append(hostConfigs, config)
+		}
+	}
+	etcdCert.commonConfig = hostConfigs
+	return etcdCert.generateCommonCertificate(caCert, caKey)
+}
+
 func getKubeCertificateConfig(certPath string, APIServerAltNames cert.AltNames, nodeName string, DNSDomain string) CertificateConfigFamily {
 	kubeCert := CertificateConfigFamily{
 		certPath: certPath,
@@ -376,7 +404,7 @@ func getEtcdCertificateConfig(etcdCertPath, certPath, nodeName string, nodeIP ne
 		},
 		IPs: map[string]net.IP{
 			net.IPv4(127, 0, 0, 1).String(): net.IPv4(127, 0, 0, 1),
-			nodeIP.To4().String():           nodeIP.To4(),
+			nodeIP.String():                 nodeIP,
 			net.IPv6loopback.String():       net.IPv6loopback,
 		},
 	}
@@ -15,8 +15,13 @@
 package clustercert
 
 import (
+	"io/ioutil"
 	"net"
+	"os"
+	"path/filepath"
 	"testing"
+
+	"github.com/sealerio/sealer/pkg/clustercert/cert"
 )
 
 func TestGenerateAll(t *testing.T) {
@@ -63,3 +68,30 @@ func TestUpdateAPIServerCert(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateEtcdHostCerts(t *testing.T) {
+	basePath, err := ioutil.TempDir("", "sealer-pki")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(basePath)
+	}()
+	etcdBasePath := filepath.Join(basePath, "etcd")
+	if err = GenerateAllKubernetesCerts(basePath, etcdBasePath, "master1", "10.64.0.0/10", "cluster.local", nil, net.ParseIP("172.27.139.11")); err != nil {
+		t.Fatalf("failed to generate cluster certs: %v", err)
+	}
+
+	hostPath := filepath.Join(basePath, "etcd-hosts", "172.27.139.21")
+	if err = GenerateEtcdHostCerts(etcdBasePath, hostPath, "etcd1", net.ParseIP("172.27.139.21")); err != nil {
+		t.Fatalf("GenerateEtcdHostCerts() error = %v", err)
+	}
+	for _, name := range []string{"server", "peer", "healthcheck-client"} {
+		if _, err = os.Stat(cert.PathForCert(hostPath, name)); err != nil {
+			t.Errorf("cert %s of etcd host is not generated: %v", name, err)
+		}
+	}
+	if _, err = os.Stat(cert.PathForCert(hostPath, "apiserver-etcd-client")); !os.IsNotExist(err) {
+		t.Errorf("cert apiserver-etcd-client should not be generated for etcd host")
+	}
+}
@@ -80,7 +80,7 @@ func mountNydusRootfs(ipList []net.IP, target string, cluster *v2.Cluster, initF
 	if err != nil {
 		return err
 	}
-	localIP, err := utilsnet.GetLocalIP(cluster.GetMaster0IP().String() + ":22")
+	localIP, err := utilsnet.GetLocalIP(net.JoinHostPort(cluster.GetMaster0IP().String(), "22"))
 	if err != nil {
 		return fmt.Errorf("failed to get local address: %v", err)
 	}
@@ -94,7 +94,7 @@ func mountNydusRootfs(ipList []net.IP, target string, cluster *v2.Cluster, initF
 		nydusdCleanCmd  = fmt.Sprintf(RemoteNydusdStop, filepath.Join(nydusdDir, "clean.sh"), nydusdDir)
 		cleanCmd        = fmt.Sprintf("echo '%s' >> "+common.DefaultClusterClearBashFile, nydusdCleanCmd, cluster.Name)
 		envProcessor    = env.NewEnvProcessor(cluster)
-		config          = registry.GetConfig(platform.DefaultMountClusterImageDir(cluster.Name), cluster.GetMaster0IP())
+		config          = registry.GetClusterConfig(platform.DefaultMountClusterImageDir(cluster.Name), cluster)
 		initCmd         = fmt.Sprintf(RemoteChmod, target, config.Domain, config.Port)
 	)
 	_, err = exec.RunSimpleCmd(nydusdfileCpCmd)
@@ -66,7 +66,7 @@ func mountRootfs(ipList []net.IP, target string, cluster *v2.Cluster, initFlag b
 		*sync.RWMutex
 		mountDirs map[string]bool
 	}{&sync.RWMutex{}, make(map[string]bool)}
-	config := registry.GetConfig(platform.DefaultMountClusterImageDir(cluster.Name), cluster.GetMaster0IP())
+	config := registry.GetClusterConfig(platform.DefaultMountClusterImageDir(cluster.Name), cluster)
 	eg, _ := errgroup.WithContext(context.Background())
 	for _, IP := range ipList {
 		ip := IP
@@ -98,11 +98,16 @@ func mountRootfs(ipList []net.IP, target string, cluster *v2.Cluster, initFlag b
 	if err = eg.Wait(); err != nil {
 		return err
 	}
-	// if config.ip is not in mountRootfs ipList, mean copy registry dir is not required, like scale up node
-	if utilsnet.NotInIPList(config.IP, ipList) {
-		return nil
+	// if registry nodes are not in mountRootfs ipList, mean copy registry dir is not required, like scale up node
+	for _, regIP := range config.GetRegistryNodes(cluster.GetMasterIPList()) {
+		if utilsnet.NotInIPList(regIP, ipList) {
+			continue
+		}
+		if err = copyRegistry(regIP, cluster, mountEntry.mountDirs, target); err != nil {
+			return err
+		}
 	}
-	return copyRegistry(config.IP, cluster, mountEntry.mountDirs, target)
+	return nil
 }
 
 func unmountRootfs(ipList []net.IP, cluster *v2.Cluster) error {
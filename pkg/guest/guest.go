@@ -24,6 +24,8 @@ import (
 
 	"github.com/sealerio/sealer/common"
 	"github.com/sealerio/sealer/pkg/image/store"
+	"github.com/sealerio/sealer/pkg/registry"
+	"github.com/sealerio/sealer/pkg/runtime"
 	v1 "github.com/sealerio/sealer/types/api/v1"
 	v2 "github.com/sealerio/sealer/types/api/v2"
 	"github.com/sealerio/sealer/utils/platform"
@@ -60,10 +62,21 @@ func (d *Default) Apply(cluster *v2.Cluster) error {
 	}
 	cmdArgs := d.getGuestCmdArg(cluster, image)
 	cmd := d.getGuestCmd(cluster, image)
-	sshClient, err := ssh.NewStdoutSSHClient(cluster.GetMaster0IP(), cluster)
+	master, err := runtime.GetOperationalMaster(cluster)
 	if err != nil {
 		return err
 	}
+	sshClient, err := ssh.NewStdoutSSHClient(master, cluster)
+	if err != nil {
+		return err
+	}
+
+	// the images of built-in registry are pushed into the external registry, so the manifests refer to it.
+	if rewrite := registry.GetClusterConfig(clusterRootfs, cluster).RewriteSeaHubCommand(clusterRootfs); rewrite != "" {
+		if err := sshClient.CmdAsync(master, rewrite); err != nil {
+			return fmt.Errorf("failed to rewrite images to external registry: %v", err)
+		}
+	}
 
 	for _, value := range cmd {
 		if value == "" {
@@ -74,7 +87,7 @@ func (d *Default) Apply(cluster *v2.Cluster) error {
 			return fmt.Errorf("failed to render build args: %v", err)
 		}
 
-		if err := sshClient.CmdAsync(cluster.GetMaster0IP(), fmt.Sprintf(common.CdAndExecCmd, clusterRootfs, cmdline)); err != nil {
+		if err := sshClient.CmdAsync(master, fmt.Sprintf(common.CdAndExecCmd, clusterRootfs, cmdline)); err != nil {
 			return err
 		}
 	}
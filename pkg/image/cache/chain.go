@@ -64,6 +64,7 @@ type ChainStore interface {
 type chainItem struct {
 	layer   v1.Layer
 	chainID ChainID
+	cacheID string
 }
 
 type chainStore struct {
@@ -74,6 +75,10 @@ type chainStore struct {
 }
 
 func NewImageStore(fs store.Backend, ls store.LayerStore) (ChainStore, error) {
+	return getChainStore(fs, ls), nil
+}
+
+func getChainStore(fs store.Backend, ls store.LayerStore) *chainStore {
 	once.Do(func() {
 		imageChain = &chainStore{
 			chains: make(map[ChainID]*chainItem),
@@ -83,7 +88,7 @@ func NewImageStore(fs store.Backend, ls store.LayerStore) (ChainStore, error) {
 
 		imageChain.restore()
 	})
-	return imageChain, nil
+	return imageChain
 }
 
 // restore reads all images saved in filesystem and calculate their chainID
@@ -94,40 +99,46 @@ func (cs *chainStore) restore() {
 	//read all image layers
 	images := cs.Images()
 	for _, image := range images {
-		layers := image.Spec.Layers
-		lastChainItem := &chainItem{}
-		for _, layer := range layers {
-			var (
-				chainID ChainID
-				err     error
-			)
-
-			cacheLayer, err := cs.newCacheLayer(layer)
-			if err != nil {
-				logrus.Warnf("failed to new a cache layer for %v, err: %s", layer, err)
-				continue
-			}
-
-			// first chainItem's parent chainID is empty
-			chainID, err = cacheLayer.ChainID(lastChainItem.chainID)
-			if err != nil {
-				logrus.Error(err)
-				break
-			}
-			logrus.Debugf("current layer %+v, restore chain id: %s", cacheLayer, chainID)
-
-			_, ok := cs.chains[chainID]
-			if !ok {
-				cItem := &chainItem{
-					layer:   layer,
-					chainID: chainID,
-				}
-				cs.chains[chainID] = cItem
-			}
-			lastChainItem = &chainItem{
-				layer:   layer,
-				chainID: chainID,
-			}
+		cs.addChains(cs.chainItems(image.Spec.Layers))
+	}
+}
+
+// chainItems calculates the chainID of each layer, the first layer's parent chainID is empty.
+func (cs *chainStore) chainItems(layers []v1.Layer) []*chainItem {
+	var (
+		items         []*chainItem
+		lastChainItem = &chainItem{}
+	)
+
+	for _, layer := range layers {
+		cacheLayer, err := cs.newCacheLayer(layer)
+		if err != nil {
+			logrus.Warnf("failed to new a cache layer for %v, err: %s", layer, err)
+			continue
+		}
+
+		chainID, err := cacheLayer.ChainID(lastChainItem.chainID)
+		if err != nil {
+			logrus.Error(err)
+			break
+		}
+		logrus.Debugf("current layer %+v, restore chain id: %s", cacheLayer, chainID)
+
+		lastChainItem = &chainItem{
+			layer:   layer,
+			chainID: chainID,
+			cacheID: cacheLayer.CacheID,
+		}
+		items = append(items, lastChainItem)
+	}
+	return items
+}
+
+// addChains adds chain items which are not in the store yet, the caller should hold the lock.
+func (cs *chainStore) addChains(items []*chainItem) {
+	for _, item := range items {
+		if _, ok := cs.chains[item.chainID]; !ok {
+			cs.chains[item.chainID] = item
 		}
 	}
 }
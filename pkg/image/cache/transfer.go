@@ -0,0 +1,253 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/sirupsen/logrus"
+
+	"github.com/sealerio/sealer/common"
+	"github.com/sealerio/sealer/pkg/image/store"
+	v1 "github.com/sealerio/sealer/types/api/v1"
+	"github.com/sealerio/sealer/utils/archive"
+	"github.com/sealerio/sealer/utils/os/fs"
+)
+
+const (
+	cacheIndexFile   = "index.json"
+	cacheLayersDir   = "layers"
+	cacheIndexFormat = "v1"
+)
+
+// ChainTransfer exports and imports cache chains, so that the build cache
+// could be shared across machines, like fresh CI runners.
+type ChainTransfer interface {
+	// ExportDir writes the cache chains of layers and the layer content into dir.
+	ExportDir(dir string, layers []v1.Layer) error
+	// ImportDir loads the cache chains and layers written by ExportDir.
+	ImportDir(dir string) error
+	// AnnotateImage records the cache id of COPY layers into image annotations,
+	// it is used when the cache is exported as a ClusterImage to registry.
+	AnnotateImage(image *v1.Image) error
+	// ImportImage adds the cache chains of a pulled image annotated by AnnotateImage.
+	ImportImage(image *v1.Image) error
+}
+
+// Index is the cache metadata file written by ExportDir.
+type Index struct {
+	Format string      `json:"format"`
+	Chains []IndexItem `json:"chains"`
+}
+
+type IndexItem struct {
+	ChainID ChainID  `json:"chain_id"`
+	CacheID string   `json:"cache_id,omitempty"`
+	Layer   v1.Layer `json:"layer"`
+}
+
+func (cs *chainStore) ExportDir(dir string, layers []v1.Layer) error {
+	layersDir := filepath.Join(dir, cacheLayersDir)
+	if err := os.MkdirAll(layersDir, common.FileMode0755); err != nil {
+		return fmt.Errorf("failed to create cache dir %s: %v", layersDir, err)
+	}
+
+	index := Index{Format: cacheIndexFormat}
+	for _, item := range cs.chainItems(layers) {
+		index.Chains = append(index.Chains, IndexItem{
+			ChainID: item.chainID,
+			CacheID: item.cacheID,
+			Layer:   item.layer,
+		})
+		if item.layer.ID == "" {
+			continue
+		}
+		if err := cs.exportLayer(layersDir, item.layer.ID); err != nil {
+			return err
+		}
+	}
+
+	indexBytes, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, cacheIndexFile), indexBytes, common.FileMode0644)
+}
+
+func (cs *chainStore) exportLayer(layersDir string, layerID digest.Digest) error {
+	target := filepath.Join(layersDir, layerID.Hex()+".tar")
+	if _, err := os.Stat(target); err == nil {
+		logrus.Debugf("cache layer %s already exported", layerID)
+		return nil
+	}
+
+	layer := cs.ls.Get(store.LayerID(layerID))
+	if layer == nil {
+		return fmt.Errorf("failed to find layer %s in local layer store", layerID)
+	}
+	tarStream, err := layer.TarStream()
+	if err != nil {
+		return err
+	}
+	defer tarStream.Close()
+
+	// write to a temp file first, so that an interrupted export won't leave a broken layer.
+	tmp := target + ".tmp"
+	// #nosec
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(f, tarStream); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to export layer %s: %v", layerID, err)
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, target)
+}
+
+func (cs *chainStore) ImportDir(dir string) error {
+	indexBytes, err := ioutil.ReadFile(filepath.Clean(filepath.Join(dir, cacheIndexFile)))
+	if err != nil {
+		return fmt.Errorf("failed to read cache index in %s: %v", dir, err)
+	}
+
+	index := Index{}
+	if err = json.Unmarshal(indexBytes, &index); err != nil {
+		return fmt.Errorf("failed to parse cache index in %s: %v", dir, err)
+	}
+	if index.Format != cacheIndexFormat {
+		return fmt.Errorf("unsupported cache index format %q", index.Format)
+	}
+
+	var items []*chainItem
+	for _, ci := range index.Chains {
+		if ci.Layer.ID != "" {
+			if err = cs.importLayer(filepath.Join(dir, cacheLayersDir), ci.Layer.ID); err != nil {
+				return err
+			}
+		}
+		if err = cs.setCacheID(ci.Layer, ci.CacheID); err != nil {
+			return err
+		}
+		items = append(items, &chainItem{
+			layer:   ci.Layer,
+			chainID: ci.ChainID,
+			cacheID: ci.CacheID,
+		})
+	}
+
+	cs.Lock()
+	defer cs.Unlock()
+	cs.addChains(items)
+	logrus.Infof("imported %d cache chains from %s", len(items), dir)
+	return nil
+}
+
+func (cs *chainStore) importLayer(layersDir string, layerID digest.Digest) error {
+	if cs.ls.Get(store.LayerID(layerID)) != nil {
+		return nil
+	}
+
+	src, err := os.Open(filepath.Clean(filepath.Join(layersDir, layerID.Hex()+".tar")))
+	if err != nil {
+		return fmt.Errorf("failed to open cache layer %s: %v", layerID, err)
+	}
+	defer src.Close()
+
+	tmp, err := fs.NewFilesystem().MkTmpdir()
+	if err != nil {
+		return fmt.Errorf("failed to create tmp dir for cache layer %s: %v", layerID, err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tmp); err != nil {
+			logrus.Warn(err)
+		}
+	}()
+
+	if _, err = archive.Untar(src, tmp); err != nil {
+		return fmt.Errorf("failed to untar cache layer %s: %v", layerID, err)
+	}
+
+	// the layer id is the digest of its content, so check it before using the layer as cache.
+	dgst, err := cs.ls.RegisterLayerForBuilder(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to register cache layer %s: %v", layerID, err)
+	}
+	if dgst != layerID {
+		return fmt.Errorf("cache layer %s is corrupted, got digest %s", layerID, dgst)
+	}
+	return nil
+}
+
+func (cs *chainStore) setCacheID(layer v1.Layer, cacheID string) error {
+	if layer.Type != common.COPYCOMMAND || cacheID == "" {
+		return nil
+	}
+	return cs.fs.SetMetadata(layer.ID, common.CacheID, []byte(cacheID))
+}
+
+func (cs *chainStore) AnnotateImage(image *v1.Image) error {
+	cacheIDs := map[digest.Digest]string{}
+	for _, item := range cs.chainItems(image.Spec.Layers) {
+		if item.cacheID != "" {
+			cacheIDs[item.layer.ID] = item.cacheID
+		}
+	}
+
+	cacheIDsBytes, err := json.Marshal(cacheIDs)
+	if err != nil {
+		return err
+	}
+	if image.Annotations == nil {
+		image.Annotations = map[string]string{}
+	}
+	image.Annotations[common.ImageAnnotationForCacheIDs] = string(cacheIDsBytes)
+	return nil
+}
+
+func (cs *chainStore) ImportImage(image *v1.Image) error {
+	cacheIDs := map[digest.Digest]string{}
+	if raw, ok := image.Annotations[common.ImageAnnotationForCacheIDs]; ok {
+		if err := json.Unmarshal([]byte(raw), &cacheIDs); err != nil {
+			return fmt.Errorf("failed to parse cache ids of image %s: %v", image.Name, err)
+		}
+	}
+
+	for _, layer := range image.Spec.Layers {
+		if err := cs.setCacheID(layer, cacheIDs[layer.ID]); err != nil {
+			return err
+		}
+	}
+
+	items := cs.chainItems(image.Spec.Layers)
+	cs.Lock()
+	defer cs.Unlock()
+	cs.addChains(items)
+	logrus.Infof("imported %d cache chains from image %s", len(items), image.Name)
+	return nil
+}
+
+func NewChainTransfer(fs store.Backend, ls store.LayerStore) (ChainTransfer, error) {
+	return getChainStore(fs, ls), nil
+}
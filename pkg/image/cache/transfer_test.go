@@ -0,0 +1,193 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/sealerio/sealer/common"
+	"github.com/sealerio/sealer/pkg/image/store"
+	v1 "github.com/sealerio/sealer/types/api/v1"
+)
+
+// layerIDFile holds the layer id in the content of fake layers, so the registered layer gets the same id.
+const layerIDFile = "id"
+
+type fakeBackend struct {
+	store.Backend
+	metadata map[digest.Digest]map[string][]byte
+}
+
+func (f *fakeBackend) ListImages() ([][]byte, error) {
+	return nil, nil
+}
+
+func (f *fakeBackend) SetMetadata(id digest.Digest, key string, data []byte) error {
+	if f.metadata[id] == nil {
+		f.metadata[id] = map[string][]byte{}
+	}
+	f.metadata[id][key] = data
+	return nil
+}
+
+func (f *fakeBackend) GetMetadata(id digest.Digest, key string) ([]byte, error) {
+	return f.metadata[id][key], nil
+}
+
+type fakeLayer struct {
+	store.Layer
+	id store.LayerID
+}
+
+func (f fakeLayer) TarStream() (io.ReadCloser, error) {
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	content := []byte(f.id.ToDigest().String())
+	if err := tw.WriteHeader(&tar.Header{Name: layerIDFile, Mode: 0644, Size: int64(len(content))}); err != nil {
+		return nil, err
+	}
+	if _, err := tw.Write(content); err != nil {
+		return nil, err
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(buf), nil
+}
+
+type fakeLayerStore struct {
+	store.LayerStore
+	layers map[store.LayerID]bool
+}
+
+func (f *fakeLayerStore) Get(id store.LayerID) store.Layer {
+	if !f.layers[id] {
+		return nil
+	}
+	return fakeLayer{id: id}
+}
+
+func (f *fakeLayerStore) RegisterLayerForBuilder(path string) (digest.Digest, error) {
+	content, err := ioutil.ReadFile(filepath.Clean(filepath.Join(path, layerIDFile)))
+	if err != nil {
+		return "", err
+	}
+	f.layers[store.LayerID(content)] = true
+	return digest.Digest(content), nil
+}
+
+func newFakeChainStore(layerIDs ...digest.Digest) *chainStore {
+	ls := &fakeLayerStore{layers: map[store.LayerID]bool{}}
+	for _, id := range layerIDs {
+		ls.layers[store.LayerID(id)] = true
+	}
+	return &chainStore{
+		chains: map[ChainID]*chainItem{},
+		fs:     &fakeBackend{metadata: map[digest.Digest]map[string][]byte{}},
+		ls:     ls,
+	}
+}
+
+func TestExportImportDir(t *testing.T) {
+	var (
+		rootfs  = digest.FromString("rootfs")
+		appYaml = digest.FromString("app yaml")
+		layers  = []v1.Layer{
+			{ID: rootfs, Type: common.COPYCOMMAND, Value: "rootfs ."},
+			{Type: common.CMDCOMMAND, Value: "kubectl apply -f etc/calico.yaml"},
+			{ID: appYaml, Type: common.COPYCOMMAND, Value: "app.yaml manifests"},
+			{Type: common.CMDCOMMAND, Value: "kubectl apply -f manifests/app.yaml"},
+		}
+	)
+	src := newFakeChainStore(rootfs, appYaml)
+	for id, cacheID := range map[digest.Digest]string{rootfs: "rootfs-cache", appYaml: "app-cache"} {
+		if err := src.fs.SetMetadata(id, common.CacheID, []byte(cacheID)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "build-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err = src.ExportDir(dir, layers); err != nil {
+		t.Fatalf("ExportDir() error: %v", err)
+	}
+
+	dst := newFakeChainStore()
+	if err = dst.ImportDir(dir); err != nil {
+		t.Fatalf("ImportDir() error: %v", err)
+	}
+
+	srcItems := src.chainItems(layers)
+	if len(srcItems) != len(layers) {
+		t.Fatalf("got %d chain items, want %d", len(srcItems), len(layers))
+	}
+	for _, item := range srcItems {
+		layer, err := dst.GetChainLayer(item.chainID)
+		if err != nil {
+			t.Errorf("chain of layer %v is not imported: %v", item.layer, err)
+			continue
+		}
+		if layer.ID != item.layer.ID || layer.Value != item.layer.Value {
+			t.Errorf("chain %s imported layer %v, want %v", item.chainID, layer, item.layer)
+		}
+		if item.layer.ID != "" && dst.ls.Get(store.LayerID(item.layer.ID)) == nil {
+			t.Errorf("layer %s is not imported", item.layer.ID)
+		}
+	}
+	// the chains imported are the same as the ones calculated by the importer, so the cache is hit.
+	for i, item := range dst.chainItems(layers) {
+		if item.chainID != srcItems[i].chainID {
+			t.Errorf("chain id of layer %d is %s after import, want %s", i, item.chainID, srcItems[i].chainID)
+		}
+	}
+}
+
+func TestImportImage(t *testing.T) {
+	appYaml := digest.FromString("app yaml")
+	layers := []v1.Layer{
+		{ID: appYaml, Type: common.COPYCOMMAND, Value: "app.yaml manifests"},
+		{Type: common.CMDCOMMAND, Value: "kubectl apply -f manifests/app.yaml"},
+	}
+	src := newFakeChainStore(appYaml)
+	if err := src.fs.SetMetadata(appYaml, common.CacheID, []byte("app-cache")); err != nil {
+		t.Fatal(err)
+	}
+	image := &v1.Image{}
+	image.Spec.Layers = layers
+	if err := src.AnnotateImage(image); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := newFakeChainStore(appYaml)
+	if err := dst.ImportImage(image); err != nil {
+		t.Fatalf("ImportImage() error: %v", err)
+	}
+	for _, item := range src.chainItems(layers) {
+		if _, err := dst.GetChainLayer(item.chainID); err != nil {
+			t.Errorf("chain of layer %v is not imported: %v", item.layer, err)
+		}
+	}
+}
@@ -22,7 +22,6 @@ import (
 	"path/filepath"
 
 	"github.com/distribution/distribution/v3"
-	"github.com/distribution/distribution/v3/manifest/schema2"
 	dockerstreams "github.com/docker/cli/cli/streams"
 	"github.com/docker/docker/api/types"
 	dockerioutils "github.com/docker/docker/pkg/ioutils"
@@ -102,7 +101,7 @@ func (d DefaultImageService) Pull(imageName string, platforms []*v1.Platform) er
 		return err
 	}
 
-	repo, err := distributionutil.NewV2Repository(named, "pull")
+	repo, err := distributionutil.NewV2PullRepository(named)
 	if err != nil {
 		return err
 	}
@@ -160,26 +159,21 @@ func (d DefaultImageService) Pull(imageName string, platforms []*v1.Platform) er
 	return nil
 }
 
-func (d DefaultImageService) handleManifest(ctx context.Context, manifest distribution.ManifestService, payload []byte, platform v1.Platform) (schema2.Manifest, error) {
+func (d DefaultImageService) handleManifest(ctx context.Context, manifest distribution.ManifestService, payload []byte, platform v1.Platform) (distribution.Manifest, error) {
 	dgest, err := distributionutil.GetImageManifestDigest(payload, platform)
 	if err != nil {
-		return schema2.Manifest{}, fmt.Errorf("failed to get digest from manifest list: %v", err)
+		return nil, fmt.Errorf("failed to get digest from manifest list: %v", err)
 	}
 
 	m, err := manifest.Get(ctx, dgest, make([]distribution.ManifestServiceOption, 0)...)
 	if err != nil {
-		return schema2.Manifest{}, fmt.Errorf("failed to get image manifest: %v", err)
+		return nil, fmt.Errorf("failed to get image manifest: %v", err)
 	}
-
-	_, ok := m.(*schema2.DeserializedManifest)
-	if !ok {
-		return schema2.Manifest{}, fmt.Errorf("failed to parse manifest to DeserializedManifest")
-	}
-	return m.(*schema2.DeserializedManifest).Manifest, nil
+	return m, nil
 }
 
 // Push local image to remote registry
-func (d DefaultImageService) Push(imageName string) error {
+func (d DefaultImageService) Push(imageName string, opts PushOptions) error {
 	named, err := reference.ParseToNamed(imageName)
 	if err != nil {
 		return err
@@ -205,6 +199,7 @@ func (d DefaultImageService) Push(imageName string) error {
 		distributionutil.Config{
 			LayerStore:     layerStore,
 			ProgressOutput: progressChanOut,
+			OCIArtifact:    opts.OCIArtifact,
 		})
 	if err != nil {
 		return err
@@ -228,7 +223,8 @@ func (d DefaultImageService) Push(imageName string) error {
 
 // Login into a registry, for saving auth info in ~/.docker/config.json
 func (d DefaultImageService) Login(RegistryURL, RegistryUsername, RegistryPasswd string) error {
-	err := distributionutil.Login(context.Background(), &types.AuthConfig{ServerAddress: RegistryURL, Username: RegistryUsername, Password: RegistryPasswd})
+	authConfig := types.AuthConfig{ServerAddress: RegistryURL, Username: RegistryUsername, Password: RegistryPasswd}
+	err := distributionutil.Login(context.Background(), &authConfig)
 	if err != nil {
 		return fmt.Errorf("failed to authenticate %s: %v", RegistryURL, err)
 	}
@@ -238,7 +234,7 @@ func (d DefaultImageService) Login(RegistryURL, RegistryUsername, RegistryPasswd
 		return fmt.Errorf("failed to read default auth file: %v", err)
 	}
 
-	if err := svc.SetAuthInfo(ConvertToHostname(RegistryURL), RegistryUsername, RegistryPasswd); err != nil {
+	if err := svc.SetAuth(ConvertToHostname(RegistryURL), authConfig); err != nil {
 		return err
 	}
 	logrus.Infof("%s login %s success", RegistryUsername, RegistryURL)
@@ -20,7 +20,6 @@ import (
 	"fmt"
 
 	"github.com/distribution/distribution/v3"
-	"github.com/distribution/distribution/v3/manifest/schema2"
 
 	"github.com/sealerio/sealer/pkg/image/distributionutil"
 	"github.com/sealerio/sealer/pkg/image/reference"
@@ -98,7 +97,7 @@ func (d DefaultImageMetadataService) GetRemoteImage(imageName string, platform *
 		return image, err
 	}
 
-	repo, err := distributionutil.NewV2Repository(named, "pull")
+	repo, err := distributionutil.NewV2PullRepository(named)
 	if err != nil {
 		return v1.Image{}, err
 	}
@@ -113,15 +112,14 @@ func (d DefaultImageMetadataService) GetRemoteImage(imageName string, platform *
 		return v1.Image{}, err
 	}
 
-	// just transform it to schema2.DeserializedManifest
-	// because we only upload this kind manifest.
-	scheme2Manifest, ok := manifest.(*schema2.DeserializedManifest)
-	if !ok {
-		return v1.Image{}, fmt.Errorf("failed to parse manifest %s to DeserializedManifest", named.RepoTag())
+	// the manifest is a docker v2 manifest, or an OCI image manifest of ClusterImage pushed as an OCI artifact.
+	config, _, err := distributionutil.ImageManifestContent(manifest)
+	if err != nil {
+		return v1.Image{}, fmt.Errorf("failed to parse manifest %s: %v", named.RepoTag(), err)
 	}
 
 	bs := repo.Blobs(ctx)
-	configJSONReader, err := bs.Open(ctx, scheme2Manifest.Config.Digest)
+	configJSONReader, err := bs.Open(ctx, config.Digest)
 	if err != nil {
 		return v1.Image{}, err
 	}
@@ -0,0 +1,86 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package distributionutil
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/distribution/distribution/v3"
+	"github.com/distribution/distribution/v3/manifest"
+	"github.com/distribution/distribution/v3/manifest/ocischema"
+	"github.com/distribution/distribution/v3/manifest/schema2"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	// ArtifactTypeClusterImage is the artifactType of ClusterImage pushed as an OCI artifact.
+	ArtifactTypeClusterImage = "application/vnd.sealer.clusterimage.v1"
+	// MediaTypeClusterImageConfig is the config media type of ClusterImage pushed as an OCI artifact,
+	// the config is the sealer image metadata.
+	MediaTypeClusterImageConfig = "application/vnd.sealer.clusterimage.config.v1+json"
+	// MediaTypeClusterImageLayer is the layer media type of ClusterImage pushed as an OCI artifact.
+	MediaTypeClusterImageLayer = "application/vnd.sealer.clusterimage.layer.v1.tar+gzip"
+)
+
+// artifactManifest is an OCI image manifest with artifactType, which is not known by ocischema yet.
+type artifactManifest struct {
+	ocischema.Manifest
+	ArtifactType string `json:"artifactType,omitempty"`
+}
+
+// deserializedArtifactManifest wraps artifactManifest with its JSON to be put to registry.
+type deserializedArtifactManifest struct {
+	artifactManifest
+	canonical []byte
+}
+
+func newArtifactManifest(config distribution.Descriptor, layers []distribution.Descriptor) (*deserializedArtifactManifest, error) {
+	m := artifactManifest{
+		Manifest: ocischema.Manifest{
+			Versioned: manifest.Versioned{
+				SchemaVersion: 2,
+				MediaType:     ocispec.MediaTypeImageManifest,
+			},
+			Config: config,
+			Layers: layers,
+		},
+		ArtifactType: ArtifactTypeClusterImage,
+	}
+	canonical, err := json.MarshalIndent(&m, "", "   ")
+	if err != nil {
+		return nil, err
+	}
+	return &deserializedArtifactManifest{artifactManifest: m, canonical: canonical}, nil
+}
+
+// Payload returns the media type and JSON of the manifest, it is an OCI image manifest for registries.
+func (m deserializedArtifactManifest) Payload() (string, []byte, error) {
+	return ocispec.MediaTypeImageManifest, m.canonical, nil
+}
+
+// ImageManifestContent returns the config and layers of a ClusterImage manifest, which is either a docker v2
+// manifest, or an OCI image manifest pushed as an OCI artifact.
+func ImageManifestContent(m distribution.Manifest) (distribution.Descriptor, []distribution.Descriptor, error) {
+	switch im := m.(type) {
+	case *schema2.DeserializedManifest:
+		return im.Config, im.Layers, nil
+	case *ocischema.DeserializedManifest:
+		return im.Config, im.Layers, nil
+	default:
+		mediaType, _, _ := m.Payload()
+		return distribution.Descriptor{}, nil, fmt.Errorf("unsupported manifest type %s", mediaType)
+	}
+}
@@ -27,6 +27,8 @@ type Config struct {
 	LayerStore     store.LayerStore
 	ProgressOutput progress.Output
 	Named          reference.Named
+	// OCIArtifact pushes the image as an OCI artifact with sealer media types instead of a docker image.
+	OCIArtifact bool
 }
 
 type registryConfig struct {
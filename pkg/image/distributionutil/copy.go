@@ -0,0 +1,182 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package distributionutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/distribution/distribution/v3"
+	"github.com/distribution/distribution/v3/manifest/manifestlist"
+	"github.com/distribution/distribution/v3/registry/api/errcode"
+	v2 "github.com/distribution/distribution/v3/registry/api/v2"
+	"github.com/sirupsen/logrus"
+)
+
+// CopyOptions are the options of copying images between registries.
+type CopyOptions struct {
+	// SkipMissingPlatforms drops the platforms missing in src from the manifest list instead of failing the copy,
+	// the manifest list put into dst has another digest then. The registries fed by ClusterImages need it, as
+	// sealer only caches the platforms needed by ClusterImage.
+	SkipMissingPlatforms bool
+}
+
+// CopyImage copies the manifest of tag and the blobs it references from src to dst.
+// all the platform manifests of manifest list are copied, and the blobs existing in dst are skipped.
+func CopyImage(ctx context.Context, src, dst distribution.Repository, tag string, opts CopyOptions) error {
+	return CopyImageTo(ctx, src, dst, tag, tag, opts)
+}
+
+// CopyImageTo is like CopyImage, but the manifest is tagged as dstTag in dst.
+func CopyImageTo(ctx context.Context, src, dst distribution.Repository, tag, dstTag string, opts CopyOptions) error {
+	desc, err := src.Tags(ctx).Get(ctx, tag)
+	if err != nil {
+		return fmt.Errorf("failed to get tag %s: %v", tag, err)
+	}
+
+	srcMs, err := src.Manifests(ctx)
+	if err != nil {
+		return err
+	}
+	m, err := srcMs.Get(ctx, desc.Digest)
+	if err != nil {
+		return fmt.Errorf("failed to get manifest of tag %s: %v", tag, err)
+	}
+
+	m, skipped, err := copyManifest(ctx, src, dst, m, opts)
+	if err != nil {
+		return fmt.Errorf("failed to copy tag %s: %v", tag, err)
+	}
+	if len(skipped) > 0 {
+		logrus.Warnf("platforms %s of tag %s are not found, skip them", strings.Join(skipped, ", "), tag)
+	}
+
+	dstMs, err := dst.Manifests(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err = dstMs.Put(ctx, m, distribution.WithTag(dstTag)); err != nil {
+		return fmt.Errorf("failed to put manifest of tag %s: %v", dstTag, err)
+	}
+	return nil
+}
+
+// copyManifest copies the content referenced by manifest m, and returns the manifest to put into dst
+// and the platforms skipped. the platform manifests missing in src fail the copy, or are dropped from
+// manifest list if opts.SkipMissingPlatforms.
+func copyManifest(ctx context.Context, src, dst distribution.Repository, m distribution.Manifest, opts CopyOptions) (distribution.Manifest, []string, error) {
+	list, ok := m.(*manifestlist.DeserializedManifestList)
+	if !ok {
+		for _, ref := range m.References() {
+			if err := copyBlob(ctx, src, dst, ref); err != nil {
+				return nil, nil, err
+			}
+		}
+		return m, nil, nil
+	}
+
+	srcMs, err := src.Manifests(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	dstMs, err := dst.Manifests(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		available []manifestlist.ManifestDescriptor
+		skipped   []string
+	)
+	for _, desc := range list.Manifests {
+		platform := desc.Platform.OS + "/" + desc.Platform.Architecture
+		child, err := srcMs.Get(ctx, desc.Digest)
+		if err != nil {
+			if !opts.SkipMissingPlatforms || !isManifestUnknown(err) {
+				return nil, nil, fmt.Errorf("failed to get manifest of platform %s: %v", platform, err)
+			}
+			skipped = append(skipped, platform)
+			continue
+		}
+		if child, _, err = copyManifest(ctx, src, dst, child, opts); err != nil {
+			return nil, nil, err
+		}
+		if _, err = dstMs.Put(ctx, child); err != nil {
+			return nil, nil, fmt.Errorf("failed to put manifest %s: %v", desc.Digest, err)
+		}
+		available = append(available, desc)
+	}
+
+	switch {
+	case len(available) == 0:
+		return nil, nil, fmt.Errorf("no platform manifest of manifest list found, missing platforms: %s", strings.Join(skipped, ", "))
+	case len(available) == len(list.Manifests):
+		return m, nil, nil
+	default:
+		m, err = manifestlist.FromDescriptorsWithMediaType(available, list.MediaType)
+		return m, skipped, err
+	}
+}
+
+// isManifestUnknown reports whether err means the manifest is not found, the storage of local registry returns
+// the distribution errors, and the registry client returns the error codes of registry API.
+func isManifestUnknown(err error) bool {
+	if errors.As(err, &distribution.ErrManifestUnknownRevision{}) || errors.As(err, &distribution.ErrManifestUnknown{}) {
+		return true
+	}
+	var errs errcode.Errors
+	if errors.As(err, &errs) {
+		for _, e := range errs {
+			if isManifestUnknown(e) {
+				return true
+			}
+		}
+		return false
+	}
+	var coder errcode.ErrorCoder
+	return errors.As(err, &coder) && coder.ErrorCode() == v2.ErrorCodeManifestUnknown
+}
+
+func copyBlob(ctx context.Context, src, dst distribution.Repository, desc distribution.Descriptor) error {
+	if _, err := dst.Blobs(ctx).Stat(ctx, desc.Digest); err == nil {
+		return nil
+	}
+
+	reader, err := src.Blobs(ctx).Open(ctx, desc.Digest)
+	if err != nil {
+		return fmt.Errorf("failed to open blob %s: %v", desc.Digest, err)
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+
+	writer, err := dst.Blobs(ctx).Create(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create blob %s: %v", desc.Digest, err)
+	}
+	defer func() {
+		_ = writer.Close()
+	}()
+
+	if _, err = writer.ReadFrom(reader); err != nil {
+		return fmt.Errorf("failed to upload blob %s: %v", desc.Digest, err)
+	}
+	if _, err = writer.Commit(ctx, desc); err != nil {
+		return fmt.Errorf("failed to commit blob %s: %v", desc.Digest, err)
+	}
+	return nil
+}
@@ -0,0 +1,305 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// the local registry is created by package save, which imports distributionutil,
+// so the tests are in the external test package.
+package distributionutil_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/distribution/distribution/v3"
+	"github.com/distribution/distribution/v3/manifest/manifestlist"
+	"github.com/distribution/distribution/v3/manifest/schema2"
+	distreference "github.com/distribution/distribution/v3/reference"
+	"github.com/distribution/distribution/v3/registry/api/errcode"
+	v2 "github.com/distribution/distribution/v3/registry/api/v2"
+	"github.com/opencontainers/go-digest"
+
+	"github.com/sealerio/sealer/pkg/image/distributionutil"
+	"github.com/sealerio/sealer/pkg/image/save"
+)
+
+func newLocalRepository(ctx context.Context, t *testing.T, name string) distribution.Repository {
+	dir, err := ioutil.TempDir("", "sealer-registry")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = os.RemoveAll(dir)
+	})
+	reg, err := save.NewLocalRegistry(ctx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	named, err := distreference.WithName(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := reg.Repository(ctx, named)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repo
+}
+
+// putImageManifest puts an image with a single layer into repo, and returns the descriptor of its manifest.
+func putImageManifest(ctx context.Context, t *testing.T, repo distribution.Repository, layer string) distribution.Descriptor {
+	config, err := repo.Blobs(ctx).Put(ctx, schema2.MediaTypeImageConfig, []byte(`{"architecture":"amd64","os":"linux"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.MediaType = schema2.MediaTypeImageConfig
+	layerDesc, err := repo.Blobs(ctx).Put(ctx, schema2.MediaTypeLayer, []byte(layer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	layerDesc.MediaType = schema2.MediaTypeLayer
+	m, err := schema2.FromStruct(schema2.Manifest{
+		Versioned: schema2.SchemaVersion,
+		Config:    config,
+		Layers:    []distribution.Descriptor{layerDesc},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ms, err := repo.Manifests(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dgst, err := ms.Put(ctx, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mediaType, payload, _ := m.Payload()
+	return distribution.Descriptor{MediaType: mediaType, Digest: dgst, Size: int64(len(payload))}
+}
+
+// putManifestList puts a manifest list with an image per architecture into repo, and returns the
+// manifest digests of the architectures.
+func putManifestList(ctx context.Context, t *testing.T, repo distribution.Repository, tag string, layers map[string]string) map[string]digest.Digest {
+	var descs []manifestlist.ManifestDescriptor
+	digests := map[string]digest.Digest{}
+	for arch, layer := range layers {
+		desc := putImageManifest(ctx, t, repo, layer)
+		descs = append(descs, manifestlist.ManifestDescriptor{
+			Descriptor: desc,
+			Platform:   manifestlist.PlatformSpec{OS: "linux", Architecture: arch},
+		})
+		digests[arch] = desc.Digest
+	}
+	list, err := manifestlist.FromDescriptors(descs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ms, err := repo.Manifests(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dgst, err := ms.Put(ctx, list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = repo.Tags(ctx).Tag(ctx, tag, distribution.Descriptor{MediaType: manifestlist.MediaTypeManifestList, Digest: dgst}); err != nil {
+		t.Fatal(err)
+	}
+	return digests
+}
+
+// brokenRepository fails to get the manifests in errs with the mapped error.
+type brokenRepository struct {
+	distribution.Repository
+	errs map[digest.Digest]error
+}
+
+func (r *brokenRepository) Manifests(ctx context.Context, options ...distribution.ManifestServiceOption) (distribution.ManifestService, error) {
+	ms, err := r.Repository.Manifests(ctx, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &brokenManifestService{ManifestService: ms, errs: r.errs}, nil
+}
+
+type brokenManifestService struct {
+	distribution.ManifestService
+	errs map[digest.Digest]error
+}
+
+func (s *brokenManifestService) Get(ctx context.Context, dgst digest.Digest, options ...distribution.ManifestServiceOption) (distribution.Manifest, error) {
+	if err, ok := s.errs[dgst]; ok {
+		return nil, err
+	}
+	return s.ManifestService.Get(ctx, dgst, options...)
+}
+
+// getCopiedManifest gets the manifest of tag in src from dst, the local registry does not tag the manifest
+// put with distribution.WithTag, which is only handled by the registry client, so the digest is looked up in src.
+func getCopiedManifest(ctx context.Context, t *testing.T, src, dst distribution.Repository, tag string) distribution.Manifest {
+	desc, err := src.Tags(ctx).Get(ctx, tag)
+	if err != nil {
+		t.Fatalf("failed to get tag %s: %v", tag, err)
+	}
+	ms, err := dst.Manifests(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := ms.Get(ctx, desc.Digest)
+	if err != nil {
+		t.Fatalf("manifest of tag %s is not copied: %v", tag, err)
+	}
+	return m
+}
+
+func TestCopyImage(t *testing.T) {
+	ctx := context.Background()
+	src := newLocalRepository(ctx, t, "library/nginx")
+	desc := putImageManifest(ctx, t, src, "nginx layer")
+	if err := src.Tags(ctx).Tag(ctx, "latest", desc); err != nil {
+		t.Fatal(err)
+	}
+	putManifestList(ctx, t, src, "multi-arch", map[string]string{"amd64": "amd64 layer", "arm64": "arm64 layer"})
+
+	dst := newLocalRepository(ctx, t, "project/library/nginx")
+	for _, tag := range []string{"latest", "multi-arch"} {
+		if err := distributionutil.CopyImage(ctx, src, dst, tag, distributionutil.CopyOptions{}); err != nil {
+			t.Fatalf("CopyImage(%s) error = %v", tag, err)
+		}
+	}
+
+	m := getCopiedManifest(ctx, t, src, dst, "latest")
+	for _, ref := range m.References() {
+		if _, err := dst.Blobs(ctx).Stat(ctx, ref.Digest); err != nil {
+			t.Errorf("blob %s of latest is not copied: %v", ref.Digest, err)
+		}
+	}
+
+	list, ok := getCopiedManifest(ctx, t, src, dst, "multi-arch").(*manifestlist.DeserializedManifestList)
+	if !ok {
+		t.Fatalf("manifest of multi-arch is not a manifest list")
+	}
+	if len(list.Manifests) != 2 {
+		t.Errorf("got %d platforms of multi-arch, want 2", len(list.Manifests))
+	}
+	ms, err := dst.Manifests(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, desc := range list.Manifests {
+		if exist, err := ms.Exists(ctx, desc.Digest); err != nil || !exist {
+			t.Errorf("manifest of platform %s is not copied: %v", desc.Platform.Architecture, err)
+		}
+	}
+}
+
+func TestCopyImageMissingTag(t *testing.T) {
+	ctx := context.Background()
+	src := newLocalRepository(ctx, t, "library/nginx")
+	dst := newLocalRepository(ctx, t, "library/nginx")
+	if err := distributionutil.CopyImage(ctx, src, dst, "latest", distributionutil.CopyOptions{}); err == nil {
+		t.Errorf("CopyImage() of missing tag succeeded, want error")
+	}
+}
+
+func TestCopyImageMissingPlatform(t *testing.T) {
+	ctx := context.Background()
+	repo := newLocalRepository(ctx, t, "library/nginx")
+	digests := putManifestList(ctx, t, repo, "multi-arch", map[string]string{"amd64": "amd64 layer", "arm64": "arm64 layer"})
+	unknown := func(arch string) error {
+		return distribution.ErrManifestUnknownRevision{Name: "library/nginx", Revision: digests[arch]}
+	}
+	// the registry client returns the error codes of registry API.
+	remoteUnknown := errcode.Errors{v2.ErrorCodeManifestUnknown.WithDetail(digests["arm64"])}
+
+	tests := []struct {
+		name          string
+		errs          map[digest.Digest]error
+		skip          bool
+		wantErr       bool
+		wantPlatforms []string
+	}{
+		{
+			name:    "missing platform fails",
+			errs:    map[digest.Digest]error{digests["arm64"]: unknown("arm64")},
+			wantErr: true,
+		},
+		{
+			name:          "missing platform is dropped",
+			errs:          map[digest.Digest]error{digests["arm64"]: unknown("arm64")},
+			skip:          true,
+			wantPlatforms: []string{"amd64"},
+		},
+		{
+			name:          "platform missing in remote registry is dropped",
+			errs:          map[digest.Digest]error{digests["arm64"]: remoteUnknown},
+			skip:          true,
+			wantPlatforms: []string{"amd64"},
+		},
+		{
+			name:    "platform missing in remote registry fails",
+			errs:    map[digest.Digest]error{digests["arm64"]: remoteUnknown},
+			wantErr: true,
+		},
+		{
+			name:    "all platforms missing",
+			errs:    map[digest.Digest]error{digests["amd64"]: unknown("amd64"), digests["arm64"]: unknown("arm64")},
+			skip:    true,
+			wantErr: true,
+		},
+		{
+			name:    "failed to get platform",
+			errs:    map[digest.Digest]error{digests["arm64"]: fmt.Errorf("connection reset")},
+			skip:    true,
+			wantErr: true,
+		},
+		{
+			name:    "unauthorized to get platform",
+			errs:    map[digest.Digest]error{digests["arm64"]: errcode.Errors{errcode.ErrorCodeUnauthorized}},
+			skip:    true,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := &brokenRepository{Repository: repo, errs: tt.errs}
+			dst := newLocalRepository(ctx, t, "library/nginx")
+			err := distributionutil.CopyImageTo(ctx, src, dst, "multi-arch", "multi-arch", distributionutil.CopyOptions{SkipMissingPlatforms: tt.skip})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CopyImageTo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			// the tag is set by the registry client only, so the platform manifests are checked by digest.
+			ms, err := dst.Manifests(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var platforms []string
+			for _, arch := range []string{"amd64", "arm64"} {
+				if exist, _ := ms.Exists(ctx, digests[arch]); exist {
+					platforms = append(platforms, arch)
+				}
+			}
+			if !reflect.DeepEqual(platforms, tt.wantPlatforms) {
+				t.Errorf("copied platforms = %v, want %v", platforms, tt.wantPlatforms)
+			}
+		})
+	}
+}
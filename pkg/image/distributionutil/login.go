@@ -75,6 +75,8 @@ func Login(ctx context.Context, authConfig *types.AuthConfig) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusOK {
+		// the token auth server may return a refresh token, which is saved instead of password.
+		authConfig.IdentityToken = credentialAuthConfig.IdentityToken
 		return nil
 	}
 
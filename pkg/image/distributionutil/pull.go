@@ -22,7 +22,6 @@ import (
 	"io/ioutil"
 
 	"github.com/distribution/distribution/v3"
-	"github.com/distribution/distribution/v3/manifest/schema2"
 	"github.com/docker/docker/pkg/progress"
 	"github.com/opencontainers/go-digest"
 	"golang.org/x/sync/errgroup"
@@ -34,7 +33,7 @@ import (
 )
 
 type Puller interface {
-	Pull(ctx context.Context, named reference.Named, manifest schema2.Manifest) (*v1.Image, error)
+	Pull(ctx context.Context, named reference.Named, manifest distribution.Manifest) (*v1.Image, error)
 }
 
 type ImagePuller struct {
@@ -42,14 +41,19 @@ type ImagePuller struct {
 	repository distribution.Repository
 }
 
-func (puller *ImagePuller) Pull(ctx context.Context, named reference.Named, manifest schema2.Manifest) (*v1.Image, error) {
+func (puller *ImagePuller) Pull(ctx context.Context, named reference.Named, manifest distribution.Manifest) (*v1.Image, error) {
 	var (
 		layerStore = puller.config.LayerStore
 		layers     = []v1.Layer{}
 		eg         *errgroup.Group
 	)
 
-	v1Image, err := puller.getRemoteImageMetadata(ctx, manifest.Config.Digest)
+	config, manifestLayers, err := ImageManifestContent(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	v1Image, err := puller.getRemoteImageMetadata(ctx, config.Digest)
 	if err != nil {
 		return nil, err
 	}
@@ -62,11 +66,11 @@ func (puller *ImagePuller) Pull(ctx context.Context, named reference.Named, mani
 		layers = append(layers, l)
 	}
 	// number of non-empty layer and layer in distribution should be equal
-	if len(layers) != len(manifest.Layers) {
-		return nil, fmt.Errorf("the number layerIDs %d and LayerDescriptor %d are mismatch", len(layers), len(manifest.Layers))
+	if len(layers) != len(manifestLayers) {
+		return nil, fmt.Errorf("the number layerIDs %d and LayerDescriptor %d are mismatch", len(layers), len(manifestLayers))
 	}
 
-	for i, l := range manifest.Layers {
+	for i, l := range manifestLayers {
 		// local value to current scope, safe to pass into goroutine
 		var (
 			descriptor = l
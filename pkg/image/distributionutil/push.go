@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"io"
 	"sync"
+	"time"
 
 	"github.com/distribution/distribution/v3"
 	"github.com/distribution/distribution/v3/manifest/manifestlist"
@@ -68,13 +69,22 @@ func (pusher *ImagePusher) Push(ctx context.Context, named reference.Named) erro
 			return err
 		}
 
-		// push sealer image metadata to registry
-		configJSON, err := pusher.putManifestConfig(ctx, *image)
+		created, err := configCreated(m.CREATED)
 		if err != nil {
 			return err
 		}
-
-		manifestDescriptor, err := pusher.putManifest(ctx, configJSON, named, layerDescriptors)
+		var manifestDescriptor distribution.Descriptor
+		if pusher.config.OCIArtifact {
+			manifestDescriptor, err = pusher.putArtifactManifest(ctx, *image, created, named, layerDescriptors)
+		} else {
+			// push sealer image metadata to registry
+			var configJSON []byte
+			configJSON, err = pusher.putManifestConfig(ctx, *image, created)
+			if err != nil {
+				return err
+			}
+			manifestDescriptor, err = pusher.putManifest(ctx, configJSON, named, layerDescriptors)
+		}
 		if err != nil {
 			return err
 		}
@@ -275,6 +285,54 @@ func (pusher *ImagePusher) putManifest(ctx context.Context, configJSON []byte, n
 	}, nil
 }
 
+// putArtifactManifest pushes the image as an OCI artifact, the config is the sealer image metadata
+// with a sealer media type, so that registries and scanners won't take it as a container image.
+func (pusher *ImagePusher) putArtifactManifest(ctx context.Context, image v1.Image, created time.Time, named reference.Named, layerDescriptors []distribution.Descriptor) (distribution.Descriptor, error) {
+	repo := pusher.repository
+	imageConfig, err := addDockerManifestConfig(image, created)
+	if err != nil {
+		return distribution.Descriptor{}, fmt.Errorf("failed to add image config: %s", err)
+	}
+	configJSON, err := json.Marshal(imageConfig)
+	if err != nil {
+		return distribution.Descriptor{}, err
+	}
+	config, err := repo.Blobs(ctx).Put(ctx, MediaTypeClusterImageConfig, configJSON)
+	if err != nil {
+		return distribution.Descriptor{}, fmt.Errorf("failed to put image config: %v", err)
+	}
+	// blob service replaces the media type with application/octet-stream
+	config.MediaType = MediaTypeClusterImageConfig
+
+	var layers []distribution.Descriptor
+	for _, d := range layerDescriptors {
+		d.MediaType = MediaTypeClusterImageLayer
+		layers = append(layers, d)
+	}
+	manifest, err := newArtifactManifest(config, layers)
+	if err != nil {
+		return distribution.Descriptor{}, err
+	}
+
+	ms, err := repo.Manifests(ctx)
+	if err != nil {
+		return distribution.Descriptor{}, err
+	}
+	dgst, err := ms.Put(ctx, manifest, distribution.WithTag(named.Tag()))
+	if err != nil {
+		return distribution.Descriptor{}, err
+	}
+	mediaType, p, err := manifest.Payload()
+	if err != nil {
+		return distribution.Descriptor{}, err
+	}
+	return distribution.Descriptor{
+		Digest:    dgst,
+		Size:      int64(len(p)),
+		MediaType: mediaType,
+	}, nil
+}
+
 func (pusher *ImagePusher) putManifestList(ctx context.Context, named reference.Named, manifest distribution.Manifest) (digest.Digest, error) {
 	repo := pusher.repository
 	manifestService, err := repo.Manifests(ctx)
@@ -291,10 +349,10 @@ func (pusher *ImagePusher) putManifestList(ctx context.Context, named reference.
 	return dgst, nil
 }
 
-func (pusher *ImagePusher) putManifestConfig(ctx context.Context, image v1.Image) ([]byte, error) {
+func (pusher *ImagePusher) putManifestConfig(ctx context.Context, image v1.Image, created time.Time) ([]byte, error) {
 	repo := pusher.repository
 
-	dockerImageConfig, err := addDockerManifestConfig(image)
+	dockerImageConfig, err := addDockerManifestConfig(image, created)
 	if err != nil {
 		return nil, fmt.Errorf("failed to add docker manifest config: %s", err)
 	}
@@ -322,14 +380,28 @@ type dockerImageLayerInfo struct {
 //wrap v1.Image with docker image config fields
 type dockerManifestConfig struct {
 	v1.Image
+	Created      *time.Time             `json:"created,omitempty"`
 	Architecture string                 `json:"architecture,omitempty"`
 	OS           string                 `json:"os,omitempty"`
 	History      []dockerImageLayerInfo `json:"history,omitempty"`
 }
 
+// configCreated returns the created time of image config, SOURCE_DATE_EPOCH overwrites the one recorded at save,
+// so the digests of pushed manifests are reproducible.
+func configCreated(created time.Time) (time.Time, error) {
+	epoch, err := archive.SourceDateEpochFromEnv()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if epoch != nil {
+		return *epoch, nil
+	}
+	return created, nil
+}
+
 // add docker image config fields to display some metadata on docker hub
-// os, architecture and each layer command
-func addDockerManifestConfig(image v1.Image) (*dockerManifestConfig, error) {
+// created time, os, architecture and each layer command
+func addDockerManifestConfig(image v1.Image, created time.Time) (*dockerManifestConfig, error) {
 	var dockerImage = &dockerManifestConfig{}
 	config, err := json.Marshal(image)
 	if err != nil {
@@ -340,6 +412,9 @@ func addDockerManifestConfig(image v1.Image) (*dockerManifestConfig, error) {
 		return nil, err
 	}
 
+	if !created.IsZero() {
+		dockerImage.Created = &created
+	}
 	dockerImage.OS = image.Spec.Platform.OS
 	dockerImage.Architecture = image.Spec.Platform.Architecture
 
@@ -0,0 +1,56 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package distributionutil
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sealerio/sealer/utils/archive"
+)
+
+func TestConfigCreated(t *testing.T) {
+	saved := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		epoch   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"created at save", "", saved, false},
+		{"source date epoch", "1640995200", time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), false},
+		{"invalid source date epoch", "yesterday", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.epoch != "" {
+				if err := os.Setenv(archive.SourceDateEpochEnv, tt.epoch); err != nil {
+					t.Fatal(err)
+				}
+				defer func() {
+					_ = os.Unsetenv(archive.SourceDateEpochEnv)
+				}()
+			}
+			got, err := configCreated(saved)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("configCreated() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("configCreated() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -21,8 +21,11 @@ import (
 	"github.com/sealerio/sealer/pkg/client/docker/auth"
 
 	"github.com/distribution/distribution/v3"
+	dockerRegistryClient "github.com/distribution/distribution/v3/registry/client"
 	"github.com/docker/docker/api/types"
+	"github.com/sirupsen/logrus"
 
+	"github.com/sealerio/sealer/pkg/image/mirror"
 	"github.com/sealerio/sealer/pkg/image/reference"
 )
 
@@ -45,6 +48,49 @@ func NewV2Repository(named reference.Named, actions ...string) (distribution.Rep
 	return getV2Repository(authConfig, named, actions...)
 }
 
+// NewV2PullRepository returns the repository to pull named from, the mirrors of named domain configured in sealer
+// config file are tried in order before the registry of named, and the first one having the tag of named is returned.
+func NewV2PullRepository(named reference.Named) (distribution.Repository, error) {
+	var lastErr error
+	for _, endpoint := range mirror.GetEndpoints(named.Domain()) {
+		endpointNamed := named
+		if endpoint.IsMirror() {
+			var err error
+			endpointNamed, err = reference.ParseToNamed(fmt.Sprintf("%s/%s:%s", endpoint.Host, endpoint.Repo(named.Repo()), named.Tag()))
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		repo, err := NewV2Repository(endpointNamed, "pull")
+		if err == nil {
+			_, err = repo.Tags(context.Background()).Get(context.Background(), named.Tag())
+		}
+		if err == nil {
+			return repo, nil
+		}
+		if endpoint.IsMirror() {
+			logrus.Debugf("failed to get %s from mirror %s, try the next one: %v", named.Raw(), endpoint.Host, err)
+		}
+		lastErr = err
+	}
+	return nil, fmt.Errorf("failed to get %s: %v", named.Raw(), lastErr)
+}
+
+// NewV2RepositoryWithAuth is like NewV2Repository, but uses the given auth instead of the default auth file.
+func NewV2RepositoryWithAuth(authConfig types.AuthConfig, named reference.Named, actions ...string) (distribution.Repository, error) {
+	return getV2Repository(authConfig, named, actions...)
+}
+
+// NewV2RegistryWithAuth returns the client of registry at domain, which lists the repositories of registry.
+func NewV2RegistryWithAuth(authConfig types.AuthConfig, domain string) (dockerRegistryClient.Registry, error) {
+	reg, err := NewRegistry(context.Background(), authConfig, registryConfig{Insecure: true, Domain: domain})
+	if err == nil {
+		return reg, nil
+	}
+	return NewRegistry(context.Background(), authConfig, registryConfig{Insecure: true, NonSSL: true, Domain: domain})
+}
+
 func getV2Repository(authConfig types.AuthConfig, named reference.Named, actions ...string) (distribution.Repository, error) {
 	repo, err := NewRepository(context.Background(), authConfig, named.Repo(), registryConfig{Insecure: true, Domain: named.Domain()}, actions...)
 	if err == nil {
@@ -36,6 +36,39 @@ import (
 )
 
 func NewRepository(ctx context.Context, authConfig types.AuthConfig, repoName string, config registryConfig, actions ...string) (distribution.Repository, error) {
+	scope := dockerAuth.RepositoryScope{
+		Repository: repoName,
+		Actions:    actions,
+		Class:      "image",
+	}
+	rurl, tr, err := newTransport(ctx, authConfig, config, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	repoNameRef, err := reference.WithName(repoName)
+	if err != nil {
+		return nil, err
+	}
+
+	return dockerRegistryClient.NewRepository(repoNameRef, rurl.String(), tr)
+}
+
+// NewRegistry returns the client to list the repositories of registry, which requires the catalog scope.
+func NewRegistry(ctx context.Context, authConfig types.AuthConfig, config registryConfig) (dockerRegistryClient.Registry, error) {
+	scope := dockerAuth.RegistryScope{
+		Name:    "catalog",
+		Actions: []string{"*"},
+	}
+	rurl, tr, err := newTransport(ctx, authConfig, config, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	return dockerRegistryClient.NewRegistry(rurl.String(), tr)
+}
+
+func newTransport(ctx context.Context, authConfig types.AuthConfig, config registryConfig, scope dockerAuth.Scope) (*url.URL, http.RoundTripper, error) {
 	tlsConfig := tlsconfig.ServerDefault()
 	tlsConfig.InsecureSkipVerify = config.Insecure
 
@@ -50,7 +83,7 @@ func NewRepository(ctx context.Context, authConfig types.AuthConfig, repoName st
 
 	rurl, err := url.Parse(rurlStr)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	direct := &net.Dialer{
@@ -69,26 +102,20 @@ func NewRepository(ctx context.Context, authConfig types.AuthConfig, repoName st
 		DisableKeepAlives: true,
 	}
 	if err := dockerRegistry.ReadCertsDirectory(base.TLSClientConfig, filepath.Join(dockerRegistry.CertsDir(), rurl.Host)); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	modifiers := dockerRegistry.Headers(dockerversion.DockerUserAgent(ctx), nil)
 	authTransport := dockerTransport.NewTransport(base, modifiers...)
 
 	challengeManager, err := dockerRegistry.PingV2Registry(rurl, authTransport)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	// typically, this filed would be empty
 	if authConfig.RegistryToken != "" {
 		passThruTokenHandler := &existingTokenHandler{token: authConfig.RegistryToken}
 		modifiers = append(modifiers, dockerAuth.NewAuthorizer(challengeManager, passThruTokenHandler))
 	} else {
-		scope := dockerAuth.RepositoryScope{
-			Repository: repoName,
-			Actions:    actions,
-			Class:      "image",
-		}
-
 		creds := dockerRegistry.NewStaticCredentialStore(&authConfig)
 		tokenHandlerOptions := dockerAuth.TokenHandlerOptions{
 			Transport:   authTransport,
@@ -101,13 +128,7 @@ func NewRepository(ctx context.Context, authConfig types.AuthConfig, repoName st
 		modifiers = append(modifiers, dockerAuth.NewAuthorizer(challengeManager, tokenHandler, basicHandler))
 	}
 
-	tr := dockerTransport.NewTransport(base, modifiers...)
-	repoNameRef, err := reference.WithName(repoName)
-	if err != nil {
-		return nil, err
-	}
-
-	return dockerRegistryClient.NewRepository(repoNameRef, rurl.String(), tr)
+	return rurl, dockerTransport.NewTransport(base, modifiers...), nil
 }
 
 type existingTokenHandler struct {
@@ -35,11 +35,18 @@ type FileService interface {
 	Merge(image *v1.Image) error
 }
 
+// PushOptions are the options of pushing an image
+type PushOptions struct {
+	// OCIArtifact pushes the image as an OCI artifact with sealer media types, so registries
+	// like Harbor list it as a ClusterImage rather than a container image.
+	OCIArtifact bool
+}
+
 // Service is image service
 type Service interface {
 	Pull(imageName string, platform []*v1.Platform) error
 	PullIfNotExist(imageName string, platform []*v1.Platform) error
-	Push(imageName string) error
+	Push(imageName string, opts PushOptions) error
 	Delete(imageName string, platforms []*v1.Platform) error
 	Login(RegistryURL, RegistryUsername, RegistryPasswd string) error
 	CacheBuilder
@@ -0,0 +1,152 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mirror
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/sealerio/sealer/common"
+	osi "github.com/sealerio/sealer/utils/os"
+)
+
+// AnyDomain configures the mirrors of all registries without their own mirrors.
+const AnyDomain = "*"
+
+var (
+	configFile = filepath.Join(common.GetHomeDir(), ".sealer.json")
+	loadOnce   sync.Once
+	config     Config
+)
+
+// sealerConfig is the sealer config file, only the registry section is read here.
+type sealerConfig struct {
+	Registry Config `json:"registry,omitempty"`
+}
+
+// Config is the mirror configuration of registries, it is the "registry" section of sealer config file.
+type Config struct {
+	// Mirrors is keyed by registry domain, like docker.io, or "*" for all registries.
+	Mirrors map[string]Mirror `json:"mirrors,omitempty"`
+}
+
+// Mirror configures the endpoints to pull images of a registry from.
+type Mirror struct {
+	// Endpoints are tried in order, and the registry itself is tried at last.
+	Endpoints []string `json:"endpoints,omitempty"`
+	// Rewrite rewrites the repository name before pulling from the endpoints, the first matched rule is applied.
+	Rewrite []RewriteRule `json:"rewrite,omitempty"`
+}
+
+// RewriteRule replaces the repository name matching Pattern with Replacement, which may refer to the
+// capture groups of Pattern like $1.
+type RewriteRule struct {
+	Pattern     string `json:"pattern"`
+	Replacement string `json:"replacement"`
+}
+
+// Endpoint is a registry to pull images of a domain from.
+type Endpoint struct {
+	// Host is the registry host, like mirror.example.com:5000, which is used to look up auth.
+	Host string
+	// URL is the url of mirror, it is empty for the registry of domain itself.
+	URL   string
+	rules []RewriteRule
+}
+
+// IsMirror returns whether the endpoint is a mirror rather than the registry of domain itself.
+func (e Endpoint) IsMirror() bool {
+	return e.URL != ""
+}
+
+// Repo returns the repository name to pull from the endpoint.
+func (e Endpoint) Repo(repo string) string {
+	for _, rule := range e.rules {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			logrus.Warnf("invalid rewrite pattern %s of mirror %s: %v", rule.Pattern, e.Host, err)
+			continue
+		}
+		if re.MatchString(repo) {
+			return re.ReplaceAllString(repo, rule.Replacement)
+		}
+	}
+	return repo
+}
+
+// SetConfigFile sets the sealer config file to read mirrors from.
+func SetConfigFile(path string) {
+	if path != "" {
+		configFile = path
+	}
+}
+
+// GetEndpoints returns the endpoints to pull images of domain from, the mirrors go first in order,
+// and the registry of domain is the last one.
+func GetEndpoints(domain string) []Endpoint {
+	loadOnce.Do(func() {
+		c, err := loadConfig(configFile)
+		if err != nil {
+			logrus.Warnf("failed to load registry mirrors, images are pulled from their registries: %v", err)
+			return
+		}
+		config = c
+	})
+	return config.Endpoints(domain)
+}
+
+// Endpoints returns the endpoints of domain by the config.
+func (c Config) Endpoints(domain string) []Endpoint {
+	m, ok := c.Mirrors[domain]
+	if !ok {
+		m = c.Mirrors[AnyDomain]
+	}
+
+	var endpoints []Endpoint
+	for _, e := range m.Endpoints {
+		u := strings.TrimSuffix(e, "/")
+		if !strings.HasPrefix(u, "https://") && !strings.HasPrefix(u, "http://") {
+			u = "https://" + u
+		}
+		endpoints = append(endpoints, Endpoint{
+			Host:  strings.TrimPrefix(strings.TrimPrefix(u, "https://"), "http://"),
+			URL:   u,
+			rules: m.Rewrite,
+		})
+	}
+	return append(endpoints, Endpoint{Host: domain})
+}
+
+func loadConfig(path string) (Config, error) {
+	if !osi.IsFileExist(path) {
+		return Config{}, nil
+	}
+	data, err := ioutil.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return Config{}, err
+	}
+	var c sealerConfig
+	if err = json.Unmarshal(data, &c); err != nil {
+		return Config{}, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	return c.Registry, nil
+}
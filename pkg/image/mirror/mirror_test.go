@@ -0,0 +1,81 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mirror
+
+import (
+	"testing"
+)
+
+func TestConfigEndpoints(t *testing.T) {
+	c := Config{
+		Mirrors: map[string]Mirror{
+			"docker.io": {
+				Endpoints: []string{"mirror.example.com", "http://10.0.0.2:5000/"},
+				Rewrite: []RewriteRule{
+					{Pattern: "^library/(.*)$", Replacement: "dockerhub/$1"},
+					{Pattern: "^(.*)$", Replacement: "others/$1"},
+				},
+			},
+			AnyDomain: {
+				Endpoints: []string{"https://all.example.com"},
+			},
+		},
+	}
+
+	tests := []struct {
+		domain    string
+		repo      string
+		wantHosts []string
+		wantURLs  []string
+		wantRepos []string
+	}{
+		{
+			domain:    "docker.io",
+			repo:      "library/nginx",
+			wantHosts: []string{"mirror.example.com", "10.0.0.2:5000", "docker.io"},
+			wantURLs:  []string{"https://mirror.example.com", "http://10.0.0.2:5000", ""},
+			wantRepos: []string{"dockerhub/nginx", "dockerhub/nginx", "library/nginx"},
+		},
+		{
+			domain:    "docker.io",
+			repo:      "sealerio/lvscare",
+			wantHosts: []string{"mirror.example.com", "10.0.0.2:5000", "docker.io"},
+			wantURLs:  []string{"https://mirror.example.com", "http://10.0.0.2:5000", ""},
+			wantRepos: []string{"others/sealerio/lvscare", "others/sealerio/lvscare", "sealerio/lvscare"},
+		},
+		{
+			domain:    "quay.io",
+			repo:      "coreos/etcd",
+			wantHosts: []string{"all.example.com", "quay.io"},
+			wantURLs:  []string{"https://all.example.com", ""},
+			wantRepos: []string{"coreos/etcd", "coreos/etcd"},
+		},
+	}
+	for _, tt := range tests {
+		endpoints := c.Endpoints(tt.domain)
+		if len(endpoints) != len(tt.wantHosts) {
+			t.Fatalf("Endpoints(%s) got %d endpoints, want %d", tt.domain, len(endpoints), len(tt.wantHosts))
+		}
+		for i, e := range endpoints {
+			if e.Host != tt.wantHosts[i] || e.URL != tt.wantURLs[i] || e.Repo(tt.repo) != tt.wantRepos[i] {
+				t.Errorf("Endpoints(%s)[%d] = {%s %s %s}, want {%s %s %s}", tt.domain, i,
+					e.Host, e.URL, e.Repo(tt.repo), tt.wantHosts[i], tt.wantURLs[i], tt.wantRepos[i])
+			}
+		}
+		if endpoints[len(endpoints)-1].IsMirror() {
+			t.Errorf("the last endpoint of %s should be the registry itself", tt.domain)
+		}
+	}
+}
@@ -46,10 +46,19 @@ type proxyingRegistry struct {
 	scheduler      *scheduler.TTLExpirationScheduler
 	remoteURL      url.URL
 	authChallenger authChallenger
+	// rewrite maps the repository name to the one of remote registry, like a mirror storing
+	// images under another path, the content is still cached with the original name.
+	rewrite func(name string) string
 }
 
 // NewRegistryPullThroughCache creates a registry acting as a pull through cache
 func NewRegistryPullThroughCache(ctx context.Context, registry distribution.Namespace, driver driver.StorageDriver, config configuration.Proxy) (distribution.Namespace, error) {
+	return NewRegistryPullThroughCacheWithRewrite(ctx, registry, driver, config, nil)
+}
+
+// NewRegistryPullThroughCacheWithRewrite is like NewRegistryPullThroughCache, but the repository names are
+// rewritten by rewrite before fetching from the remote registry.
+func NewRegistryPullThroughCacheWithRewrite(ctx context.Context, registry distribution.Namespace, driver driver.StorageDriver, config configuration.Proxy, rewrite func(string) string) (distribution.Namespace, error) {
 	remoteURL, err := url.Parse(config.RemoteURL)
 	if err != nil {
 		return nil, err
@@ -69,6 +78,7 @@ func NewRegistryPullThroughCache(ctx context.Context, registry distribution.Name
 			cm:        challenge.NewSimpleManager(),
 			cs:        cs,
 		},
+		rewrite: rewrite,
 	}, nil
 }
 
@@ -83,6 +93,14 @@ func (pr *proxyingRegistry) Repositories(ctx context.Context, repos []string, la
 // #nosec
 func (pr *proxyingRegistry) Repository(ctx context.Context, name reference.Named) (distribution.Repository, error) {
 	c := pr.authChallenger
+	remoteName := name
+	if pr.rewrite != nil {
+		rewritten, err := reference.WithName(pr.rewrite(name.Name()))
+		if err != nil {
+			return nil, err
+		}
+		remoteName = rewritten
+	}
 	tlsConfig := tlsconfig.ServerDefault()
 	if err := registry.ReadCertsDirectory(tlsConfig, filepath.Join(registry.CertsDir(), pr.remoteURL.Host)); err != nil {
 		return nil, err
@@ -105,7 +123,7 @@ func (pr *proxyingRegistry) Repository(ctx context.Context, name reference.Named
 		Credentials: c.credentialStore(),
 		Scopes: []auth.Scope{
 			auth.RepositoryScope{
-				Repository: name.Name(),
+				Repository: remoteName.Name(),
 				Actions:    []string{"pull"},
 			},
 		},
@@ -133,7 +151,7 @@ func (pr *proxyingRegistry) Repository(ctx context.Context, name reference.Named
 		return nil, err
 	}
 
-	remoteRepo, err := client.NewRepository(name, pr.remoteURL.String(), tr)
+	remoteRepo, err := client.NewRepository(remoteName, pr.remoteURL.String(), tr)
 	if err != nil {
 		return nil, err
 	}
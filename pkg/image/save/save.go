@@ -38,6 +38,7 @@ import (
 	"github.com/sealerio/sealer/common"
 	"github.com/sealerio/sealer/pkg/client/docker/auth"
 	"github.com/sealerio/sealer/pkg/image/distributionutil"
+	"github.com/sealerio/sealer/pkg/image/mirror"
 	"github.com/sealerio/sealer/pkg/image/save/distributionpkg/proxy"
 	v1 "github.com/sealerio/sealer/types/api/v1"
 )
@@ -91,11 +92,11 @@ func (is *DefaultImageSaver) SaveImages(images []string, dir string, platform v1
 			defer func() {
 				<-numCh
 			}()
-			registry, err := NewProxyRegistry(is.ctx, dir, tmpnameds[0].domain)
+			registries, err := NewProxyRegistries(is.ctx, dir, tmpnameds[0].domain)
 			if err != nil {
 				return fmt.Errorf("failed to init registry: %v", err)
 			}
-			err = is.save(tmpnameds, platform, registry)
+			err = is.saveFromRegistries(tmpnameds, platform, registries)
 			if err != nil {
 				return fmt.Errorf("failed to save domain %s image: %v", tmpnameds[0].domain, err)
 			}
@@ -142,11 +143,11 @@ func (is *DefaultImageSaver) SaveImagesWithAuth(imageList ImageListWithAuth, dir
 					<-numCh
 				}()
 
-				registry, err := NewProxyRegistryWithAuth(is.ctx, section.Username, section.Password, dir, tmpnameds[0].domain)
+				registries, err := NewProxyRegistriesWithAuth(is.ctx, section.Username, section.Password, dir, tmpnameds[0].domain)
 				if err != nil {
 					return fmt.Errorf("failed to init registry: %v", err)
 				}
-				err = is.save(tmpnameds, platform, registry)
+				err = is.saveFromRegistries(tmpnameds, platform, registries)
 				if err != nil {
 					return fmt.Errorf("failed to save domain %s image: %v", tmpnameds[0], err)
 				}
@@ -164,6 +165,19 @@ func (is *DefaultImageSaver) SaveImagesWithAuth(imageList ImageListWithAuth, dir
 	return nil
 }
 
+// saveFromRegistries saves images from the first registry which has them, the registries are the mirrors
+// of image domain and the registry of domain.
+func (is *DefaultImageSaver) saveFromRegistries(nameds []Named, platform v1.Platform, registries []distribution.Namespace) error {
+	var err error
+	for _, registry := range registries {
+		if err = is.save(nameds, platform, registry); err == nil {
+			return nil
+		}
+		logrus.Debugf("failed to save images of %s, try the next registry: %v", nameds[0].FullName(), err)
+	}
+	return err
+}
+
 func (is *DefaultImageSaver) save(nameds []Named, platform v1.Platform, registry distribution.Namespace) error {
 	repo, err := is.getRepository(nameds[0], registry)
 	if err != nil {
@@ -369,35 +383,74 @@ func (is *DefaultImageSaver) saveBlobs(imageDigests []digest.Digest, repo distri
 }
 
 func NewProxyRegistryWithAuth(ctx context.Context, username, password, rootdir, domain string) (distribution.Namespace, error) {
+	return newEndpointProxyRegistry(ctx, rootdir, domain, mirror.Endpoint{Host: domain}, &types.AuthConfig{Username: username, Password: password})
+}
+
+func NewProxyRegistry(ctx context.Context, rootdir, domain string) (distribution.Namespace, error) {
+	return newEndpointProxyRegistry(ctx, rootdir, domain, mirror.Endpoint{Host: domain}, nil)
+}
+
+// NewProxyRegistriesWithAuth is like NewProxyRegistries, but the registry of domain uses the given auth.
+func NewProxyRegistriesWithAuth(ctx context.Context, username, password, rootdir, domain string) ([]distribution.Namespace, error) {
+	return newProxyRegistries(ctx, rootdir, domain, &types.AuthConfig{Username: username, Password: password})
+}
+
+// NewProxyRegistries returns the proxy registries to pull images of domain from, the mirrors of domain
+// configured in sealer config file go first in order, and the registry of domain is the last one.
+func NewProxyRegistries(ctx context.Context, rootdir, domain string) ([]distribution.Namespace, error) {
+	return newProxyRegistries(ctx, rootdir, domain, nil)
+}
+
+func newProxyRegistries(ctx context.Context, rootdir, domain string, domainAuth *types.AuthConfig) ([]distribution.Namespace, error) {
+	var registries []distribution.Namespace
+	for _, endpoint := range mirror.GetEndpoints(domain) {
+		registry, err := newEndpointProxyRegistry(ctx, rootdir, domain, endpoint, domainAuth)
+		if err != nil {
+			return nil, err
+		}
+		registries = append(registries, registry)
+	}
+	return registries, nil
+}
+
+// newEndpointProxyRegistry returns the proxy registry pulling images of domain from endpoint, domainAuth is used
+// for the registry of domain, and the auth of mirror is read from the default auth file.
+func newEndpointProxyRegistry(ctx context.Context, rootdir, domain string, endpoint mirror.Endpoint, domainAuth *types.AuthConfig) (distribution.Namespace, error) {
 	// set the URL of registry
-	proxyURL := HTTPS + domain
-	if domain == defaultDomain {
-		proxyURL = defaultProxyURL
+	proxyURL := endpoint.URL
+	if !endpoint.IsMirror() {
+		proxyURL = HTTPS + domain
+		if domain == defaultDomain {
+			proxyURL = defaultProxyURL
+		}
+	}
+
+	authConfig := domainAuth
+	if authConfig == nil || endpoint.IsMirror() {
+		defaultAuth, err := getDefaultAuth(endpoint.Host)
+		if err != nil {
+			return nil, err
+		}
+		authConfig = &defaultAuth
 	}
 
 	config := configuration.Configuration{
 		Proxy: configuration.Proxy{
 			RemoteURL: proxyURL,
-			Username:  username,
-			Password:  password,
+			Username:  authConfig.Username,
+			Password:  authConfig.Password,
 		},
 		Storage: configuration.Storage{
 			driverName: configuration.Parameters{configRootDir: rootdir},
 		},
 	}
-	return newProxyRegistry(ctx, config)
+	return newProxyRegistry(ctx, config, endpoint.Repo)
 }
 
-func NewProxyRegistry(ctx context.Context, rootdir, domain string) (distribution.Namespace, error) {
-	// set the URL of registry
-	proxyURL := HTTPS + domain
-	if domain == defaultDomain {
-		proxyURL = defaultProxyURL
-	}
-
+func getDefaultAuth(domain string) (types.AuthConfig, error) {
 	svc, err := auth.NewDockerAuthService()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read default auth file: %v", err)
+		return types.AuthConfig{}, fmt.Errorf("failed to read default auth file: %v", err)
 	}
 	defaultAuth := types.AuthConfig{ServerAddress: domain}
 	authConfig, err := svc.GetAuthByDomain(domain)
@@ -405,24 +458,29 @@ func NewProxyRegistry(ctx context.Context, rootdir, domain string) (distribution
 	//regard it as a public registry
 	//only report parse error
 	if err != nil && authConfig != defaultAuth {
-		return nil, fmt.Errorf("failed to get authentication info: %v", err)
+		return types.AuthConfig{}, fmt.Errorf("failed to get authentication info: %v", err)
 	}
+	return authConfig, nil
+}
 
-	config := configuration.Configuration{
-		Proxy: configuration.Proxy{
-			RemoteURL: proxyURL,
-			Username:  authConfig.Username,
-			Password:  authConfig.Password,
-		},
-		Storage: configuration.Storage{
-			driverName: configuration.Parameters{configRootDir: rootdir},
-		},
+// NewLocalRegistry opens the registry storage in rootdir, like the registry dir of ClusterImage.
+func NewLocalRegistry(ctx context.Context, rootdir string) (distribution.Namespace, error) {
+	config := configuration.Storage{
+		driverName: configuration.Parameters{configRootDir: rootdir},
+	}
+	driver, err := factory.Create(config.Type(), config.Parameters())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storage driver: %v", err)
 	}
 
-	return newProxyRegistry(ctx, config)
+	registry, err := storage.NewRegistry(ctx, driver, make([]storage.RegistryOption, 0)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create local registry: %v", err)
+	}
+	return registry, nil
 }
 
-func newProxyRegistry(ctx context.Context, config configuration.Configuration) (distribution.Namespace, error) {
+func newProxyRegistry(ctx context.Context, config configuration.Configuration, rewrite func(string) string) (distribution.Namespace, error) {
 	driver, err := factory.Create(config.Storage.Type(), config.Storage.Parameters())
 	if err != nil {
 		return nil, fmt.Errorf("failed to create storage driver: %v", err)
@@ -434,11 +492,11 @@ func newProxyRegistry(ctx context.Context, config configuration.Configuration) (
 		return nil, fmt.Errorf("failed to create local registry: %v", err)
 	}
 
-	proxyRegistry, err := proxy.NewRegistryPullThroughCache(ctx, registry, driver, config.Proxy)
+	proxyRegistry, err := proxy.NewRegistryPullThroughCacheWithRewrite(ctx, registry, driver, config.Proxy, rewrite)
 	if err != nil { // try http
 		logrus.Warnf("https error: %v, sealer try to use http", err)
 		config.Proxy.RemoteURL = strings.Replace(config.Proxy.RemoteURL, HTTPS, HTTP, 1)
-		proxyRegistry, err = proxy.NewRegistryPullThroughCache(ctx, registry, driver, config.Proxy)
+		proxyRegistry, err = proxy.NewRegistryPullThroughCacheWithRewrite(ctx, registry, driver, config.Proxy, rewrite)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create proxy registry: %v", err)
 		}
@@ -0,0 +1,309 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/distribution/distribution/v3"
+	"github.com/distribution/distribution/v3/manifest/manifestlist"
+	distreference "github.com/distribution/distribution/v3/reference"
+	"github.com/opencontainers/go-digest"
+	"github.com/sirupsen/logrus"
+
+	"github.com/sealerio/sealer/common"
+	"github.com/sealerio/sealer/pkg/image/distributionutil"
+	"github.com/sealerio/sealer/pkg/image/reference"
+	save2 "github.com/sealerio/sealer/pkg/image/save"
+	v1 "github.com/sealerio/sealer/types/api/v1"
+	platUtil "github.com/sealerio/sealer/utils/platform"
+)
+
+const (
+	// UnknownPlatform is the platform of tags whose metadata can not be read.
+	UnknownPlatform = "unknown"
+	// maxSearchGoroutineNum bounds the tags whose metadata is read concurrently.
+	maxSearchGoroutineNum = 8
+)
+
+// SearchOptions filters the tags of ClusterImage in remote registry.
+type SearchOptions struct {
+	// Semver is a semver constraint of tags, like ">= 1.20, < 1.23", the tags not in semver are skipped.
+	Semver string
+	// Regex is the regular expression tags match.
+	Regex string
+	// Platform keeps the tags having the image of platform only, like linux/amd64.
+	Platform string
+}
+
+// TagInfo is the metadata of a ClusterImage tag in remote registry.
+type TagInfo struct {
+	Name      string         `json:"name"`
+	Tag       string         `json:"tag"`
+	Platforms []PlatformInfo `json:"platforms"`
+}
+
+// PlatformInfo is the metadata of the image of a platform in the manifest list of tag.
+type PlatformInfo struct {
+	Platform    string     `json:"platform"`
+	Created     *time.Time `json:"created,omitempty"`
+	Size        int64      `json:"size"`
+	KubeVersion string     `json:"kubeVersion,omitempty"`
+}
+
+// remoteImageConfig is the image config pushed by sealer, the sealer image metadata with docker config fields.
+type remoteImageConfig struct {
+	v1.Image
+	Created      *time.Time `json:"created,omitempty"`
+	Architecture string     `json:"architecture,omitempty"`
+	OS           string     `json:"os,omitempty"`
+}
+
+// Search lists the tags of ClusterImage imageName matching opts, with the platforms, created time, size and
+// kubernetes version of each tag read from the manifest list and image configs, nothing is pulled into local.
+func Search(imageName string, opts SearchOptions) ([]TagInfo, error) {
+	named, err := reference.ParseToNamed(imageName)
+	if err != nil {
+		return nil, err
+	}
+	registries, err := save2.NewProxyRegistries(context.Background(), "", named.Domain())
+	if err != nil {
+		return nil, err
+	}
+	tags, err := searchTags(registries, named.Repo())
+	if err != nil {
+		return nil, err
+	}
+	tags, err = filterTags(tags, opts)
+	if err != nil || len(tags) == 0 {
+		return nil, err
+	}
+
+	var platform *v1.Platform
+	if opts.Platform != "" {
+		p, err := platUtil.Parse(opts.Platform)
+		if err != nil {
+			return nil, err
+		}
+		platform = &p
+	}
+
+	// the registry to read manifests from is chosen by the first tag, and the mirrors are tried first.
+	firstNamed, err := reference.ParseToNamed(fmt.Sprintf("%s:%s", named.Name(), tags[0]))
+	if err != nil {
+		return nil, err
+	}
+	repo, err := distributionutil.NewV2PullRepository(firstNamed)
+	if err != nil {
+		return nil, err
+	}
+
+	var infos []TagInfo
+	for _, info := range getTagInfos(context.Background(), repo, named.String(), tags) {
+		if platform != nil {
+			info.Platforms = filterPlatforms(info.Platforms, *platform)
+			if len(info.Platforms) == 0 {
+				continue
+			}
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// getTagInfos reads the platforms of tags concurrently in the order of tags, the platform of a tag whose
+// metadata can not be read is UnknownPlatform, so it does not fail the others.
+func getTagInfos(ctx context.Context, repo distribution.Repository, name string, tags []string) []TagInfo {
+	infos := make([]TagInfo, len(tags))
+	var wg sync.WaitGroup
+	numCh := make(chan struct{}, maxSearchGoroutineNum)
+	for i, tag := range tags {
+		i, tag := i, tag
+		numCh <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-numCh
+				wg.Done()
+			}()
+			platforms, err := getTagPlatforms(ctx, repo, tag)
+			if err != nil {
+				logrus.Warnf("failed to get metadata of %s:%s: %v", name, tag, err)
+				platforms = []PlatformInfo{{Platform: UnknownPlatform}}
+			}
+			infos[i] = TagInfo{Name: name, Tag: tag, Platforms: platforms}
+		}()
+	}
+	wg.Wait()
+	return infos
+}
+
+// searchTags lists the tags of repo from the first registry which has it, the registries are the mirrors
+// of the repo domain and the registry of domain.
+func searchTags(registries []distribution.Namespace, repo string) ([]string, error) {
+	rNamed, err := distreference.WithName(repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get repository name: %v", err)
+	}
+
+	for i, ns := range registries {
+		var r distribution.Repository
+		r, err = ns.Repository(context.Background(), rNamed)
+		if err != nil {
+			continue
+		}
+		var tags []string
+		tags, err = r.Tags(context.Background()).All(context.Background())
+		if err == nil || i == len(registries)-1 {
+			return tags, err
+		}
+	}
+	return nil, err
+}
+
+func filterTags(tags []string, opts SearchOptions) ([]string, error) {
+	var (
+		constraint *semver.Constraints
+		re         *regexp.Regexp
+		err        error
+	)
+	if opts.Semver != "" {
+		if constraint, err = semver.NewConstraint(opts.Semver); err != nil {
+			return nil, fmt.Errorf("invalid semver constraint %s: %v", opts.Semver, err)
+		}
+	}
+	if opts.Regex != "" {
+		if re, err = regexp.Compile(opts.Regex); err != nil {
+			return nil, fmt.Errorf("invalid regex %s: %v", opts.Regex, err)
+		}
+	}
+
+	var res []string
+	for _, tag := range tags {
+		if constraint != nil {
+			v, err := semver.NewVersion(tag)
+			if err != nil || !constraint.Check(v) {
+				continue
+			}
+		}
+		if re != nil && !re.MatchString(tag) {
+			continue
+		}
+		res = append(res, tag)
+	}
+	return res, nil
+}
+
+func filterPlatforms(platforms []PlatformInfo, platform v1.Platform) []PlatformInfo {
+	var res []PlatformInfo
+	for _, p := range platforms {
+		parsed, err := platUtil.Parse(p.Platform)
+		if err == nil && platUtil.Matched(parsed, platform) {
+			res = append(res, p)
+		}
+	}
+	return res
+}
+
+// getTagPlatforms reads the images of all platforms of tag, the tag is a manifest list usually,
+// and it is a single image manifest for the images not pushed by sealer.
+func getTagPlatforms(ctx context.Context, repo distribution.Repository, tag string) ([]PlatformInfo, error) {
+	desc, err := repo.Tags(ctx).Get(ctx, tag)
+	if err != nil {
+		return nil, err
+	}
+	ms, err := repo.Manifests(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m, err := ms.Get(ctx, desc.Digest)
+	if err != nil {
+		return nil, err
+	}
+
+	list, ok := m.(*manifestlist.DeserializedManifestList)
+	if !ok {
+		info, err := getPlatformInfo(ctx, repo, m, nil)
+		if err != nil {
+			return nil, err
+		}
+		return []PlatformInfo{info}, nil
+	}
+
+	var platforms []PlatformInfo
+	for _, d := range list.Manifests {
+		platform := &v1.Platform{
+			Architecture: d.Platform.Architecture,
+			OS:           d.Platform.OS,
+			Variant:      d.Platform.Variant,
+		}
+		info, err := getChildPlatformInfo(ctx, repo, ms, d.Digest, platform)
+		if err != nil {
+			// the platform is known from the manifest list, only its metadata is missing.
+			logrus.Warnf("failed to get metadata of %s of tag %s: %v", platUtil.Format(*platform), tag, err)
+			info = PlatformInfo{Platform: platUtil.Format(*platform)}
+		}
+		platforms = append(platforms, info)
+	}
+	return platforms, nil
+}
+
+func getChildPlatformInfo(ctx context.Context, repo distribution.Repository, ms distribution.ManifestService, dgst digest.Digest, platform *v1.Platform) (PlatformInfo, error) {
+	child, err := ms.Get(ctx, dgst)
+	if err != nil {
+		return PlatformInfo{}, err
+	}
+	return getPlatformInfo(ctx, repo, child, platform)
+}
+
+// getPlatformInfo reads the metadata of image manifest m, the platform is read from image config if it is
+// not given by manifest list.
+func getPlatformInfo(ctx context.Context, repo distribution.Repository, m distribution.Manifest, platform *v1.Platform) (PlatformInfo, error) {
+	config, layers, err := distributionutil.ImageManifestContent(m)
+	if err != nil {
+		return PlatformInfo{}, err
+	}
+	configJSON, err := repo.Blobs(ctx).Get(ctx, config.Digest)
+	if err != nil {
+		return PlatformInfo{}, fmt.Errorf("failed to get image config: %v", err)
+	}
+	var imageConfig remoteImageConfig
+	if err = json.Unmarshal(configJSON, &imageConfig); err != nil {
+		return PlatformInfo{}, fmt.Errorf("failed to parse image config: %v", err)
+	}
+
+	if platform == nil {
+		platform = &imageConfig.Spec.Platform
+		if platform.OS == "" {
+			platform = &v1.Platform{OS: imageConfig.OS, Architecture: imageConfig.Architecture}
+		}
+	}
+	size := config.Size
+	for _, l := range layers {
+		size += l.Size
+	}
+	return PlatformInfo{
+		Platform:    platUtil.Format(*platform),
+		Created:     imageConfig.Created,
+		Size:        size,
+		KubeVersion: imageConfig.Annotations[common.ImageAnnotationForKubeVersion],
+	}, nil
+}
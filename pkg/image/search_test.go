@@ -0,0 +1,145 @@
+// Copyright © 2021 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/distribution/distribution/v3"
+	"github.com/distribution/distribution/v3/manifest/manifestlist"
+	"github.com/opencontainers/go-digest"
+)
+
+func Test_filterTags(t *testing.T) {
+	tags := []string{"latest", "v1.19.8", "v1.20.4", "v1.22.8", "v1.22.8-alpine", "v1.23.1"}
+	tests := []struct {
+		name string
+		opts SearchOptions
+		want []string
+	}{
+		{
+			"no filter",
+			SearchOptions{},
+			tags,
+		},
+		{
+			"semver range",
+			SearchOptions{Semver: ">= 1.20, < 1.23"},
+			[]string{"v1.20.4", "v1.22.8"},
+		},
+		{
+			"regex",
+			SearchOptions{Regex: "^v1\\.22"},
+			[]string{"v1.22.8", "v1.22.8-alpine"},
+		},
+		{
+			"semver range and regex",
+			SearchOptions{Semver: ">= 1.20", Regex: "^v1\\.2[23]"},
+			[]string{"v1.22.8", "v1.23.1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := filterTags(tags, tt.opts)
+			if err != nil {
+				t.Fatalf("filterTags() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filterTags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := filterTags(tags, SearchOptions{Semver: "not a constraint"}); err == nil {
+		t.Errorf("filterTags() should fail with invalid semver constraint")
+	}
+}
+
+type fakeTags struct {
+	distribution.TagService
+	tags map[string]digest.Digest
+}
+
+func (f *fakeTags) Get(ctx context.Context, tag string) (distribution.Descriptor, error) {
+	d, ok := f.tags[tag]
+	if !ok {
+		return distribution.Descriptor{}, distribution.ErrTagUnknown{Tag: tag}
+	}
+	return distribution.Descriptor{Digest: d}, nil
+}
+
+type fakeManifests struct {
+	distribution.ManifestService
+	manifests map[digest.Digest]distribution.Manifest
+}
+
+func (f *fakeManifests) Get(ctx context.Context, dgst digest.Digest, options ...distribution.ManifestServiceOption) (distribution.Manifest, error) {
+	m, ok := f.manifests[dgst]
+	if !ok {
+		return nil, distribution.ErrManifestUnknownRevision{Revision: dgst}
+	}
+	return m, nil
+}
+
+type fakeRepository struct {
+	distribution.Repository
+	tags      *fakeTags
+	manifests *fakeManifests
+}
+
+func (f *fakeRepository) Tags(ctx context.Context) distribution.TagService {
+	return f.tags
+}
+
+func (f *fakeRepository) Manifests(ctx context.Context, options ...distribution.ManifestServiceOption) (distribution.ManifestService, error) {
+	return f.manifests, nil
+}
+
+func TestGetTagInfos(t *testing.T) {
+	// the images of the manifest list are missing, so only the platforms of list are known.
+	list, err := manifestlist.FromDescriptors([]manifestlist.ManifestDescriptor{
+		{Descriptor: distribution.Descriptor{Digest: digest.FromString("amd64")}, Platform: manifestlist.PlatformSpec{OS: "linux", Architecture: "amd64"}},
+		{Descriptor: distribution.Descriptor{Digest: digest.FromString("arm64")}, Platform: manifestlist.PlatformSpec{OS: "linux", Architecture: "arm64", Variant: "v8"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	listDigest := digest.FromString("list")
+	repo := &fakeRepository{
+		tags:      &fakeTags{tags: map[string]digest.Digest{}},
+		manifests: &fakeManifests{manifests: map[digest.Digest]distribution.Manifest{listDigest: list}},
+	}
+
+	var tags []string
+	var want []TagInfo
+	for i := 0; i < 3*maxSearchGoroutineNum; i++ {
+		tag := fmt.Sprintf("v1.%d.0", i)
+		tags = append(tags, tag)
+		// the odd tags are unknown to the registry.
+		if i%2 == 1 {
+			want = append(want, TagInfo{Name: "kubernetes", Tag: tag, Platforms: []PlatformInfo{{Platform: UnknownPlatform}}})
+			continue
+		}
+		repo.tags.tags[tag] = listDigest
+		want = append(want, TagInfo{Name: "kubernetes", Tag: tag, Platforms: []PlatformInfo{{Platform: "linux/amd64"}, {Platform: "linux/arm64/v8"}}})
+	}
+
+	if got := getTagInfos(context.Background(), repo, "kubernetes", tags); !reflect.DeepEqual(got, want) {
+		t.Errorf("getTagInfos() = %+v, want %+v", got, want)
+	}
+}
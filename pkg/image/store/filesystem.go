@@ -42,6 +42,7 @@ import (
 	"github.com/sealerio/sealer/common"
 	"github.com/sealerio/sealer/pkg/image/types"
 	v1 "github.com/sealerio/sealer/types/api/v1"
+	"github.com/sealerio/sealer/utils/archive"
 	platUtils "github.com/sealerio/sealer/utils/platform"
 	yamlUtils "github.com/sealerio/sealer/utils/yaml"
 )
@@ -516,8 +517,17 @@ func (fs *filesystem) getImageMetadataItem(name string, platform *v1.Platform) (
 	return nil, &types.ImageNameOrIDNotFoundError{Name: name}
 }
 
+// setImageMetadata records the image of name, the created time is the source date epoch of reproducible build,
+// or the time of save. It is kept if the same image is saved again, as it is pushed in the image config.
 func (fs *filesystem) setImageMetadata(name string, metadata *types.ManifestDescriptor) error {
+	epoch, err := archive.SourceDateEpoch()
+	if err != nil {
+		return err
+	}
 	metadata.CREATED = time.Now()
+	if epoch != nil {
+		metadata.CREATED = *epoch
+	}
 	imagesMap, err := fs.getImageMetadataMap()
 	if err != nil {
 		return err
@@ -533,8 +543,10 @@ func (fs *filesystem) setImageMetadata(name string, metadata *types.ManifestDesc
 		// modify the existed image
 		for _, m := range manifestList.Manifests {
 			if platUtils.Matched(m.Platform, metadata.Platform) {
+				if m.ID != metadata.ID || epoch != nil {
+					m.CREATED = metadata.CREATED
+				}
 				m.ID = metadata.ID
-				m.CREATED = metadata.CREATED
 				m.SIZE = metadata.SIZE
 				changed = true
 			}
@@ -0,0 +1,81 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/sealerio/sealer/pkg/image/distributionutil"
+	"github.com/sealerio/sealer/pkg/image/reference"
+	"github.com/sealerio/sealer/utils/yaml"
+)
+
+// SyncItem is a ClusterImage to sync from Src to Dst.
+type SyncItem struct {
+	Src string `json:"src"`
+	Dst string `json:"dst"`
+}
+
+// SyncList is the yaml file listing the ClusterImages to sync.
+type SyncList struct {
+	Images []SyncItem `json:"images"`
+}
+
+// LoadSyncList reads the ClusterImages to sync from a yaml file.
+func LoadSyncList(path string) ([]SyncItem, error) {
+	var list SyncList
+	if err := yaml.UnmarshalFile(path, &list); err != nil {
+		return nil, err
+	}
+	for _, item := range list.Images {
+		if item.Src == "" || item.Dst == "" {
+			return nil, fmt.Errorf("both src and dst of images to sync are required in %s", path)
+		}
+	}
+	return list.Images, nil
+}
+
+// Sync copies the manifest list of ClusterImage src with the manifests, configs and layers of all platforms
+// from its registry to the registry of dst directly, the blobs existing in dst are skipped, and nothing is
+// stored into the local layer store. The auth of both registries is read from the docker auth file.
+func Sync(src, dst string, opts distributionutil.CopyOptions) error {
+	srcNamed, err := reference.ParseToNamed(src)
+	if err != nil {
+		return err
+	}
+	dstNamed, err := reference.ParseToNamed(dst)
+	if err != nil {
+		return err
+	}
+
+	srcRepo, err := distributionutil.NewV2Repository(srcNamed, "pull")
+	if err != nil {
+		return fmt.Errorf("failed to connect to registry %s: %v", srcNamed.Domain(), err)
+	}
+	dstRepo, err := distributionutil.NewV2Repository(dstNamed, "pull", "push")
+	if err != nil {
+		return fmt.Errorf("failed to connect to registry %s: %v", dstNamed.Domain(), err)
+	}
+
+	logrus.Infof("syncing image %s to %s", srcNamed.Raw(), dstNamed.Raw())
+	if err = distributionutil.CopyImageTo(context.Background(), srcRepo, dstRepo, srcNamed.Tag(), dstNamed.Tag(), opts); err != nil {
+		return fmt.Errorf("failed to sync image %s to %s: %v", srcNamed.Raw(), dstNamed.Raw(), err)
+	}
+	logrus.Infof("succeed in syncing image %s to %s", srcNamed.Raw(), dstNamed.Raw())
+	return nil
+}
@@ -15,10 +15,14 @@
 package docker
 
 import (
+	"fmt"
+	"io/ioutil"
+
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/strslice"
 	"github.com/sirupsen/logrus"
 
 	"github.com/sealerio/sealer/pkg/infra/container/client"
@@ -110,6 +114,70 @@ func (p *Provider) RunContainer(opts *client.CreateOptsForContainer) (string, er
 	return resp.ID, nil
 }
 
+func (p *Provider) RunCommand(opts *client.CreateOptsForCommand) (string, error) {
+	//docker run --rm --network host --workdir /path --volume /path:/path
+	//--entrypoint /bin/sh image -c "cmd"
+	_, err := p.PullImage(opts.ImageName)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := p.DockerClient.ContainerCreate(p.Ctx, &container.Config{
+		Image:      opts.ImageName,
+		Entrypoint: strslice.StrSlice{"/bin/sh", "-c"},
+		Cmd:        strslice.StrSlice{opts.Cmd},
+		Env:        opts.Env,
+		WorkingDir: opts.WorkingDir,
+		// with tty, stdout and stderr are not multiplexed in logs.
+		Tty: true,
+	},
+		&container.HostConfig{
+			NetworkMode: "host",
+			Mounts:      opts.Mount,
+		}, nil, nil, "")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := p.RmContainer(resp.ID); err != nil {
+			logrus.Warnf("failed to remove container %s: %v", resp.ID, err)
+		}
+	}()
+
+	statusCh, errCh := p.DockerClient.ContainerWait(p.Ctx, resp.ID, container.WaitConditionNextExit)
+	if err = p.DockerClient.ContainerStart(p.Ctx, resp.ID, types.ContainerStartOptions{}); err != nil {
+		return "", err
+	}
+
+	var exitCode int64
+	select {
+	case err = <-errCh:
+		return "", fmt.Errorf("failed to wait container %s: %v", resp.ID, err)
+	case status := <-statusCh:
+		exitCode = status.StatusCode
+	}
+
+	logs, err := p.DockerClient.ContainerLogs(p.Ctx, resp.ID, types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+	})
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = logs.Close()
+	}()
+
+	output, err := ioutil.ReadAll(logs)
+	if err != nil {
+		return "", err
+	}
+	if exitCode != 0 {
+		return string(output), fmt.Errorf("command exited with code %d", exitCode)
+	}
+	return string(output), nil
+}
+
 func (p *Provider) GetContainerInfo(containerID string, networkName string) (*client.Container, error) {
 	resp, err := p.DockerClient.ContainerInspect(p.Ctx, containerID)
 	if err != nil {
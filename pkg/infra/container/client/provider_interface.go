@@ -24,6 +24,7 @@ type ProviderService interface {
 	GetContainerInfo(containerID string, networkName string) (*Container, error)
 	RmContainer(containerID string) error
 	PullImage(imageName string) (string, error)
+	RunCommand(opts *CreateOptsForCommand) (string, error)
 }
 
 type Container struct {
@@ -45,6 +46,16 @@ type CreateOptsForContainer struct {
 	Mount             []mount.Mount
 }
 
+// CreateOptsForCommand is used to run a command in a throwaway container,
+// the container is removed once the command exits.
+type CreateOptsForCommand struct {
+	ImageName  string
+	Cmd        string
+	Env        []string
+	WorkingDir string
+	Mount      []mount.Mount
+}
+
 type DockerInfo struct {
 	CgroupDriver    string
 	CgroupVersion   string
@@ -23,6 +23,8 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/scheme"
+
+	"github.com/sealerio/sealer/common"
 )
 
 const (
@@ -39,10 +41,10 @@ func LvsStaticPodYaml(vip net.IP, masters []net.IP, image string) string {
 	if image == "" {
 		image = DefaultLvsCareImage
 	}
-	args := []string{"care", "--vs", vip.String() + ":6443", "--health-path", "/healthz", "--health-schem", "https"}
+	args := []string{"care", "--vs", net.JoinHostPort(vip.String(), common.APIServerPort), "--health-path", "/healthz", "--health-schem", "https"}
 	for _, m := range masters {
 		args = append(args, "--rs")
-		args = append(args, m.String()+":6443")
+		args = append(args, net.JoinHostPort(m.String(), common.APIServerPort))
 	}
 	flag := true
 	pod := componentPod(v1.Container{
@@ -0,0 +1,113 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadbalancer
+
+import (
+	"fmt"
+	"net"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/sealerio/sealer/common"
+)
+
+const (
+	KubeVIPStaticPodName = "kube-vip"
+	DefaultKubeVIPImage  = "plndr/kube-vip:v0.4.4"
+	kubeVIPKubeConfig    = "/etc/kubernetes/admin.conf"
+)
+
+// kubeVIP runs kube-vip on each master, the leader advertises VIP by ARP on the network interface,
+// so that VIP is reachable from outside the cluster.
+type kubeVIP struct {
+	vip   net.IP
+	iface string
+	image string
+}
+
+func (k *kubeVIP) Type() string {
+	return KubeVIP
+}
+
+func (k *kubeVIP) VIP() net.IP {
+	return k.vip
+}
+
+func (k *kubeVIP) MasterStaticPod() (string, error) {
+	cidr := "32"
+	if k.vip.To4() == nil {
+		cidr = "128"
+	}
+	hostPathType := v1.HostPathFile
+	pod := v1.Pod{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Pod",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      KubeVIPStaticPodName,
+			Namespace: metav1.NamespaceSystem,
+		},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{{
+				Name:            KubeVIPStaticPodName,
+				Image:           k.image,
+				Args:            []string{"manager"},
+				ImagePullPolicy: v1.PullIfNotPresent,
+				Env: []v1.EnvVar{
+					{Name: "vip_arp", Value: "true"},
+					{Name: "port", Value: common.APIServerPort},
+					{Name: "vip_interface", Value: k.iface},
+					{Name: "vip_cidr", Value: cidr},
+					{Name: "cp_enable", Value: "true"},
+					{Name: "cp_namespace", Value: metav1.NamespaceSystem},
+					{Name: "vip_leaderelection", Value: "true"},
+					{Name: "vip_leaseduration", Value: "5"},
+					{Name: "vip_renewdeadline", Value: "3"},
+					{Name: "vip_retryperiod", Value: "1"},
+					{Name: "address", Value: k.vip.String()},
+				},
+				SecurityContext: &v1.SecurityContext{
+					Capabilities: &v1.Capabilities{
+						Add: []v1.Capability{"NET_ADMIN", "NET_RAW"},
+					},
+				},
+				VolumeMounts: []v1.VolumeMount{
+					{Name: "kubeconfig", MountPath: kubeVIPKubeConfig},
+				},
+			}},
+			HostNetwork: true,
+			Volumes: []v1.Volume{
+				{Name: "kubeconfig", VolumeSource: v1.VolumeSource{
+					HostPath: &v1.HostPathVolumeSource{
+						Path: kubeVIPKubeConfig,
+						Type: &hostPathType,
+					},
+				}},
+			},
+		},
+	}
+	data, err := yaml.Marshal(pod)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate kube-vip static pod: %v", err)
+	}
+	return string(data), nil
+}
+
+func (k *kubeVIP) NodeStaticPod(masters []net.IP) (string, error) {
+	return "", nil
+}
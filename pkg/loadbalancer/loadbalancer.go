@@ -0,0 +1,110 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadbalancer
+
+import (
+	"fmt"
+	"net"
+
+	v2 "github.com/sealerio/sealer/types/api/v2"
+)
+
+const (
+	LVSCare  = "lvscare"
+	KubeVIP  = "kube-vip"
+	External = "external"
+
+	DefaultVIP = "10.103.97.2"
+)
+
+// Interface is the load balancer of control-plane endpoint, the nodes reach apiserver on masters by its VIP.
+type Interface interface {
+	// Type returns the type of load balancer, which is lvscare, kube-vip or external.
+	Type() string
+	// VIP returns the address of control-plane endpoint, it is added to the cert SANs of apiserver.
+	VIP() net.IP
+	// MasterStaticPod returns the static pod yaml running on masters to serve VIP, it is empty if not required.
+	MasterStaticPod() (string, error)
+	// NodeStaticPod returns the static pod yaml running on nodes to balance VIP to masters, it is empty if not required.
+	NodeStaticPod(masters []net.IP) (string, error)
+}
+
+// NewLoadBalancer returns the load balancer of spec, lvscare is used by default. The images of static pods
+// are pulled from registryRepo if they are not set in spec.
+func NewLoadBalancer(spec v2.LoadBalancer, master0 net.IP, registryRepo string) (Interface, error) {
+	var vip net.IP
+	if spec.VIP != "" {
+		if vip = net.ParseIP(spec.VIP); vip == nil {
+			return nil, fmt.Errorf("invalid VIP %s of load balancer", spec.VIP)
+		}
+	}
+
+	switch spec.Type {
+	case "", LVSCare:
+		// lvscare splits the servers "[ip]:port" by colon, so it never sets the IPVS rules of IPv6 addresses.
+		if isIPv6(master0) || isIPv6(vip) {
+			return nil, fmt.Errorf("lvscare does not support IPv6, set the load balancer type to %s or %s", KubeVIP, External)
+		}
+		if vip == nil {
+			vip = net.ParseIP(DefaultVIP)
+		}
+		image := spec.Image
+		if image == "" {
+			image = registryRepo + "/fanux/lvscare:latest"
+		}
+		return &lvscare{vip: vip, image: image}, nil
+	case KubeVIP:
+		if vip == nil || spec.Interface == "" {
+			return nil, fmt.Errorf("both VIP and network interface are required for kube-vip")
+		}
+		image := spec.Image
+		if image == "" {
+			image = registryRepo + "/" + DefaultKubeVIPImage
+		}
+		return &kubeVIP{vip: vip, iface: spec.Interface, image: image}, nil
+	case External:
+		if vip == nil {
+			return nil, fmt.Errorf("the address of external load balancer is required")
+		}
+		return &external{vip: vip}, nil
+	default:
+		return nil, fmt.Errorf("unsupported load balancer type %s, it should be one of %s, %s and %s", spec.Type, LVSCare, KubeVIP, External)
+	}
+}
+
+func isIPv6(ip net.IP) bool {
+	return ip != nil && ip.To4() == nil
+}
+
+// external is the load balancer outside the cluster, which forwards VIP:6443 to masters, sealer only sets the endpoint.
+type external struct {
+	vip net.IP
+}
+
+func (e *external) Type() string {
+	return External
+}
+
+func (e *external) VIP() net.IP {
+	return e.vip
+}
+
+func (e *external) MasterStaticPod() (string, error) {
+	return "", nil
+}
+
+func (e *external) NodeStaticPod(masters []net.IP) (string, error) {
+	return "", nil
+}
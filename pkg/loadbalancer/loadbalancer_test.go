@@ -0,0 +1,74 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadbalancer
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	v2 "github.com/sealerio/sealer/types/api/v2"
+)
+
+func TestNewLoadBalancer(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    v2.LoadBalancer
+		master0 string
+		wantVIP string
+		wantErr bool
+	}{
+		{"default lvscare", v2.LoadBalancer{}, "192.168.0.2", DefaultVIP, false},
+		{"default lvscare ipv6", v2.LoadBalancer{}, "2001:db8::2", "", true},
+		{"lvscare with ipv6 vip", v2.LoadBalancer{Type: LVSCare, VIP: "fd00::100"}, "192.168.0.2", "", true},
+		{"kube-vip ipv6", v2.LoadBalancer{Type: KubeVIP, VIP: "2001:db8::100", Interface: "eth0"}, "2001:db8::2", "2001:db8::100", false},
+		{"lvscare with vip", v2.LoadBalancer{Type: LVSCare, VIP: "10.0.0.100"}, "192.168.0.2", "10.0.0.100", false},
+		{"kube-vip", v2.LoadBalancer{Type: KubeVIP, VIP: "192.168.0.100", Interface: "eth0"}, "192.168.0.2", "192.168.0.100", false},
+		{"kube-vip without interface", v2.LoadBalancer{Type: KubeVIP, VIP: "192.168.0.100"}, "192.168.0.2", "", true},
+		{"external", v2.LoadBalancer{Type: External, VIP: "192.168.0.200"}, "192.168.0.2", "192.168.0.200", false},
+		{"external without vip", v2.LoadBalancer{Type: External}, "192.168.0.2", "", true},
+		{"invalid vip", v2.LoadBalancer{Type: External, VIP: "foo"}, "192.168.0.2", "", true},
+		{"unknown type", v2.LoadBalancer{Type: "haproxy"}, "192.168.0.2", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lb, err := NewLoadBalancer(tt.spec, net.ParseIP(tt.master0), "sea.hub:5000")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewLoadBalancer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !lb.VIP().Equal(net.ParseIP(tt.wantVIP)) {
+				t.Errorf("NewLoadBalancer() VIP = %s, want %s", lb.VIP(), tt.wantVIP)
+			}
+		})
+	}
+}
+
+func TestStaticPods(t *testing.T) {
+	masters := []net.IP{net.ParseIP("192.168.0.2"), net.ParseIP("192.168.0.3")}
+
+	lvs, _ := NewLoadBalancer(v2.LoadBalancer{}, masters[0], "sea.hub:5000")
+	if pod, _ := lvs.MasterStaticPod(); pod != "" {
+		t.Errorf("lvscare should not run on masters, got %s", pod)
+	}
+
+	vip, _ := NewLoadBalancer(v2.LoadBalancer{Type: KubeVIP, VIP: "192.168.0.100", Interface: "eth0"}, masters[0], "sea.hub:5000")
+	pod, err := vip.MasterStaticPod()
+	if err != nil || !strings.Contains(pod, "sea.hub:5000/"+DefaultKubeVIPImage) || !strings.Contains(pod, "192.168.0.100") {
+		t.Errorf("unexpected kube-vip master static pod: %s, %v", pod, err)
+	}
+	if pod, _ := vip.NodeStaticPod(masters); pod != "" {
+		t.Errorf("kube-vip should not run on nodes, got %s", pod)
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadbalancer
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/sealerio/sealer/pkg/ipvs"
+)
+
+// lvscare keeps the IPVS rules of VIP on each node by the kube-lvscare static pod, VIP is only reachable in cluster.
+type lvscare struct {
+	vip   net.IP
+	image string
+}
+
+func (l *lvscare) Type() string {
+	return LVSCare
+}
+
+func (l *lvscare) VIP() net.IP {
+	return l.vip
+}
+
+func (l *lvscare) MasterStaticPod() (string, error) {
+	return "", nil
+}
+
+func (l *lvscare) NodeStaticPod(masters []net.IP) (string, error) {
+	yaml := ipvs.LvsStaticPodYaml(l.vip, masters, l.image)
+	if yaml == "" {
+		return "", fmt.Errorf("failed to generate lvscare static pod of masters %s", masters)
+	}
+	return yaml, nil
+}
@@ -109,7 +109,7 @@ func connEtcd(masterIP net.IP) (clientv3.Config, error) {
 		RootCAs:      pool,
 	}
 
-	endpoints := []string{fmt.Sprintf("https://%s:2379", masterIP)}
+	endpoints := []string{"https://" + net.JoinHostPort(masterIP.String(), "2379")}
 	cfg := clientv3.Config{
 		Endpoints:   endpoints,
 		DialTimeout: dialTimeout,
@@ -18,14 +18,17 @@ import (
 	"fmt"
 	"net"
 	"strings"
+	"sync"
 
 	v1 "github.com/sealerio/sealer/types/api/v1"
+	v2 "github.com/sealerio/sealer/types/api/v2"
 
 	utilsnet "github.com/sealerio/sealer/utils/net"
 	"github.com/sirupsen/logrus"
 
 	"github.com/sealerio/sealer/common"
 	"github.com/sealerio/sealer/pkg/client/k8s"
+	"github.com/sealerio/sealer/pkg/runtime"
 )
 
 const (
@@ -35,6 +38,13 @@ const (
 	SplitSymbol = "|"
 )
 
+var (
+	operationalMastersLock sync.Mutex
+	// operationalMasters caches the operational master of clusters by name, so the masters are probed once per
+	// run instead of once per plugin.
+	operationalMasters = map[string]net.IP{}
+)
+
 func GetIpsByOnField(on string, context Context, phase Phase) (ipList []net.IP, err error) {
 	on = strings.TrimSpace(on)
 	if strings.Contains(on, EqualSymbol) {
@@ -57,7 +67,14 @@ func GetIpsByOnField(on string, context Context, phase Phase) (ipList []net.IP,
 		if len(ipList) < 1 {
 			return nil, fmt.Errorf("invalid on filed: [%s]", on)
 		}
+		// master0 of plugin is an operational master once the cluster is initialized, the first master is used
+		// before that or if none is found.
 		ipList = ipList[:1]
+		if isClusterInitialized(phase) {
+			if master := getOperationalMaster(context.Cluster); master != nil {
+				ipList = []net.IP{master}
+			}
+		}
 	} else {
 		ipList = utilsnet.DisassembleIPList(on)
 	}
@@ -67,6 +84,32 @@ func GetIpsByOnField(on string, context Context, phase Phase) (ipList []net.IP,
 	return ipList, nil
 }
 
+// isClusterInitialized returns whether the cluster is initialized and not deleted yet at phase.
+func isClusterInitialized(phase Phase) bool {
+	switch phase {
+	case PhasePreJoin, PhasePostJoin, PhasePreGuest, PhasePostInstall, PhasePreClean:
+		return true
+	}
+	return false
+}
+
+// getOperationalMaster returns the cached operational master of cluster, or probes the masters for it, nil is
+// returned if none is found.
+func getOperationalMaster(cluster *v2.Cluster) net.IP {
+	operationalMastersLock.Lock()
+	defer operationalMastersLock.Unlock()
+	if master, ok := operationalMasters[cluster.Name]; ok {
+		return master
+	}
+	master, err := runtime.GetOperationalMaster(cluster)
+	if err != nil {
+		logrus.Warnf("failed to get operational master, the first master is used: %v", err)
+		return nil
+	}
+	operationalMasters[cluster.Name] = master
+	return master
+}
+
 func isSamePluginSpec(p1, p2 v1.Plugin) bool {
 	return p1.Spec.Type == p2.Spec.Type && p1.Spec.On == p2.Spec.On &&
 		p1.Spec.Data == p2.Spec.Data && p1.Spec.Action == p2.Spec.Action
@@ -0,0 +1,73 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"net"
+	"testing"
+
+	"github.com/sealerio/sealer/common"
+	v2 "github.com/sealerio/sealer/types/api/v2"
+)
+
+func TestGetIpsByOnFieldMaster0(t *testing.T) {
+	cluster := &v2.Cluster{}
+	cluster.Name = "test-master0-plugin"
+	cluster.Spec.Hosts = []v2.Host{
+		{IPS: []net.IP{net.ParseIP("192.168.0.2"), net.ParseIP("192.168.0.3")}, Roles: []string{common.MASTER}},
+		{IPS: []net.IP{net.ParseIP("192.168.0.4")}, Roles: []string{common.NODE}},
+	}
+	// the operational master is cached by the first probe of the run.
+	operationalMasters[cluster.Name] = net.ParseIP("192.168.0.3")
+	defer delete(operationalMasters, cluster.Name)
+
+	tests := []struct {
+		name  string
+		phase Phase
+		want  net.IP
+	}{
+		{
+			name:  "first master before init",
+			phase: PhasePreInit,
+			want:  net.ParseIP("192.168.0.2"),
+		},
+		{
+			name:  "first master after clean",
+			phase: PhasePostClean,
+			want:  net.ParseIP("192.168.0.2"),
+		},
+		{
+			name:  "operational master after install",
+			phase: PhasePostInstall,
+			want:  net.ParseIP("192.168.0.3"),
+		},
+		{
+			name:  "operational master before join",
+			phase: PhasePreJoin,
+			want:  net.ParseIP("192.168.0.3"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetIpsByOnField(common.MASTER0, Context{Cluster: cluster}, tt.phase)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != 1 || !got[0].Equal(tt.want) {
+				t.Errorf("GetIpsByOnField() = %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
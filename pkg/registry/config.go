@@ -20,6 +20,7 @@ import (
 	"path/filepath"
 
 	"github.com/sealerio/sealer/common"
+	v2 "github.com/sealerio/sealer/types/api/v2"
 	osi "github.com/sealerio/sealer/utils/os"
 	"github.com/sealerio/sealer/utils/yaml"
 
@@ -30,6 +31,14 @@ import (
 const (
 	ConfigFile = "registry.yml"
 	SeaHub     = "sea.hub"
+	// SeaHubRepo is the built-in registry referenced by the images in manifests and charts of ClusterImage.
+	SeaHubRepo = SeaHub + ":" + defaultPort
+	// NodeAnnotation is the cluster annotation recording the host which runs the built-in registry.
+	NodeAnnotation = "sealer.io/registry-node"
+
+	defaultPort         = "5000"
+	externalDefaultPort = "443"
+	defaultCertYears    = 10
 )
 
 type Config struct {
@@ -38,6 +47,20 @@ type Config struct {
 	Port     string `yaml:"port,omitempty"`
 	Username string `json:"username,omitempty"`
 	Password string `json:"password,omitempty"`
+	// HA runs the registry on every master, or on Nodes if it is set, and the registry domain
+	// resolves to all of them, so image pulls survive the loss of one registry node.
+	HA    bool     `json:"ha,omitempty"`
+	Nodes []net.IP `json:"nodes,omitempty"`
+	// External uses an existing registry like Harbor instead of starting the built-in one,
+	// the images cached in ClusterImage are pushed into Project of it.
+	External bool   `json:"external,omitempty"`
+	Project  string `json:"project,omitempty"`
+	// CA is the CA certificate path of external registry on the host running sealer,
+	// it is sent to nodes to trust the registry, leave it empty if the registry cert is trusted by system.
+	CA string `json:"ca,omitempty"`
+	// CertYears is the lifetime of the built-in registry cert, which is signed by the registry CA,
+	// renew it by "sealer alpha cert renew --registry".
+	CertYears int `json:"certYears,omitempty"`
 }
 
 func (c *Config) GenerateHTTPBasicAuth() (string, error) {
@@ -51,16 +74,61 @@ func (c *Config) GenerateHTTPBasicAuth() (string, error) {
 	return c.Username + ":" + string(pwdHash), nil
 }
 
-func (c *Config) Repo() string {
+// Host returns the registry address, the https port is omitted for external registry.
+func (c *Config) Host() string {
+	if c.External && c.Port == externalDefaultPort {
+		return c.Domain
+	}
 	return fmt.Sprintf("%s:%s", c.Domain, c.Port)
 }
 
+// Repo returns the prefix of images in registry, the project is included for external registry.
+func (c *Config) Repo() string {
+	if c.External && c.Project != "" {
+		return fmt.Sprintf("%s/%s", c.Host(), c.Project)
+	}
+	return c.Host()
+}
+
+// GetRegistryNodes returns the hosts which run the registry.
+func (c *Config) GetRegistryNodes(masters []net.IP) []net.IP {
+	if c.External {
+		return nil
+	}
+	if !c.HA {
+		return []net.IP{c.IP}
+	}
+	if len(c.Nodes) != 0 {
+		return c.Nodes
+	}
+	return masters
+}
+
+// GetClusterConfig returns the registry config of cluster under rootfs. The built-in registry runs on the host
+// recorded by NodeAnnotation, which is the bootstrap master of cluster creation, so it does not follow master0
+// when the masters change; the first master is used for the clusters created before the annotation.
+func GetClusterConfig(rootfs string, cluster *v2.Cluster) *Config {
+	ip := net.ParseIP(cluster.GetAnnotationsByKey(NodeAnnotation))
+	if ip == nil {
+		ip = cluster.GetMaster0IP()
+	}
+	return GetConfig(rootfs, ip)
+}
+
+// RecordNode records the host running the built-in registry on cluster when it is created.
+func RecordNode(cluster *v2.Cluster) {
+	if cluster.GetAnnotationsByKey(NodeAnnotation) == "" {
+		cluster.SetAnnotations(NodeAnnotation, cluster.GetMaster0IP().String())
+	}
+}
+
 func GetConfig(rootfs string, registryIP net.IP) *Config {
 	var config Config
 	var defaultConfig = &Config{
-		IP:     registryIP,
-		Domain: SeaHub,
-		Port:   "5000",
+		IP:        registryIP,
+		Domain:    SeaHub,
+		Port:      defaultPort,
+		CertYears: defaultCertYears,
 	}
 	registryConfigPath := filepath.Join(rootfs, common.EtcDir, ConfigFile)
 	if !osi.IsFileExist(registryConfigPath) {
@@ -72,8 +140,22 @@ func GetConfig(rootfs string, registryIP net.IP) *Config {
 		logrus.Errorf("failed to read registry config: %v", err)
 		return defaultConfig
 	}
+	if config.External {
+		if config.Domain == "" {
+			logrus.Errorf("domain of external registry is required, default registry configuration is used")
+			return defaultConfig
+		}
+		if config.Port == "" {
+			config.Port = externalDefaultPort
+		}
+		logrus.Debugf("The ultimate registry configration is: \n %+v", config)
+		return &config
+	}
 	if config.IP == nil {
 		config.IP = defaultConfig.IP
+		if config.HA && len(config.Nodes) != 0 {
+			config.IP = config.Nodes[0]
+		}
 	}
 	if config.Port == "" {
 		config.Port = defaultConfig.Port
@@ -81,6 +163,9 @@ func GetConfig(rootfs string, registryIP net.IP) *Config {
 	if config.Domain == "" {
 		config.Domain = defaultConfig.Domain
 	}
+	if config.CertYears <= 0 {
+		config.CertYears = defaultCertYears
+	}
 	logrus.Debugf("The ultimate registry configration is: \n %+v", config)
 	return &config
 }
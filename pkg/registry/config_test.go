@@ -0,0 +1,72 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"net"
+	"testing"
+
+	"github.com/sealerio/sealer/common"
+
+	v2 "github.com/sealerio/sealer/types/api/v2"
+)
+
+func TestGetClusterConfig(t *testing.T) {
+	newCluster := func(annotations map[string]string, masters ...string) *v2.Cluster {
+		cluster := &v2.Cluster{}
+		cluster.Annotations = annotations
+		host := v2.Host{Roles: []string{common.MASTER}}
+		for _, m := range masters {
+			host.IPS = append(host.IPS, net.ParseIP(m))
+		}
+		cluster.Spec.Hosts = []v2.Host{host}
+		return cluster
+	}
+	tests := []struct {
+		name    string
+		cluster *v2.Cluster
+		want    string
+	}{
+		{
+			name:    "registry node recorded",
+			cluster: newCluster(map[string]string{NodeAnnotation: "192.168.0.1"}, "192.168.0.2", "192.168.0.1"),
+			want:    "192.168.0.1",
+		},
+		{
+			name:    "fall back to master0",
+			cluster: newCluster(nil, "192.168.0.2", "192.168.0.1"),
+			want:    "192.168.0.2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetClusterConfig("", tt.cluster); got.IP.String() != tt.want {
+				t.Errorf("GetClusterConfig() ip = %s, want %s", got.IP, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordNode(t *testing.T) {
+	cluster := &v2.Cluster{}
+	cluster.Spec.Hosts = []v2.Host{{Roles: []string{common.MASTER}, IPS: []net.IP{net.ParseIP("192.168.0.1")}}}
+	RecordNode(cluster)
+	// master0 changes, the recorded node is kept.
+	cluster.Spec.Hosts[0].IPS = []net.IP{net.ParseIP("192.168.0.2")}
+	RecordNode(cluster)
+	if got := cluster.GetAnnotationsByKey(NodeAnnotation); got != "192.168.0.1" {
+		t.Errorf("RecordNode() = %s, want 192.168.0.1", got)
+	}
+}
@@ -0,0 +1,307 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/distribution/distribution/v3"
+	"github.com/distribution/distribution/v3/manifest/manifestlist"
+	distreference "github.com/distribution/distribution/v3/reference"
+	"github.com/docker/docker/api/types"
+	"github.com/opencontainers/go-digest"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/sealerio/sealer/pkg/image/distributionutil"
+	"github.com/sealerio/sealer/pkg/image/reference"
+)
+
+const (
+	// repositoriesDir is the dir of repositories under the registry storage root.
+	repositoriesDir = "docker/registry/v2/repositories"
+	// gcCommand stops the registry container, so nothing is pushed during the garbage collection, and deletes the
+	// blobs not referenced by any manifest in a throwaway container with the volumes of it, then starts the
+	// registry again whatever the garbage collection results. The untagged manifests are not deleted, since the
+	// platform manifests of manifest lists are untagged, the manifests of deleted images are removed explicitly.
+	gcCommand = "CLI=docker; docker inspect %[1]s >/dev/null 2>&1 || CLI=nerdctl; " +
+		"IMAGE=$($CLI inspect -f '{{.Config.Image}}' %[1]s) && $CLI stop %[1]s && " +
+		"($CLI run --rm --volumes-from %[1]s --entrypoint registry $IMAGE garbage-collect /etc/docker/registry/config.yml; " +
+		"RC=$?; $CLI start %[1]s && exit $RC)"
+	catalogPageSize = 100
+	// SandboxImageRepo is the repository of the pod sandbox image, which is run by container runtime instead of
+	// pods, so it is never seen in the pod specs.
+	SandboxImageRepo = "pause"
+)
+
+// Image is a tag of repository stored in the registry.
+type Image struct {
+	Repo   string
+	Tag    string
+	Digest digest.Digest
+	// Manifests are the platform manifests of manifest list.
+	Manifests []digest.Digest
+	// Size is the size of manifest config and layers, of all platforms for manifest list.
+	Size int64
+	// InUse is true if any pod of cluster runs the image.
+	InUse bool
+	// Kept is true if the image is the sandbox image or listed in the ClusterImage, it is never garbage collected.
+	Kept bool
+}
+
+func (i Image) String() string {
+	return fmt.Sprintf("%s:%s", i.Repo, i.Tag)
+}
+
+// ListImages lists all the images of registry, host is the address to connect the registry, like 192.168.0.2:5000.
+func (c *Config) ListImages(host string) ([]Image, error) {
+	client, err := c.connect(host)
+	if err != nil {
+		return nil, err
+	}
+	return listImages(context.Background(), host, client)
+}
+
+// connect connects the registry of host with the credentials of registry.
+func (c *Config) connect(host string) (*registryClient, error) {
+	authConfig := types.AuthConfig{
+		Username:      c.Username,
+		Password:      c.Password,
+		ServerAddress: host,
+	}
+	reg, err := distributionutil.NewV2RegistryWithAuth(authConfig, host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to registry %s: %v", host, err)
+	}
+	return &registryClient{
+		catalog: reg.Repositories,
+		repository: func(name string) (distribution.Repository, error) {
+			named, err := reference.ParseToNamed(fmt.Sprintf("%s/%s", host, name))
+			if err != nil {
+				return nil, err
+			}
+			repo, err := distributionutil.NewV2RepositoryWithAuth(authConfig, named, "pull", "push")
+			if err != nil {
+				return nil, fmt.Errorf("failed to connect to repository %s: %v", name, err)
+			}
+			return repo, nil
+		},
+	}, nil
+}
+
+func listImages(ctx context.Context, host string, client *registryClient) ([]Image, error) {
+	var (
+		repos []string
+		last  string
+	)
+	for {
+		entries := make([]string, catalogPageSize)
+		n, err := client.catalog(ctx, entries, last)
+		repos = append(repos, entries[:n]...)
+		if err == io.EOF || n == 0 {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list repositories of registry %s: %v", host, err)
+		}
+		last = entries[n-1]
+	}
+
+	var images []Image
+	for _, repoName := range repos {
+		repo, err := client.repository(repoName)
+		if err != nil {
+			return nil, err
+		}
+		repoImages, err := listRepoImages(ctx, repoName, repo)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, repoImages...)
+	}
+	return images, nil
+}
+
+func listRepoImages(ctx context.Context, repoName string, repo distribution.Repository) ([]Image, error) {
+	tags, err := repo.Tags(ctx).All(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags of %s: %v", repoName, err)
+	}
+	ms, err := repo.Manifests(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var images []Image
+	for _, tag := range tags {
+		desc, err := repo.Tags(ctx).Get(ctx, tag)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get tag %s of %s: %v", tag, repoName, err)
+		}
+		m, err := ms.Get(ctx, desc.Digest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get manifest of %s:%s: %v", repoName, tag, err)
+		}
+		image := Image{
+			Repo:   repoName,
+			Tag:    tag,
+			Digest: desc.Digest,
+			Size:   manifestSize(ctx, ms, m),
+		}
+		if _, ok := m.(*manifestlist.DeserializedManifestList); ok {
+			for _, ref := range m.References() {
+				image.Manifests = append(image.Manifests, ref.Digest)
+			}
+		}
+		images = append(images, image)
+	}
+	return images, nil
+}
+
+// manifestSize sums the size of blobs referenced by manifest, the platforms missing in registry are skipped.
+func manifestSize(ctx context.Context, ms distribution.ManifestService, m distribution.Manifest) int64 {
+	var size int64
+	if _, ok := m.(*manifestlist.DeserializedManifestList); !ok {
+		for _, ref := range m.References() {
+			size += ref.Size
+		}
+		return size
+	}
+	for _, ref := range m.References() {
+		child, err := ms.Get(ctx, ref.Digest)
+		if err != nil {
+			continue
+		}
+		size += manifestSize(ctx, ms, child)
+	}
+	return size
+}
+
+// MarkInUse marks the images run by the containers of pods, and the images kept whatever pods run, which are the
+// sandbox image and the images of kept. Images are matched by repository path and tag, regardless of the registry
+// domain, since the registry serves as the mirror of all registries, or by digest.
+func MarkInUse(images []Image, pods []v1.Pod, kept []string) {
+	var (
+		repoTags     = map[string]bool{}
+		digests      = map[digest.Digest]bool{}
+		keptRepoTags = map[string]bool{}
+		keptDigests  = map[digest.Digest]bool{}
+	)
+	for _, pod := range pods {
+		var containers []v1.Container
+		containers = append(containers, pod.Spec.InitContainers...)
+		containers = append(containers, pod.Spec.Containers...)
+		for _, c := range containers {
+			addImageRef(c.Image, repoTags, digests)
+		}
+
+		var statuses []v1.ContainerStatus
+		statuses = append(statuses, pod.Status.InitContainerStatuses...)
+		statuses = append(statuses, pod.Status.ContainerStatuses...)
+		for _, s := range statuses {
+			if i := strings.LastIndex(s.ImageID, "@"); i != -1 {
+				digests[digest.Digest(s.ImageID[i+1:])] = true
+			}
+		}
+	}
+	for _, image := range kept {
+		addImageRef(image, keptRepoTags, keptDigests)
+	}
+
+	for i := range images {
+		images[i].InUse = repoTags[images[i].String()] || digests[images[i].Digest]
+		images[i].Kept = keptRepoTags[images[i].String()] || keptDigests[images[i].Digest] ||
+			path.Base(images[i].Repo) == SandboxImageRepo
+	}
+}
+
+// addImageRef adds the repository path and tag of image to repoTags, or its digest to digests if it is canonical.
+func addImageRef(image string, repoTags map[string]bool, digests map[digest.Digest]bool) {
+	named, err := distreference.ParseNormalizedNamed(strings.TrimSpace(image))
+	if err != nil {
+		return
+	}
+	if canonical, ok := named.(distreference.Canonical); ok {
+		digests[canonical.Digest()] = true
+		return
+	}
+	named = distreference.TagNameOnly(named)
+	if tagged, ok := named.(distreference.Tagged); ok {
+		repoTags[distreference.Path(named)+":"+tagged.Tag()] = true
+	}
+}
+
+// UnusedImages returns the images neither used by any pod nor kept.
+func UnusedImages(images []Image) []Image {
+	var unused []Image
+	for _, image := range images {
+		if !image.InUse && !image.Kept {
+			unused = append(unused, image)
+		}
+	}
+	return unused
+}
+
+// GCCommands returns the commands to delete images from the registry storage under registryDir and to run the
+// blob garbage collection of registry container, the repository dir is removed if all its images are deleted.
+// The manifests of deleted images, including the platform manifests of manifest lists, are removed unless they
+// are referenced by the remaining images of repository.
+func GCCommands(registryDir, container string, images []Image, all []Image) []string {
+	deleted := map[string]bool{}
+	for _, image := range images {
+		deleted[image.String()] = true
+	}
+	var (
+		remains    = map[string]int{}
+		referenced = map[string]bool{}
+	)
+	for _, image := range all {
+		if deleted[image.String()] {
+			continue
+		}
+		remains[image.Repo]++
+		for _, d := range append([]digest.Digest{image.Digest}, image.Manifests...) {
+			referenced[image.Repo+"@"+d.String()] = true
+		}
+	}
+
+	var (
+		cmds    []string
+		removed = map[string]bool{}
+	)
+	for _, image := range images {
+		repoDir := filepath.Join(registryDir, repositoriesDir, image.Repo)
+		if remains[image.Repo] == 0 {
+			if !removed[image.Repo] {
+				cmds = append(cmds, fmt.Sprintf("rm -rf %s", repoDir))
+				removed[image.Repo] = true
+			}
+			continue
+		}
+		cmds = append(cmds, fmt.Sprintf("rm -rf %s", filepath.Join(repoDir, "_manifests", "tags", image.Tag)))
+		for _, d := range append([]digest.Digest{image.Digest}, image.Manifests...) {
+			if d == "" || referenced[image.Repo+"@"+d.String()] || removed[image.Repo+"@"+d.String()] {
+				continue
+			}
+			cmds = append(cmds, fmt.Sprintf("rm -rf %s", filepath.Join(repoDir, "_manifests", "revisions", d.Algorithm().String(), d.Hex())))
+			removed[image.Repo+"@"+d.String()] = true
+		}
+	}
+	return append(cmds, fmt.Sprintf(gcCommand, container))
+}
@@ -0,0 +1,188 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	digestA = digest.Digest("sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa")
+	digestB = digest.Digest("sha256:bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb")
+	digestC = digest.Digest("sha256:cccccccccccccccccccccccccccccccccccccccccccccccccccccccccccccccc")
+	digestD = digest.Digest("sha256:dddddddddddddddddddddddddddddddddddddddddddddddddddddddddddddddd")
+	digestE = digest.Digest("sha256:eeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee")
+)
+
+func TestMarkInUse(t *testing.T) {
+	tests := []struct {
+		name      string
+		image     Image
+		pods      []v1.Pod
+		kept      []string
+		wantInUse bool
+		wantKept  bool
+	}{
+		{
+			name:      "used by container of other registry domain",
+			image:     Image{Repo: "library/nginx", Tag: "1.21"},
+			pods:      []v1.Pod{podWithImages([]string{"sea.hub:5000/library/nginx:1.21"}, nil)},
+			wantInUse: true,
+		},
+		{
+			name:      "used by init container of docker hub",
+			image:     Image{Repo: "library/busybox", Tag: "latest"},
+			pods:      []v1.Pod{podWithImages(nil, []string{"busybox"})},
+			wantInUse: true,
+		},
+		{
+			name:  "other tag is not used",
+			image: Image{Repo: "library/nginx", Tag: "1.20"},
+			pods:  []v1.Pod{podWithImages([]string{"nginx:1.21"}, nil)},
+		},
+		{
+			name:      "used by digest",
+			image:     Image{Repo: "app", Tag: "v1", Digest: digestA},
+			pods:      []v1.Pod{podWithImages([]string{"sea.hub:5000/app@" + digestA.String()}, nil)},
+			wantInUse: true,
+		},
+		{
+			name:  "used by image id of status",
+			image: Image{Repo: "app", Tag: "v1", Digest: digestB},
+			pods: []v1.Pod{{Status: v1.PodStatus{ContainerStatuses: []v1.ContainerStatus{
+				{ImageID: "docker-pullable://sea.hub:5000/app@" + digestB.String()},
+			}}}},
+			wantInUse: true,
+		},
+		{
+			name:     "sandbox image is kept",
+			image:    Image{Repo: "pause", Tag: "3.6"},
+			wantKept: true,
+		},
+		{
+			name:     "image of ClusterImage is kept",
+			image:    Image{Repo: "calico/node", Tag: "v3.22.1"},
+			kept:     []string{"docker.io/calico/node:v3.22.1", ""},
+			wantKept: true,
+		},
+		{
+			name:  "unused image",
+			image: Image{Repo: "calico/node", Tag: "v3.19.1"},
+			pods:  []v1.Pod{podWithImages([]string{"calico/node:v3.22.1"}, nil)},
+			kept:  []string{"calico/node:v3.22.1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			images := []Image{tt.image}
+			MarkInUse(images, tt.pods, tt.kept)
+			if images[0].InUse != tt.wantInUse || images[0].Kept != tt.wantKept {
+				t.Errorf("MarkInUse() InUse = %v, Kept = %v, want %v, %v", images[0].InUse, images[0].Kept, tt.wantInUse, tt.wantKept)
+			}
+			unused := len(UnusedImages(images)) == 1
+			if unused != (!tt.wantInUse && !tt.wantKept) {
+				t.Errorf("UnusedImages() returns %v for %s", unused, tt.image)
+			}
+		})
+	}
+}
+
+func TestGCCommands(t *testing.T) {
+	all := []Image{
+		{Repo: "library/nginx", Tag: "1.20", Digest: digestA},
+		{Repo: "library/nginx", Tag: "1.21", Digest: digestB},
+		{Repo: "library/nginx", Tag: "latest", Digest: digestB},
+		{Repo: "app", Tag: "v1", Digest: digestA},
+		{Repo: "app", Tag: "v2", Digest: digestB},
+		{Repo: "multi-arch", Tag: "v1", Digest: digestA, Manifests: []digest.Digest{digestC, digestD}},
+		{Repo: "multi-arch", Tag: "v2", Digest: digestB, Manifests: []digest.Digest{digestC, digestE}},
+	}
+	gc := "CLI=docker; docker inspect sealer-registry >/dev/null 2>&1 || CLI=nerdctl; " +
+		"IMAGE=$($CLI inspect -f '{{.Config.Image}}' sealer-registry) && $CLI stop sealer-registry && " +
+		"($CLI run --rm --volumes-from sealer-registry --entrypoint registry $IMAGE garbage-collect /etc/docker/registry/config.yml; " +
+		"RC=$?; $CLI start sealer-registry && exit $RC)"
+	revision := func(repo string, d digest.Digest) string {
+		return "rm -rf /registry/docker/registry/v2/repositories/" + repo + "/_manifests/revisions/sha256/" + d.Hex()
+	}
+	tests := []struct {
+		name   string
+		images []Image
+		want   []string
+	}{
+		{
+			name:   "nothing to delete",
+			images: nil,
+			want:   []string{gc},
+		},
+		{
+			name:   "delete tag of repository",
+			images: []Image{all[0]},
+			want: []string{
+				"rm -rf /registry/docker/registry/v2/repositories/library/nginx/_manifests/tags/1.20",
+				revision("library/nginx", digestA),
+				gc,
+			},
+		},
+		{
+			name:   "manifest referenced by other tag is kept",
+			images: []Image{all[1]},
+			want: []string{
+				"rm -rf /registry/docker/registry/v2/repositories/library/nginx/_manifests/tags/1.21",
+				gc,
+			},
+		},
+		{
+			name:   "delete all tags of repository",
+			images: []Image{all[3], all[2], all[4]},
+			want: []string{
+				"rm -rf /registry/docker/registry/v2/repositories/app",
+				"rm -rf /registry/docker/registry/v2/repositories/library/nginx/_manifests/tags/latest",
+				gc,
+			},
+		},
+		{
+			name:   "platform manifest shared by other manifest list is kept",
+			images: []Image{all[5]},
+			want: []string{
+				"rm -rf /registry/docker/registry/v2/repositories/multi-arch/_manifests/tags/v1",
+				revision("multi-arch", digestA),
+				revision("multi-arch", digestD),
+				gc,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GCCommands("/registry", "sealer-registry", tt.images, all); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GCCommands() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func podWithImages(containers, initContainers []string) v1.Pod {
+	var pod v1.Pod
+	for _, image := range containers {
+		pod.Spec.Containers = append(pod.Spec.Containers, v1.Container{Image: image})
+	}
+	for _, image := range initContainers {
+		pod.Spec.InitContainers = append(pod.Spec.InitContainers, v1.Container{Image: image})
+	}
+	return pod
+}
@@ -0,0 +1,113 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/distribution/distribution/v3"
+	distreference "github.com/distribution/distribution/v3/reference"
+	"github.com/docker/docker/api/types"
+	"github.com/sirupsen/logrus"
+
+	"github.com/sealerio/sealer/common"
+	"github.com/sealerio/sealer/pkg/image/distributionutil"
+	"github.com/sealerio/sealer/pkg/image/reference"
+	"github.com/sealerio/sealer/pkg/image/save"
+)
+
+// rewriteImagesCommand rewrites the images of registry %[1]s in the files under dirs %[2]s to %[4]s,
+// %[3]s is the registry escaped for sed.
+const rewriteImagesCommand = "grep -rlF '%[1]s' %[2]s 2>/dev/null | xargs -r sed -i 's#%[3]s#%[4]s#g'"
+
+// RewriteSeaHubCommand returns the command rewriting the images of built-in registry referenced by the manifests
+// and charts under rootfs to the external registry, which the images of ClusterImage are pushed into.
+func (c *Config) RewriteSeaHubCommand(rootfs string) string {
+	if !c.External {
+		return ""
+	}
+	dirs := []string{filepath.Join(rootfs, common.RenderManifestsDir), filepath.Join(rootfs, common.RenderChartsDir)}
+	return fmt.Sprintf(rewriteImagesCommand, SeaHubRepo, strings.Join(dirs, " "), strings.ReplaceAll(SeaHubRepo, ".", `\.`), c.Repo())
+}
+
+// PushImages pushes all the images cached in registryDir, the registry dir of ClusterImage,
+// into the project of external registry, the repository path of images is kept.
+func (c *Config) PushImages(registryDir string) error {
+	ctx := context.Background()
+	local, err := save.NewLocalRegistry(ctx, registryDir)
+	if err != nil {
+		return err
+	}
+
+	authConfig := types.AuthConfig{
+		Username:      c.Username,
+		Password:      c.Password,
+		ServerAddress: c.Host(),
+	}
+	return pushImages(ctx, local, func(repoName string) (distribution.Repository, error) {
+		dstNamed, err := reference.ParseToNamed(c.targetRepo(repoName))
+		if err != nil {
+			return nil, err
+		}
+		dst, err := distributionutil.NewV2RepositoryWithAuth(authConfig, dstNamed, "pull", "push")
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to registry %s: %v", c.Host(), err)
+		}
+		return dst, nil
+	})
+}
+
+// targetRepo returns the repository of external registry, which the image of repoName in ClusterImage is pushed into.
+func (c *Config) targetRepo(repoName string) string {
+	return fmt.Sprintf("%s/%s", c.Repo(), repoName)
+}
+
+// pushImages copies all the tags of repositories in local to the repositories returned by getDst.
+func pushImages(ctx context.Context, local distribution.Namespace, getDst func(repoName string) (distribution.Repository, error)) error {
+	enumerator, ok := local.(distribution.RepositoryEnumerator)
+	if !ok {
+		return fmt.Errorf("failed to list repositories of local registry")
+	}
+	return enumerator.Enumerate(ctx, func(repoName string) error {
+		srcNamed, err := distreference.WithName(repoName)
+		if err != nil {
+			return err
+		}
+		src, err := local.Repository(ctx, srcNamed)
+		if err != nil {
+			return err
+		}
+		dst, err := getDst(repoName)
+		if err != nil {
+			return err
+		}
+
+		tags, err := src.Tags(ctx).All(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list tags of %s: %v", repoName, err)
+		}
+		for _, tag := range tags {
+			logrus.Infof("pushing image %s:%s to %s", repoName, tag, dst.Named())
+			// the local registry caches the platforms needed by ClusterImage only.
+			if err = distributionutil.CopyImage(ctx, src, dst, tag, distributionutil.CopyOptions{SkipMissingPlatforms: true}); err != nil {
+				return fmt.Errorf("failed to push image %s:%s: %v", repoName, tag, err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,166 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/distribution/distribution/v3"
+	"github.com/distribution/distribution/v3/manifest/schema2"
+	distreference "github.com/distribution/distribution/v3/reference"
+
+	"github.com/sealerio/sealer/pkg/image/save"
+)
+
+func newLocalRegistry(ctx context.Context, t *testing.T) distribution.Namespace {
+	dir, err := ioutil.TempDir("", "sealer-registry")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = os.RemoveAll(dir)
+	})
+	reg, err := save.NewLocalRegistry(ctx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return reg
+}
+
+func getRepository(ctx context.Context, t *testing.T, reg distribution.Namespace, name string) distribution.Repository {
+	named, err := distreference.WithName(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := reg.Repository(ctx, named)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repo
+}
+
+// putImage puts an image with a single layer as repo:tag into reg, and returns the digest of its manifest.
+func putImage(ctx context.Context, t *testing.T, reg distribution.Namespace, repoName, tag string) distribution.Descriptor {
+	return putImageWithLayer(ctx, t, reg, repoName, tag, repoName+":"+tag)
+}
+
+// putImageWithLayer is like putImage, but the content of layer is specified.
+func putImageWithLayer(ctx context.Context, t *testing.T, reg distribution.Namespace, repoName, tag, layerContent string) distribution.Descriptor {
+	repo := getRepository(ctx, t, reg, repoName)
+	config, err := repo.Blobs(ctx).Put(ctx, schema2.MediaTypeImageConfig, []byte(`{"architecture":"amd64","os":"linux"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.MediaType = schema2.MediaTypeImageConfig
+	layer, err := repo.Blobs(ctx).Put(ctx, schema2.MediaTypeLayer, []byte(layerContent))
+	if err != nil {
+		t.Fatal(err)
+	}
+	layer.MediaType = schema2.MediaTypeLayer
+	m, err := schema2.FromStruct(schema2.Manifest{Versioned: schema2.SchemaVersion, Config: config, Layers: []distribution.Descriptor{layer}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ms, err := repo.Manifests(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dgst, err := ms.Put(ctx, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc := distribution.Descriptor{MediaType: schema2.MediaTypeManifest, Digest: dgst}
+	if err = repo.Tags(ctx).Tag(ctx, tag, desc); err != nil {
+		t.Fatal(err)
+	}
+	return desc
+}
+
+func TestPushImages(t *testing.T) {
+	ctx := context.Background()
+	local := newLocalRegistry(ctx, t)
+	images := map[string]distribution.Descriptor{
+		"library/nginx:latest": putImage(ctx, t, local, "library/nginx", "latest"),
+		"library/nginx:1.21":   putImage(ctx, t, local, "library/nginx", "1.21"),
+		"fanux/lvscare:latest": putImage(ctx, t, local, "fanux/lvscare", "latest"),
+	}
+
+	remote := newLocalRegistry(ctx, t)
+	c := &Config{Domain: "harbor.example.com", Port: externalDefaultPort, External: true, Project: "sealer"}
+	var pushed []string
+	err := pushImages(ctx, local, func(repoName string) (distribution.Repository, error) {
+		pushed = append(pushed, c.targetRepo(repoName))
+		return getRepository(ctx, t, remote, "sealer/"+repoName), nil
+	})
+	if err != nil {
+		t.Fatalf("pushImages() error = %v", err)
+	}
+
+	sort.Strings(pushed)
+	want := []string{"harbor.example.com/sealer/fanux/lvscare", "harbor.example.com/sealer/library/nginx"}
+	if len(pushed) != len(want) || pushed[0] != want[0] || pushed[1] != want[1] {
+		t.Errorf("pushed repositories = %v, want %v", pushed, want)
+	}
+	for image, desc := range images {
+		named, err := distreference.ParseNamed("docker.io/" + image)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ms, err := getRepository(ctx, t, remote, "sealer/"+distreference.Path(named)).Manifests(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exist, err := ms.Exists(ctx, desc.Digest); err != nil || !exist {
+			t.Errorf("image %s is not pushed: %v", image, err)
+		}
+	}
+}
+
+func TestRewriteSeaHubCommand(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *Config
+		want   string
+	}{
+		{
+			name:   "built-in registry",
+			config: &Config{Domain: SeaHub, Port: defaultPort},
+			want:   "",
+		},
+		{
+			name:   "external registry with project",
+			config: &Config{Domain: "harbor.example.com", Port: externalDefaultPort, External: true, Project: "sealer"},
+			want: "grep -rlF 'sea.hub:5000' /var/lib/sealer/data/my-cluster/rootfs/manifests /var/lib/sealer/data/my-cluster/rootfs/charts 2>/dev/null | " +
+				"xargs -r sed -i 's#sea\\.hub:5000#harbor.example.com/sealer#g'",
+		},
+		{
+			name:   "external registry with port",
+			config: &Config{Domain: "registry.example.com", Port: "5443", External: true},
+			want: "grep -rlF 'sea.hub:5000' /var/lib/sealer/data/my-cluster/rootfs/manifests /var/lib/sealer/data/my-cluster/rootfs/charts 2>/dev/null | " +
+				"xargs -r sed -i 's#sea\\.hub:5000#registry.example.com:5443#g'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.config.RewriteSeaHubCommand("/var/lib/sealer/data/my-cluster/rootfs"); got != tt.want {
+				t.Errorf("RewriteSeaHubCommand() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
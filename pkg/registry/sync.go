@@ -0,0 +1,140 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/distribution/distribution/v3"
+	"github.com/opencontainers/go-digest"
+	"github.com/sirupsen/logrus"
+
+	"github.com/sealerio/sealer/pkg/image/distributionutil"
+)
+
+// SyncReplicas syncs the images among the registries on nodes by the registry API, the primary is the source of
+// truth, which is the recorded registry node IP, or the first node if IP does not run the registry. The tags only on replicas are pushed to them via the registry domain, so
+// they are copied to primary first, then the tags missing on replicas or pointing to other manifests are copied
+// from primary, only the manifests and blobs missing in the destination are transferred. The images are deleted
+// by "sealer registry gc" on every node, so the sync never deletes any tag.
+func (c *Config) SyncReplicas(nodes []net.IP) error {
+	if len(nodes) < 2 {
+		return nil
+	}
+	primary := nodes[0]
+	for _, node := range nodes {
+		if node.Equal(c.IP) {
+			primary = node
+		}
+	}
+
+	var replicas []string
+	for _, node := range nodes {
+		if !node.Equal(primary) {
+			replicas = append(replicas, net.JoinHostPort(node.String(), c.Port))
+		}
+	}
+	if err := syncReplicas(context.Background(), net.JoinHostPort(primary.String(), c.Port), replicas, c.connect); err != nil {
+		return err
+	}
+	logrus.Infof("registry replicas %v are synced with %s", replicas, primary)
+	return nil
+}
+
+// registryConnector connects the registry of host.
+type registryConnector func(host string) (*registryClient, error)
+
+// registryClient lists the repositories of registry and gets the repository of name.
+type registryClient struct {
+	catalog    func(ctx context.Context, entries []string, last string) (int, error)
+	repository func(name string) (distribution.Repository, error)
+}
+
+func syncReplicas(ctx context.Context, primary string, replicas []string, connect registryConnector) error {
+	primaryClient, err := connect(primary)
+	if err != nil {
+		return err
+	}
+	primaryImages, err := listImages(ctx, primary, primaryClient)
+	if err != nil {
+		return err
+	}
+	tags := imageDigests(primaryImages)
+
+	clients := map[string]*registryClient{}
+	for _, replica := range replicas {
+		if clients[replica], err = connect(replica); err != nil {
+			return err
+		}
+		images, err := listImages(ctx, replica, clients[replica])
+		if err != nil {
+			return err
+		}
+		for _, image := range images {
+			if d, ok := tags[image.String()]; ok {
+				if d != image.Digest {
+					logrus.Infof("image %s on %s is overwritten by the one on %s", image, replica, primary)
+				}
+				continue
+			}
+			if err = copyRegistryImage(ctx, clients[replica], primaryClient, image); err != nil {
+				return fmt.Errorf("failed to copy image %s from %s to %s: %v", image, replica, primary, err)
+			}
+			tags[image.String()] = image.Digest
+			primaryImages = append(primaryImages, image)
+		}
+	}
+
+	for _, replica := range replicas {
+		images, err := listImages(ctx, replica, clients[replica])
+		if err != nil {
+			return err
+		}
+		replicaTags := imageDigests(images)
+		for _, image := range primaryImages {
+			if d, ok := replicaTags[image.String()]; ok && d == image.Digest {
+				continue
+			}
+			if err = copyRegistryImage(ctx, primaryClient, clients[replica], image); err != nil {
+				return fmt.Errorf("failed to copy image %s from %s to %s: %v", image, primary, replica, err)
+			}
+		}
+	}
+	return nil
+}
+
+// imageDigests maps the tags of images to the manifest digests.
+func imageDigests(images []Image) map[string]digest.Digest {
+	res := map[string]digest.Digest{}
+	for _, image := range images {
+		res[image.String()] = image.Digest
+	}
+	return res
+}
+
+func copyRegistryImage(ctx context.Context, src, dst *registryClient, image Image) error {
+	srcRepo, err := src.repository(image.Repo)
+	if err != nil {
+		return err
+	}
+	dstRepo, err := dst.repository(image.Repo)
+	if err != nil {
+		return err
+	}
+	// the registries are fed by ClusterImages, which cache the platforms needed only.
+	return distributionutil.CopyImage(ctx, srcRepo, dstRepo, image.Tag, distributionutil.CopyOptions{SkipMissingPlatforms: true})
+}
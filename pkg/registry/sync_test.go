@@ -0,0 +1,186 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/distribution/distribution/v3"
+	distreference "github.com/distribution/distribution/v3/reference"
+	"github.com/opencontainers/go-digest"
+)
+
+// taggingManifests tags the manifest put with distribution.WithTag like the registry API, which the local
+// registry does not.
+type taggingManifests struct {
+	distribution.ManifestService
+	tags distribution.TagService
+	// puts counts the manifests put.
+	puts *int
+}
+
+func (m *taggingManifests) Put(ctx context.Context, manifest distribution.Manifest, options ...distribution.ManifestServiceOption) (digest.Digest, error) {
+	dgst, err := m.ManifestService.Put(ctx, manifest, options...)
+	if err != nil {
+		return "", err
+	}
+	*m.puts++
+	for _, option := range options {
+		if tag, ok := option.(distribution.WithTagOption); ok {
+			mediaType, _, _ := manifest.Payload()
+			if err = m.tags.Tag(ctx, tag.Tag, distribution.Descriptor{MediaType: mediaType, Digest: dgst}); err != nil {
+				return "", err
+			}
+		}
+	}
+	return dgst, nil
+}
+
+type taggingRepository struct {
+	distribution.Repository
+	puts *int
+}
+
+func (r *taggingRepository) Manifests(ctx context.Context, options ...distribution.ManifestServiceOption) (distribution.ManifestService, error) {
+	ms, err := r.Repository.Manifests(ctx, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &taggingManifests{ManifestService: ms, tags: r.Repository.Tags(ctx), puts: r.puts}, nil
+}
+
+// localRegistries serves the registries of hosts by local registries, and counts the manifests put into them.
+type localRegistries struct {
+	registries map[string]distribution.Namespace
+	puts       map[string]*int
+}
+
+func newLocalRegistries(ctx context.Context, t *testing.T, hosts ...string) *localRegistries {
+	l := &localRegistries{registries: map[string]distribution.Namespace{}, puts: map[string]*int{}}
+	for _, host := range hosts {
+		l.registries[host] = newLocalRegistry(ctx, t)
+		l.puts[host] = new(int)
+	}
+	return l
+}
+
+func (l *localRegistries) connect(host string) (*registryClient, error) {
+	reg, ok := l.registries[host]
+	if !ok {
+		return nil, fmt.Errorf("registry %s is unreachable", host)
+	}
+	return &registryClient{
+		catalog: reg.Repositories,
+		repository: func(name string) (distribution.Repository, error) {
+			named, err := distreference.WithName(name)
+			if err != nil {
+				return nil, err
+			}
+			repo, err := reg.Repository(context.Background(), named)
+			if err != nil {
+				return nil, err
+			}
+			return &taggingRepository{Repository: repo, puts: l.puts[host]}, nil
+		},
+	}, nil
+}
+
+func (l *localRegistries) tags(ctx context.Context, t *testing.T, host string) map[string]digest.Digest {
+	client, err := l.connect(host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	images, err := listImages(ctx, host, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return imageDigests(images)
+}
+
+func TestSyncReplicas(t *testing.T) {
+	ctx := context.Background()
+	primary, replica1, replica2 := "192.168.0.2:5000", "192.168.0.3:5000", "192.168.0.4:5000"
+	regs := newLocalRegistries(ctx, t, primary, replica1, replica2)
+
+	nginx := putImage(ctx, t, regs.registries[primary], "library/nginx", "1.21")
+	// app:v1 of primary overwrites the one on replica1.
+	app := putImageWithLayer(ctx, t, regs.registries[primary], "app", "v1", "app v1 of primary")
+	putImageWithLayer(ctx, t, regs.registries[replica1], "app", "v1", "app v1 of replica1")
+	// the image pushed to replica2 is served by all nodes.
+	busybox := putImage(ctx, t, regs.registries[replica2], "library/busybox", "latest")
+	// replica1 has nginx already.
+	putImage(ctx, t, regs.registries[replica1], "library/nginx", "1.21")
+
+	if err := syncReplicas(ctx, primary, []string{replica1, replica2}, regs.connect); err != nil {
+		t.Fatalf("syncReplicas() error = %v", err)
+	}
+
+	want := map[string]digest.Digest{
+		"library/nginx:1.21":     nginx.Digest,
+		"app:v1":                 app.Digest,
+		"library/busybox:latest": busybox.Digest,
+	}
+	for _, host := range []string{primary, replica1, replica2} {
+		if got := regs.tags(ctx, t, host); !reflect.DeepEqual(got, want) {
+			t.Errorf("images of %s = %v, want %v", host, got, want)
+		}
+	}
+	// only app:v1 and busybox are copied to replica1.
+	if got := *regs.puts[replica1]; got != 2 {
+		t.Errorf("%d manifests are put into %s, want 2", got, replica1)
+	}
+}
+
+func TestSyncReplicasIdempotent(t *testing.T) {
+	ctx := context.Background()
+	primary, replica := "192.168.0.2:5000", "192.168.0.3:5000"
+	regs := newLocalRegistries(ctx, t, primary, replica)
+	putImage(ctx, t, regs.registries[primary], "library/nginx", "1.21")
+	putImage(ctx, t, regs.registries[replica], "library/nginx", "1.21")
+
+	if err := syncReplicas(ctx, primary, []string{replica}, regs.connect); err != nil {
+		t.Fatalf("syncReplicas() error = %v", err)
+	}
+	var hosts []string
+	for host, puts := range regs.puts {
+		if *puts != 0 {
+			hosts = append(hosts, host)
+		}
+	}
+	sort.Strings(hosts)
+	if len(hosts) != 0 {
+		t.Errorf("manifests are copied into %v, want none", hosts)
+	}
+}
+
+func TestSyncReplicasUnreachable(t *testing.T) {
+	ctx := context.Background()
+	regs := newLocalRegistries(ctx, t, "192.168.0.2:5000")
+	if err := syncReplicas(ctx, "192.168.0.2:5000", []string{"192.168.0.3:5000"}, regs.connect); err == nil {
+		t.Errorf("syncReplicas() with unreachable replica succeeded, want error")
+	}
+}
+
+func TestSyncReplicasSingleNode(t *testing.T) {
+	c := &Config{IP: net.ParseIP("192.168.0.2"), Port: defaultPort}
+	if err := c.SyncReplicas([]net.IP{net.ParseIP("192.168.0.2")}); err != nil {
+		t.Errorf("SyncReplicas() error = %v", err)
+	}
+}
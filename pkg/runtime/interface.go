@@ -16,6 +16,9 @@ package runtime
 
 import (
 	"net"
+	"time"
+
+	v2 "github.com/sealerio/sealer/types/api/v2"
 )
 
 type Interface interface {
@@ -29,10 +32,24 @@ type Interface interface {
 	JoinMasters(newMastersIPList []net.IP) error
 	// JoinNodes exec joining phase for cluster, add worker/<none> role for these nodes. net.IP is the worker/<none> node IP array.
 	JoinNodes(newNodesIPList []net.IP) error
+	// JoinEtcds exec joining phase for external etcd cluster, add etcd members on these hosts. net.IP is the etcd host IP array.
+	JoinEtcds(newEtcdsIPList []net.IP) error
 	// DeleteMasters exec deleting phase for deleting cluster master role nodes. net.IP is the master node IP array.
 	DeleteMasters(mastersIPList []net.IP) error
 	// DeleteNodes exec deleting phase for deleting worker/<none> master role nodes. net.IP is the worker/<none> node IP array.
 	DeleteNodes(nodesIPList []net.IP) error
+	// DeleteEtcds exec deleting phase for removing members of external etcd cluster. net.IP is the etcd host IP array.
+	DeleteEtcds(etcdsIPList []net.IP) error
+	// ForceDeleteMasters deletes the masters which may be unreachable, the etcd members and node objects of unreachable masters are removed via an operational master.
+	ForceDeleteMasters(mastersIPList []net.IP) error
+	// ForceDeleteNodes deletes the worker/<none> nodes which may be unreachable, the node objects of unreachable nodes are removed via an operational master.
+	ForceDeleteNodes(nodesIPList []net.IP) error
+	// Stop stops the cluster gracefully, the nodes are cordoned and the pods, kubelet and container runtime are stopped on workers then masters. The etcd is snapshot before all if snapshotEtcd.
+	Stop(snapshotEtcd bool) error
+	// Start starts the cluster stopped by Stop in reverse order, and waits at most timeout for the etcd, apiserver and nodes to be healthy.
+	Start(timeout time.Duration) error
+	// UpdateKubeletExtraArgs applies the kubelet extra args declared on the hosts, which have joined the cluster, and restarts their kubelet. The args declared in previous but removed are removed from kubelet.
+	UpdateKubeletExtraArgs(previous *v2.Cluster, hosts []net.IP) error
 	// GetClusterMetadata read the rootfs/Metadata file to get some install info for cluster.
 	GetClusterMetadata() (*Metadata, error)
 }
@@ -0,0 +1,152 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+	certutil "k8s.io/client-go/util/cert"
+
+	"github.com/sealerio/sealer/pkg/clustercert/cert"
+	osi "github.com/sealerio/sealer/utils/os"
+)
+
+// CertExpiration is the expiration of a cert managed by sealer.
+type CertExpiration struct {
+	Name     string
+	NotAfter time.Time
+	IsCA     bool
+}
+
+// registryCACert returns the registry CA to trust, the registry cert of clusters created before the registry CA
+// was introduced is self-signed, so it is the CA itself.
+func (k *Runtime) registryCACert() string {
+	caCert := cert.PathForCert(k.getCertsDir(), RegistryCAName)
+	if osi.IsFileExist(caCert) {
+		return caCert
+	}
+	return cert.PathForCert(k.getCertsDir(), k.RegConfig.Domain)
+}
+
+// CheckCertsExpiration prints the expiration of kubernetes certs on an operational master.
+func (k *Runtime) CheckCertsExpiration() error {
+	master, err := k.getOperationalMaster()
+	if err != nil {
+		return err
+	}
+	ssh, err := k.getHostSSHClient(master)
+	if err != nil {
+		return fmt.Errorf("failed to get ssh client of master %s: %v", master, err)
+	}
+	return ssh.CmdAsync(master, RemoteCheckCerts)
+}
+
+// RegistryCertsExpiration returns the expiration of the built-in registry cert and its CA.
+func (k *Runtime) RegistryCertsExpiration() ([]CertExpiration, error) {
+	if k.RegConfig.External {
+		return nil, nil
+	}
+
+	var expirations []CertExpiration
+	for _, name := range []string{RegistryCAName, k.RegConfig.Domain} {
+		certPath := cert.PathForCert(k.getCertsDir(), name)
+		if !osi.IsFileExist(certPath) {
+			continue
+		}
+		certs, err := certutil.CertsFromFile(certPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cert %s: %v", certPath, err)
+		}
+		expirations = append(expirations, CertExpiration{
+			Name:     name,
+			NotAfter: certs[0].NotAfter,
+			IsCA:     certs[0].IsCA,
+		})
+	}
+	return expirations, nil
+}
+
+// RenewRegistryCert reissues the built-in registry cert, and the registry CA too if rotateCA is set,
+// then distributes the CA to the docker and containerd trust dirs of all nodes, and restarts the registry
+// with the new cert.
+func (k *Runtime) RenewRegistryCert(rotateCA bool) error {
+	if k.RegConfig.External {
+		return fmt.Errorf("cert of external registry %s is not managed by sealer", k.RegConfig.Host())
+	}
+
+	if rotateCA {
+		for _, path := range []string{cert.PathForCert(k.getCertsDir(), RegistryCAName), cert.PathForKey(k.getCertsDir(), RegistryCAName)} {
+			if err := os.RemoveAll(path); err != nil {
+				return fmt.Errorf("failed to remove registry CA %s: %v", path, err)
+			}
+		}
+	}
+	if err := GenerateRegistryCert(k.getCertsDir(), k.RegConfig.Domain, k.RegConfig.CertYears); err != nil {
+		return err
+	}
+
+	if err := k.SendRegistryCert(k.cluster.GetAllIPList()); err != nil {
+		return err
+	}
+	eg, _ := errgroup.WithContext(context.Background())
+	for _, node := range k.cluster.GetAllIPList() {
+		node := node
+		eg.Go(func() error {
+			return k.refreshRegistryTrust(node)
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return err
+	}
+
+	for _, node := range k.registryNodes() {
+		ssh, err := k.getHostSSHClient(node)
+		if err != nil {
+			return fmt.Errorf("failed to get registry ssh client: %v", err)
+		}
+		if err = ssh.CmdAsync(node, fmt.Sprintf(RestartRegistryCommand, RegistryName)); err != nil {
+			return fmt.Errorf("failed to restart registry on %s: %v", node, err)
+		}
+	}
+	logrus.Infof("registry cert is renewed, it expires in %d years", k.RegConfig.CertYears)
+	return nil
+}
+
+// refreshRegistryTrust removes the self-signed registry cert trusted before the registry CA, and rewrites
+// the containerd registry hosts to trust the new CA.
+func (k *Runtime) refreshRegistryTrust(node net.IP) error {
+	ssh, err := k.getHostSSHClient(node)
+	if err != nil {
+		return fmt.Errorf("failed to get ssh client of %s: %v", node, err)
+	}
+	cmds := []string{
+		fmt.Sprintf("rm -f %s/%s:%s/%s.crt", DockerCertDir, k.RegConfig.Domain, k.RegConfig.Port, k.RegConfig.Domain),
+		fmt.Sprintf("rm -f %s/%s:%s/%s.crt", DockerCertDir, SeaHub, k.RegConfig.Port, k.RegConfig.Domain),
+	}
+	cri, err := k.getCRIFromShell(node)
+	if err != nil {
+		return err
+	}
+	if cri == CRIContainerd {
+		cmds = append(cmds, k.containerdRegistryCommands()...)
+	}
+	return ssh.CmdAsync(node, cmds...)
+}
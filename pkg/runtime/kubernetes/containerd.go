@@ -0,0 +1,177 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+
+	"github.com/sealerio/sealer/pkg/registry"
+)
+
+const (
+	CRIDocker     = "docker"
+	CRIContainerd = "containerd"
+
+	ContainerdConfig   = "/etc/containerd/config.toml"
+	ContainerdCertsDir = "/etc/containerd/certs.d"
+	// containerdDefaultHostDir configures the mirrors of all registries.
+	containerdDefaultHostDir = "_default"
+
+	// DetectCRICommand prints the CRI of node, docker also runs containerd, so docker socket is checked first.
+	DetectCRICommand = "if [ -S /var/run/docker.sock ]; then echo " + CRIDocker +
+		"; elif [ -S " + DefaultContainerdCRISocket + " ]; then echo " + CRIContainerd + "; fi"
+	// containerdRegistryTable is the registry table of CRI plugin in config of version 2.
+	containerdRegistryTable = `[plugins."io.containerd.grpc.v1.cri".registry]`
+	// ContainerdEnableHostsCommand makes containerd read registry hosts from /etc/containerd/certs.d,
+	// the empty config_path is filled, or config_path is added to the registry table if it is absent,
+	// the registry table is appended if the config of version 2 does not have it.
+	ContainerdEnableHostsCommand = `if [ -f ` + ContainerdConfig + ` ]; then ` +
+		`if grep -q 'config_path = ""' ` + ContainerdConfig + `; then ` +
+		`sed -i 's#config_path = ""#config_path = "` + ContainerdCertsDir + `"#' ` + ContainerdConfig + ` && systemctl restart containerd; ` +
+		`elif ! grep -q 'config_path' ` + ContainerdConfig + `; then ` +
+		`if grep -qF '` + containerdRegistryTable + `' ` + ContainerdConfig + `; then ` +
+		`sed -i '/^\s*\[plugins\."io\.containerd\.grpc\.v1\.cri"\.registry\]/a config_path = "` + ContainerdCertsDir + `"' ` + ContainerdConfig + ` && systemctl restart containerd; ` +
+		`elif grep -q '^version = 2' ` + ContainerdConfig + `; then ` +
+		`printf '\n` + containerdRegistryTable + `\n  config_path = "` + ContainerdCertsDir + `"\n' >> ` + ContainerdConfig + ` && systemctl restart containerd; ` +
+		`fi; fi; fi`
+	// DockerRewriteMirrorCommand points the mirror of built-in registry in daemon.json of docker to the external registry,
+	// the images referring to the built-in registry are pulled from the external one then.
+	DockerRewriteMirrorCommand = "if grep -qF '%[1]s' " + DockerDaemonConfig + " 2>/dev/null; then " +
+		"sed -i 's#http://%[2]s#https://%[3]s#g; s#%[2]s#%[3]s#g' " + DockerDaemonConfig + " && systemctl restart docker; fi"
+	DockerDaemonConfig = "/etc/docker/daemon.json"
+	// RemoteRemoveContainerdHosts removes the hosts dir of registry written by sealer.
+	RemoteRemoveContainerdHosts = "rm -rf " + ContainerdCertsDir + "/%s*"
+	// WriteContainerdHostsCommand writes hosts.toml of registry, and copies the registry certs sent to docker cert dir.
+	WriteContainerdHostsCommand = "mkdir -p %[1]s && (cp -f " + DockerCertDir + "/%[2]s/*.crt %[1]s 2>/dev/null || true) && echo '%[3]s' > %[1]s/hosts.toml"
+)
+
+// containerdHost is a host entry of containerd hosts.toml.
+type containerdHost struct {
+	URL          string
+	Capabilities []string
+	CA           string
+	// Auth is the base64 encoded "username:password", it is sent as basic authorization header.
+	Auth         string
+	OverridePath bool
+}
+
+// containerdHostsToml generates hosts.toml, server is the upstream registry, which is used if all hosts fail,
+// containerd falls back to the registry of image itself if server is empty.
+func containerdHostsToml(server string, hosts ...containerdHost) string {
+	var b strings.Builder
+	if server != "" {
+		fmt.Fprintf(&b, "server = \"%s\"\n", server)
+	}
+	for _, h := range hosts {
+		fmt.Fprintf(&b, "\n[host.\"%s\"]\n", h.URL)
+		fmt.Fprintf(&b, "  capabilities = [\"%s\"]\n", strings.Join(h.Capabilities, "\", \""))
+		if h.CA != "" {
+			fmt.Fprintf(&b, "  ca = \"%s\"\n", h.CA)
+		}
+		if h.OverridePath {
+			b.WriteString("  override_path = true\n")
+		}
+		if h.Auth != "" {
+			fmt.Fprintf(&b, "  [host.\"%s\".header]\n", h.URL)
+			fmt.Fprintf(&b, "    Authorization = \"Basic %s\"\n", h.Auth)
+		}
+	}
+	return b.String()
+}
+
+// getCRIFromShell returns the CRI of node, docker is returned if it could not be detected.
+func (k *Runtime) getCRIFromShell(node net.IP) (string, error) {
+	cri, err := k.CmdToString(node, DetectCRICommand, "")
+	if err != nil {
+		return "", fmt.Errorf("failed to detect CRI of node %s: %v", node, err)
+	}
+	if strings.TrimSpace(cri) == CRIContainerd {
+		return CRIContainerd, nil
+	}
+	return CRIDocker, nil
+}
+
+// registryConfigCommands returns the commands to let the CRI of node access the registry,
+// docker logs in the registry, while containerd writes hosts.toml under /etc/containerd/certs.d,
+// with the registry CA, credentials and the mirror entry redirecting all registries to the registry.
+// The images of sea.hub are pulled from the external registry by the mirror, docker mirrors sea.hub in daemon.json.
+func (k *Runtime) registryConfigCommands(node net.IP) ([]string, error) {
+	cri, err := k.getCRIFromShell(node)
+	if err != nil {
+		return nil, err
+	}
+	if cri == CRIContainerd {
+		return k.containerdRegistryCommands(), nil
+	}
+	var cmds []string
+	if k.RegConfig.External {
+		cmds = append(cmds, fmt.Sprintf(DockerRewriteMirrorCommand, registry.SeaHubRepo,
+			strings.ReplaceAll(registry.SeaHubRepo, ".", `\.`), k.RegConfig.Host()))
+	}
+	if k.RegConfig.Username != "" && k.RegConfig.Password != "" {
+		cmds = append(cmds, k.GenLoginCommand())
+	}
+	return cmds, nil
+}
+
+func (k *Runtime) containerdRegistryCommands() []string {
+	var (
+		reg   = k.RegConfig
+		auth  string
+		hosts = []string{reg.Host()}
+	)
+	if reg.Username != "" && reg.Password != "" {
+		auth = base64.StdEncoding.EncodeToString([]byte(reg.Username + ":" + reg.Password))
+	}
+	if !reg.External && reg.Domain != SeaHub {
+		hosts = append(hosts, fmt.Sprintf("%s:%s", SeaHub, reg.Port))
+	}
+	caPath := func(host string) string {
+		if reg.External && reg.CA == "" {
+			return ""
+		}
+		return filepath.Join(ContainerdCertsDir, host, "ca.crt")
+	}
+
+	cmds := []string{ContainerdEnableHostsCommand}
+	for _, host := range hosts {
+		toml := containerdHostsToml("https://"+host, containerdHost{
+			URL:          "https://" + host,
+			Capabilities: []string{"pull", "resolve", "push"},
+			CA:           caPath(host),
+			Auth:         auth,
+		})
+		cmds = append(cmds, fmt.Sprintf(WriteContainerdHostsCommand, filepath.Join(ContainerdCertsDir, host), host, toml))
+	}
+
+	// images are pushed under project of external registry, so the path of mirror is overridden.
+	mirror := containerdHost{
+		URL:          "https://" + reg.Host(),
+		Capabilities: []string{"pull", "resolve"},
+		CA:           caPath(reg.Host()),
+		Auth:         auth,
+	}
+	if reg.External && reg.Project != "" {
+		mirror.URL = fmt.Sprintf("https://%s/v2/%s", reg.Host(), reg.Project)
+		mirror.OverridePath = true
+	}
+	cmds = append(cmds, fmt.Sprintf(WriteContainerdHostsCommand,
+		filepath.Join(ContainerdCertsDir, containerdDefaultHostDir), containerdDefaultHostDir, containerdHostsToml("", mirror)))
+	return cmds
+}
@@ -0,0 +1,419 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/sealerio/sealer/pkg/clustercert"
+	"github.com/sealerio/sealer/pkg/runtime/kubernetes/kubeadm/v1beta3"
+	utilsnet "github.com/sealerio/sealer/utils/net"
+	osi "github.com/sealerio/sealer/utils/os"
+	"github.com/sealerio/sealer/utils/platform"
+)
+
+const (
+	EtcdClientPort      = "2379"
+	EtcdPeerPort        = "2380"
+	EtcdDataDir         = "/var/lib/etcd"
+	EtcdServiceFile     = "/etc/systemd/system/etcd.service"
+	EtcdClusterToken    = "sealer-etcd-cluster"
+	EtcdStateNew        = "new"
+	EtcdStateExisting   = "existing"
+	RemoteEtcdBinDir    = "/usr/bin"
+	RemoteEtcdCertMkdir = "mkdir -p " + clustercert.KubeDefaultCertEtcdPath
+	RemoteWriteEtcdUnit = `echo '%s' > ` + EtcdServiceFile
+	RemoteStartEtcd     = `chmod +x /usr/bin/etcd /usr/bin/etcdctl && systemctl daemon-reload && systemctl enable etcd && systemctl restart etcd`
+	RemoteCleanEtcd     = `systemctl stop etcd; systemctl disable etcd; rm -rf ` + EtcdServiceFile + ` ` + EtcdDataDir + ` ` + clustercert.KubeDefaultCertEtcdPath + ` /usr/bin/etcd /usr/bin/etcdctl && systemctl daemon-reload`
+	RemoteEtcdctl       = `ETCDCTL_API=3 etcdctl --endpoints=https://127.0.0.1:2379 --cacert=%[1]s/ca.crt --cert=%[1]s/healthcheck-client.crt --key=%[1]s/healthcheck-client.key`
+	RemoteEtcdHealth    = RemoteEtcdctl + ` endpoint health`
+	RemoteEtcdMemberAdd = RemoteEtcdctl + ` member add %s --peer-urls=%s`
+	// RemoteEtcdMemberID prints the hex ID of the member with peer URL, the output of member list is like
+	// "8e9e05c52164694d, started, etcd1, https://192.168.0.2:2380, https://192.168.0.2:2379, false".
+	RemoteEtcdMemberID     = RemoteEtcdctl + ` member list | grep -F '%s,' | cut -d, -f1`
+	RemoteEtcdMemberRemove = RemoteEtcdctl + ` member remove %s`
+	// RemoteSetEtcdServers points apiserver to the external etcd members, kubelet restarts apiserver on the change.
+	RemoteSetEtcdServers   = `sed -i "s#--etcd-servers=.*#--etcd-servers=%s#" /etc/kubernetes/manifests/kube-apiserver.yaml`
+	RemoteGetKubeadmConfig = "kubectl -n kube-system get cm kubeadm-config -o json"
+	// RemoteReplaceKubeadmConfig replaces the kubeadm-config ConfigMap by the base64 encoded json, which is
+	// decoded on host to avoid quoting the yaml in it.
+	RemoteReplaceKubeadmConfig = "echo %s | base64 -d | kubectl replace -f -"
+	kubeadmClusterConfigKey    = "ClusterConfiguration"
+)
+
+const etcdUnit = `[Unit]
+Description=etcd
+Documentation=https://github.com/etcd-io/etcd
+After=network.target
+
+[Service]
+Type=notify
+ExecStart=/usr/bin/etcd %s
+Restart=always
+RestartSec=5s
+LimitNOFILE=65536
+
+[Install]
+WantedBy=multi-user.target`
+
+// isExternalEtcd returns true if the cluster has dedicated etcd hosts, otherwise etcd is stacked on masters.
+func (k *Runtime) isExternalEtcd() bool {
+	return len(k.cluster.GetEtcdIPList()) != 0
+}
+
+// handleExternalEtcd points kubeadm to the external etcd cluster, the client cert of apiserver is signed by
+// the etcd CA generated with the cluster certs.
+func (k *Runtime) handleExternalEtcd() {
+	if !k.isExternalEtcd() {
+		return
+	}
+	k.Etcd.Local = nil
+	k.Etcd.External = &v1beta3.ExternalEtcd{
+		Endpoints: getEtcdEndpoints(k.cluster.GetEtcdIPList()),
+		CAFile:    filepath.Join(clustercert.KubeDefaultCertEtcdPath, "ca.crt"),
+		CertFile:  filepath.Join(clustercert.KubeDefaultCertPath, "apiserver-etcd-client.crt"),
+		KeyFile:   filepath.Join(clustercert.KubeDefaultCertPath, "apiserver-etcd-client.key"),
+	}
+}
+
+// InitEtcd bootstraps the external etcd cluster on the etcd hosts before master0 is initialized.
+func (k *Runtime) InitEtcd() error {
+	etcds := k.cluster.GetEtcdIPList()
+	if len(etcds) == 0 {
+		return nil
+	}
+	logrus.Infof("start to init external etcd cluster on %s", etcds)
+	names, err := k.getEtcdMemberNames(etcds)
+	if err != nil {
+		return err
+	}
+	initialCluster := getEtcdInitialCluster(etcds, names)
+
+	eg, _ := errgroup.WithContext(context.Background())
+	for _, etcd := range etcds {
+		etcd := etcd
+		eg.Go(func() error {
+			// all members of the new cluster have to be started together to reach the quorum.
+			return k.installEtcdMember(etcd, names[etcd.String()], initialCluster, EtcdStateNew)
+		})
+	}
+	if err = eg.Wait(); err != nil {
+		return err
+	}
+	return k.checkEtcdHealth(etcds[0])
+}
+
+// joinEtcds adds the members to the external etcd cluster one by one, each of them is added by etcdctl first, and
+// then started to join the existing members, so the quorum is never lost during the scaling.
+func (k *Runtime) joinEtcds(etcds []net.IP) error {
+	if len(etcds) == 0 {
+		return nil
+	}
+	members := k.getCurrentEtcds(etcds)
+	if len(members) == 0 {
+		return fmt.Errorf("failed to join etcd %s: no running etcd member in cluster", etcds)
+	}
+	if err := k.checkEtcdHealth(members[0]); err != nil {
+		return err
+	}
+	names, err := k.getEtcdMemberNames(k.cluster.GetEtcdIPList())
+	if err != nil {
+		return err
+	}
+	for _, etcd := range etcds {
+		logrus.Infof("Start to join %s as etcd member", etcd)
+		name := names[etcd.String()]
+		ssh, err := k.getHostSSHClient(members[0])
+		if err != nil {
+			return fmt.Errorf("failed to get ssh client of etcd(%s): %v", members[0], err)
+		}
+		if err = ssh.CmdAsync(members[0], fmt.Sprintf(RemoteEtcdMemberAdd, clustercert.KubeDefaultCertEtcdPath, name, getEtcdPeerURL(etcd))); err != nil {
+			return fmt.Errorf("failed to add etcd member %s: %v", etcd, err)
+		}
+		members = append(members, etcd)
+		if err = k.installEtcdMember(etcd, name, getEtcdInitialCluster(members, names), EtcdStateExisting); err != nil {
+			return err
+		}
+		if err = k.checkEtcdHealth(etcd); err != nil {
+			return err
+		}
+		logrus.Infof("Succeeded in joining %s as etcd member", etcd)
+	}
+	return k.setEtcdServersOnMasters(k.cluster.GetMasterIPList(), members)
+}
+
+// deleteEtcds removes the members from the external etcd cluster one by one, apiserver is pointed to the remaining
+// members before they are removed, and the last member is never removed.
+func (k *Runtime) deleteEtcds(etcds []net.IP) error {
+	if len(etcds) == 0 {
+		return nil
+	}
+	var remaining []net.IP
+	for _, m := range k.cluster.GetEtcdIPList() {
+		if utilsnet.NotInIPList(m, etcds) {
+			remaining = append(remaining, m)
+		}
+	}
+	if len(remaining) == 0 {
+		return fmt.Errorf("failed to delete etcd %s: at least one etcd member is required", etcds)
+	}
+	if err := k.checkEtcdHealth(remaining[0]); err != nil {
+		return err
+	}
+	if err := k.setEtcdServersOnMasters(k.cluster.GetMasterIPList(), remaining); err != nil {
+		return err
+	}
+
+	ssh, err := k.getHostSSHClient(remaining[0])
+	if err != nil {
+		return fmt.Errorf("failed to get ssh client of etcd(%s): %v", remaining[0], err)
+	}
+	for _, etcd := range etcds {
+		logrus.Infof("Start to delete etcd member %s", etcd)
+		id, err := ssh.CmdToString(remaining[0], fmt.Sprintf(RemoteEtcdMemberID, clustercert.KubeDefaultCertEtcdPath, getEtcdPeerURL(etcd)), "")
+		if err != nil {
+			return fmt.Errorf("failed to get member ID of etcd %s: %v", etcd, err)
+		}
+		if id = strings.TrimSpace(id); id != "" {
+			if err = ssh.CmdAsync(remaining[0], fmt.Sprintf(RemoteEtcdMemberRemove, clustercert.KubeDefaultCertEtcdPath, id)); err != nil {
+				return fmt.Errorf("failed to remove etcd member %s: %v", etcd, err)
+			}
+		} else {
+			logrus.Warnf("etcd %s is not a member of cluster, skip removing it", etcd)
+		}
+		if err = k.resetEtcd(etcd); err != nil {
+			logrus.Errorf("failed to clean etcd %s: %v", etcd, err)
+		}
+		if err = k.checkEtcdHealth(remaining[0]); err != nil {
+			return err
+		}
+		logrus.Infof("Succeeded in deleting etcd member %s", etcd)
+	}
+	return nil
+}
+
+// installEtcdMember sends the etcd binaries and certs to etcd host, and starts etcd as a systemd service.
+func (k *Runtime) installEtcdMember(etcd net.IP, name, initialCluster, state string) error {
+	ssh, err := k.getHostSSHClient(etcd)
+	if err != nil {
+		return fmt.Errorf("failed to get ssh client of etcd(%s): %v", etcd, err)
+	}
+	plat, err := ssh.Platform(etcd)
+	if err != nil {
+		return fmt.Errorf("failed to get platform of etcd(%s): %v", etcd, err)
+	}
+	binDir := filepath.Join(platform.GetMountClusterImagePlatformDir(k.cluster.Name, plat), "bin")
+	for _, bin := range []string{"etcd", "etcdctl"} {
+		if !osi.IsFileExist(filepath.Join(binDir, bin)) {
+			return fmt.Errorf("%s is required in the bin dir of ClusterImage for external etcd", bin)
+		}
+		if err = ssh.Copy(etcd, filepath.Join(binDir, bin), filepath.Join(RemoteEtcdBinDir, bin)); err != nil {
+			return fmt.Errorf("failed to copy %s to etcd(%s): %v", bin, etcd, err)
+		}
+	}
+
+	hostCertPath := filepath.Join(k.getBasePath(), "etcd", etcd.String())
+	if err = clustercert.GenerateEtcdHostCerts(k.getEtcdCertPath(), hostCertPath, name, etcd); err != nil {
+		return fmt.Errorf("failed to generate certs of etcd(%s): %v", etcd, err)
+	}
+	if err = ssh.CmdAsync(etcd, RemoteEtcdCertMkdir); err != nil {
+		return err
+	}
+	if err = ssh.Copy(etcd, filepath.Join(k.getEtcdCertPath(), "ca.crt"), filepath.Join(clustercert.KubeDefaultCertEtcdPath, "ca.crt")); err != nil {
+		return fmt.Errorf("failed to copy etcd CA to etcd(%s): %v", etcd, err)
+	}
+	if err = ssh.Copy(etcd, hostCertPath, clustercert.KubeDefaultCertEtcdPath); err != nil {
+		return fmt.Errorf("failed to copy certs to etcd(%s): %v", etcd, err)
+	}
+
+	unit := fmt.Sprintf(etcdUnit, strings.Join(getEtcdArgs(etcd, name, initialCluster, state), " "))
+	if err = ssh.CmdAsync(etcd, fmt.Sprintf(RemoteWriteEtcdUnit, unit), RemoteStartEtcd); err != nil {
+		return fmt.Errorf("failed to start etcd on %s: %v", etcd, err)
+	}
+	return nil
+}
+
+func (k *Runtime) resetEtcds(etcds []net.IP) {
+	for _, etcd := range etcds {
+		if err := k.resetEtcd(etcd); err != nil {
+			logrus.Errorf("failed to delete etcd(%s): %v", etcd, err)
+		}
+	}
+}
+
+func (k *Runtime) resetEtcd(etcd net.IP) error {
+	ssh, err := k.getHostSSHClient(etcd)
+	if err != nil {
+		return fmt.Errorf("failed to get ssh client of etcd(%s): %v", etcd, err)
+	}
+	return ssh.CmdAsync(etcd, RemoteCleanEtcd)
+}
+
+func (k *Runtime) checkEtcdHealth(etcd net.IP) error {
+	ssh, err := k.getHostSSHClient(etcd)
+	if err != nil {
+		return fmt.Errorf("failed to get ssh client of etcd(%s): %v", etcd, err)
+	}
+	if err = ssh.CmdAsync(etcd, fmt.Sprintf(RemoteEtcdHealth, clustercert.KubeDefaultCertEtcdPath)); err != nil {
+		return fmt.Errorf("etcd %s is not healthy: %v", etcd, err)
+	}
+	return nil
+}
+
+// setEtcdServersOnMasters points apiserver on masters to the external etcd members.
+func (k *Runtime) setEtcdServersOnMasters(masters, etcds []net.IP) error {
+	cmd := fmt.Sprintf(RemoteSetEtcdServers, getEtcdEndpointsWithHTTPSPrefix(etcds))
+	for _, master := range masters {
+		ssh, err := k.getHostSSHClient(master)
+		if err != nil {
+			return fmt.Errorf("failed to get ssh client of master(%s): %v", master, err)
+		}
+		if err = ssh.CmdAsync(master, cmd); err != nil {
+			return fmt.Errorf("failed to set etcd servers of apiserver on %s: %v", master, err)
+		}
+	}
+	return k.setEtcdEndpointsInKubeadmConfig(etcds)
+}
+
+// setEtcdEndpointsInKubeadmConfig updates the external etcd endpoints of ClusterConfiguration in the kubeadm-config
+// ConfigMap, which is read by kubeadm on join and upgrade.
+func (k *Runtime) setEtcdEndpointsInKubeadmConfig(etcds []net.IP) error {
+	master, err := k.getOperationalMaster()
+	if err != nil {
+		return err
+	}
+	ssh, err := k.getHostSSHClient(master)
+	if err != nil {
+		return fmt.Errorf("failed to get ssh client of master(%s): %v", master, err)
+	}
+	out, err := ssh.Cmd(master, RemoteGetKubeadmConfig)
+	if err != nil {
+		return fmt.Errorf("failed to get kubeadm-config: %v", err)
+	}
+	cm := &v1.ConfigMap{}
+	if err = json.Unmarshal(out, cm); err != nil {
+		return fmt.Errorf("failed to parse kubeadm-config: %v", err)
+	}
+	config, err := setClusterConfigEtcdEndpoints(cm.Data[kubeadmClusterConfigKey], getEtcdEndpoints(etcds))
+	if err != nil {
+		return err
+	}
+	if config == cm.Data[kubeadmClusterConfigKey] {
+		return nil
+	}
+	cm.Data[kubeadmClusterConfigKey] = config
+	data, err := json.Marshal(cm)
+	if err != nil {
+		return err
+	}
+	if err = ssh.CmdAsync(master, fmt.Sprintf(RemoteReplaceKubeadmConfig, base64.StdEncoding.EncodeToString(data))); err != nil {
+		return fmt.Errorf("failed to update etcd endpoints in kubeadm-config: %v", err)
+	}
+	return nil
+}
+
+// setClusterConfigEtcdEndpoints sets the external etcd endpoints of ClusterConfiguration yaml, the other fields are
+// kept as they are. The config is returned unchanged if it uses the stacked etcd.
+func setClusterConfigEtcdEndpoints(config string, endpoints []string) (string, error) {
+	clusterConfig := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(config), &clusterConfig); err != nil {
+		return "", fmt.Errorf("failed to parse ClusterConfiguration: %v", err)
+	}
+	etcd, _ := clusterConfig["etcd"].(map[string]interface{})
+	external, ok := etcd["external"].(map[string]interface{})
+	if !ok {
+		return config, nil
+	}
+	external["endpoints"] = endpoints
+	out, err := yaml.Marshal(clusterConfig)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// getCurrentEtcds returns the etcd hosts of cluster excluding the ones to join.
+func (k *Runtime) getCurrentEtcds(toJoin []net.IP) []net.IP {
+	var res []net.IP
+	for _, etcd := range k.cluster.GetEtcdIPList() {
+		if utilsnet.NotInIPList(etcd, toJoin) {
+			res = append(res, etcd)
+		}
+	}
+	return res
+}
+
+// getEtcdMemberNames returns the member names of etcd hosts, which are their hostnames.
+func (k *Runtime) getEtcdMemberNames(etcds []net.IP) (map[string]string, error) {
+	names := make(map[string]string)
+	for _, etcd := range etcds {
+		name, err := k.getRemoteHostName(etcd)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get hostname of etcd(%s): %v", etcd, err)
+		}
+		names[etcd.String()] = name
+	}
+	return names, nil
+}
+
+func getEtcdPeerURL(etcd net.IP) string {
+	return "https://" + net.JoinHostPort(etcd.String(), EtcdPeerPort)
+}
+
+func getEtcdInitialCluster(etcds []net.IP, names map[string]string) string {
+	var members []string
+	for _, etcd := range etcds {
+		members = append(members, fmt.Sprintf("%s=%s", names[etcd.String()], getEtcdPeerURL(etcd)))
+	}
+	return strings.Join(members, ",")
+}
+
+func getEtcdArgs(etcd net.IP, name, initialCluster, state string) []string {
+	clientURL := "https://" + net.JoinHostPort(etcd.String(), EtcdClientPort)
+	certFile := func(name string) string {
+		return filepath.Join(clustercert.KubeDefaultCertEtcdPath, name)
+	}
+	return []string{
+		"--name=" + name,
+		"--data-dir=" + EtcdDataDir,
+		"--listen-client-urls=https://127.0.0.1:2379," + clientURL,
+		"--advertise-client-urls=" + clientURL,
+		"--listen-peer-urls=" + getEtcdPeerURL(etcd),
+		"--initial-advertise-peer-urls=" + getEtcdPeerURL(etcd),
+		"--initial-cluster=" + initialCluster,
+		"--initial-cluster-state=" + state,
+		"--initial-cluster-token=" + EtcdClusterToken,
+		"--cert-file=" + certFile("server.crt"),
+		"--key-file=" + certFile("server.key"),
+		"--client-cert-auth=true",
+		"--trusted-ca-file=" + certFile("ca.crt"),
+		"--peer-cert-file=" + certFile("peer.crt"),
+		"--peer-key-file=" + certFile("peer.key"),
+		"--peer-client-cert-auth=true",
+		"--peer-trusted-ca-file=" + certFile("ca.crt"),
+		"--snapshot-count=10000",
+	}
+}
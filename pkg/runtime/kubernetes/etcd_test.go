@@ -0,0 +1,179 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"net"
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+func TestGetEtcdInitialCluster(t *testing.T) {
+	names := map[string]string{
+		"192.168.0.2":   "etcd1",
+		"192.168.0.3":   "etcd2",
+		"2001:db8::100": "etcd3",
+	}
+	tests := []struct {
+		name  string
+		etcds []net.IP
+		want  string
+	}{
+		{
+			name:  "single member",
+			etcds: []net.IP{net.ParseIP("192.168.0.2")},
+			want:  "etcd1=https://192.168.0.2:2380",
+		},
+		{
+			name:  "members in order",
+			etcds: []net.IP{net.ParseIP("192.168.0.3"), net.ParseIP("192.168.0.2")},
+			want:  "etcd2=https://192.168.0.3:2380,etcd1=https://192.168.0.2:2380",
+		},
+		{
+			name:  "ipv6 member",
+			etcds: []net.IP{net.ParseIP("2001:db8::100")},
+			want:  "etcd3=https://[2001:db8::100]:2380",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getEtcdInitialCluster(tt.etcds, names); got != tt.want {
+				t.Errorf("getEtcdInitialCluster() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetEtcdArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		etcd    net.IP
+		state   string
+		wantSub []string
+	}{
+		{
+			name:  "new member",
+			etcd:  net.ParseIP("192.168.0.2"),
+			state: EtcdStateNew,
+			wantSub: []string{
+				"--name=etcd1",
+				"--listen-client-urls=https://127.0.0.1:2379,https://192.168.0.2:2379",
+				"--advertise-client-urls=https://192.168.0.2:2379",
+				"--listen-peer-urls=https://192.168.0.2:2380",
+				"--initial-advertise-peer-urls=https://192.168.0.2:2380",
+				"--initial-cluster=etcd1=https://192.168.0.2:2380",
+				"--initial-cluster-state=new",
+				"--initial-cluster-token=" + EtcdClusterToken,
+				"--cert-file=/etc/kubernetes/pki/etcd/server.crt",
+				"--peer-trusted-ca-file=/etc/kubernetes/pki/etcd/ca.crt",
+			},
+		},
+		{
+			name:  "joining ipv6 member",
+			etcd:  net.ParseIP("2001:db8::100"),
+			state: EtcdStateExisting,
+			wantSub: []string{
+				"--advertise-client-urls=https://[2001:db8::100]:2379",
+				"--listen-peer-urls=https://[2001:db8::100]:2380",
+				"--initial-cluster-state=existing",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := getEtcdArgs(tt.etcd, "etcd1", "etcd1=https://192.168.0.2:2380", tt.state)
+			set := map[string]bool{}
+			for _, arg := range args {
+				if set[arg] {
+					t.Errorf("duplicated etcd arg %s", arg)
+				}
+				set[arg] = true
+			}
+			for _, want := range tt.wantSub {
+				if !set[want] {
+					t.Errorf("getEtcdArgs() = %v, missing %s", args, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSetClusterConfigEtcdEndpoints(t *testing.T) {
+	endpoints := []string{"https://192.168.0.2:2379", "https://192.168.0.3:2379"}
+	tests := []struct {
+		name    string
+		config  string
+		want    []string
+		changed bool
+	}{
+		{
+			name: "external etcd",
+			config: `apiVersion: kubeadm.k8s.io/v1beta3
+etcd:
+  external:
+    caFile: /etc/kubernetes/pki/etcd/ca.crt
+    endpoints:
+    - https://192.168.0.2:2379
+kind: ClusterConfiguration
+kubernetesVersion: v1.22.15
+`,
+			want:    endpoints,
+			changed: true,
+		},
+		{
+			name: "stacked etcd",
+			config: `apiVersion: kubeadm.k8s.io/v1beta3
+etcd:
+  local:
+    dataDir: /var/lib/etcd
+kind: ClusterConfiguration
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := setClusterConfigEtcdEndpoints(tt.config, endpoints)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.changed {
+				if got != tt.config {
+					t.Errorf("setClusterConfigEtcdEndpoints() changed config to %s", got)
+				}
+				return
+			}
+			config := struct {
+				KubernetesVersion string `json:"kubernetesVersion"`
+				Etcd              struct {
+					External struct {
+						CAFile    string   `json:"caFile"`
+						Endpoints []string `json:"endpoints"`
+					} `json:"external"`
+				} `json:"etcd"`
+			}{}
+			if err = yaml.Unmarshal([]byte(got), &config); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(config.Etcd.External.Endpoints, tt.want) {
+				t.Errorf("endpoints = %v, want %v", config.Etcd.External.Endpoints, tt.want)
+			}
+			if config.KubernetesVersion != "v1.22.15" || config.Etcd.External.CAFile == "" {
+				t.Errorf("other fields are not kept: %s", got)
+			}
+		})
+	}
+}
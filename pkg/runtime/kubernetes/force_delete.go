@@ -0,0 +1,141 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// RemoteGetNodeName prints the name of node whose internal IP is the given one.
+	RemoteGetNodeName   = `kubectl get nodes -o jsonpath='{range .items[*]}{.metadata.name}{" "}{.status.addresses[?(@.type=="InternalIP")].address}{"\n"}{end}' | awk '$2=="%s"{print $1}'`
+	KubeForceDeleteNode = "kubectl delete node %s --ignore-not-found"
+	// RemoteStackedEtcdctl runs etcdctl in the stacked etcd pod on master, whose API version is v3 by default since etcd v3.4.
+	RemoteStackedEtcdctl          = `kubectl -n kube-system exec etcd-%s -- etcdctl --endpoints=https://127.0.0.1:2379 --cacert=/etc/kubernetes/pki/etcd/ca.crt --cert=/etc/kubernetes/pki/etcd/healthcheck-client.crt --key=/etc/kubernetes/pki/etcd/healthcheck-client.key`
+	RemoteStackedEtcdMemberID     = RemoteStackedEtcdctl + ` member list | grep -F '%s,' | cut -d, -f1`
+	RemoteStackedEtcdMemberRemove = RemoteStackedEtcdctl + ` member remove %s`
+)
+
+// forceDeleteMasters deletes the masters which may be unreachable. The reachable masters are cleaned as usual,
+// for the others, the etcd members and node objects are removed via an operational master and the hosts are left
+// as they are.
+func (k *Runtime) forceDeleteMasters(masters []net.IP) error {
+	for _, master := range masters {
+		if k.WaitSSHReady(1, master) == nil {
+			logrus.Infof("Start to delete master %s", master)
+			if err := k.deleteMaster(master); err != nil {
+				return fmt.Errorf("failed to delete master %s: %v", master, err)
+			}
+			continue
+		}
+
+		logrus.Warnf("master %s is unreachable, start to remove it from cluster by force", master)
+		if !k.isExternalEtcd() {
+			if err := k.removeStackedEtcdMember(master); err != nil {
+				return err
+			}
+		}
+		if err := k.removeNodeObject(master); err != nil {
+			return err
+		}
+		var remaining []net.IP
+		for _, ip := range k.cluster.GetMasterIPList() {
+			if !ip.Equal(master) {
+				remaining = append(remaining, ip)
+			}
+		}
+		if err := k.updateNodeLoadBalancer(remaining); err != nil {
+			return err
+		}
+		logrus.Infof("Succeeded in removing master %s from cluster", master)
+	}
+	return nil
+}
+
+// forceDeleteNodes deletes the nodes which may be unreachable, the node objects of unreachable nodes are removed only.
+func (k *Runtime) forceDeleteNodes(nodes []net.IP) error {
+	for _, node := range nodes {
+		if k.WaitSSHReady(1, node) == nil {
+			if err := k.deleteNodes([]net.IP{node}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		logrus.Warnf("node %s is unreachable, start to remove it from cluster by force", node)
+		if err := k.removeNodeObject(node); err != nil {
+			return err
+		}
+		logrus.Infof("Succeeded in removing node %s from cluster", node)
+	}
+	return nil
+}
+
+// removeStackedEtcdMember removes the etcd member of master by the etcd pod on an operational master, it is skipped if the
+// member has been removed already.
+func (k *Runtime) removeStackedEtcdMember(master net.IP) error {
+	operational, err := k.getOperationalMaster(master)
+	if err != nil {
+		return err
+	}
+	ssh, err := k.getHostSSHClient(operational)
+	if err != nil {
+		return fmt.Errorf("failed to get ssh client of master %s: %v", operational, err)
+	}
+	name, err := k.getRemoteHostName(operational)
+	if err != nil {
+		return err
+	}
+	id, err := ssh.CmdToString(operational, fmt.Sprintf(RemoteStackedEtcdMemberID, name, getEtcdPeerURL(master)), "")
+	if err != nil {
+		return fmt.Errorf("failed to get etcd member ID of master %s: %v", master, err)
+	}
+	if id = strings.TrimSpace(id); id == "" {
+		logrus.Warnf("master %s is not an etcd member, skip removing it", master)
+		return nil
+	}
+	if err = ssh.CmdAsync(operational, fmt.Sprintf(RemoteStackedEtcdMemberRemove, name, id)); err != nil {
+		return fmt.Errorf("failed to remove etcd member of master %s: %v", master, err)
+	}
+	return nil
+}
+
+// removeNodeObject deletes the node object of host by kubectl on an operational master.
+func (k *Runtime) removeNodeObject(host net.IP) error {
+	operational, err := k.getOperationalMaster(host)
+	if err != nil {
+		return err
+	}
+	ssh, err := k.getHostSSHClient(operational)
+	if err != nil {
+		return fmt.Errorf("failed to get ssh client of master %s: %v", operational, err)
+	}
+	name, err := ssh.CmdToString(operational, fmt.Sprintf(RemoteGetNodeName, host), "")
+	if err != nil {
+		return fmt.Errorf("failed to get node name of %s: %v", host, err)
+	}
+	if name = strings.TrimSpace(name); name == "" {
+		logrus.Warnf("failed to find the node of %s, skip deleting it", host)
+		return nil
+	}
+	if err = ssh.CmdAsync(operational, fmt.Sprintf(KubeForceDeleteNode, name)); err != nil {
+		return fmt.Errorf("failed to delete node %s: %v", name, err)
+	}
+	return nil
+}
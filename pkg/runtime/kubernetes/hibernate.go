@@ -0,0 +1,195 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/sealerio/sealer/pkg/clustercert"
+	"github.com/sealerio/sealer/pkg/exec"
+)
+
+const (
+	// StoppedCordonLabel marks the nodes cordoned by cluster stop, only they are uncordoned by cluster start, so
+	// the nodes cordoned before are kept unschedulable.
+	StoppedCordonLabel  = "sealer.io/cordoned-by-stop"
+	RemoteCordonNodes   = `for n in $(kubectl get nodes --field-selector spec.unschedulable=false -o name); do kubectl cordon $n && kubectl label $n ` + StoppedCordonLabel + `=true --overwrite; done`
+	RemoteUncordonNodes = `for n in $(kubectl get nodes -l ` + StoppedCordonLabel + ` -o name); do kubectl uncordon $n && kubectl label $n ` + StoppedCordonLabel + `-; done`
+	// RemoteNotReadyNodes prints the nodes not ready, the status of cordoned nodes is "Ready,SchedulingDisabled".
+	RemoteNotReadyNodes = `kubectl get nodes --no-headers | awk '$2 !~ /^Ready/ {print $1}'`
+	// RemoteStopPods stops the pods gracefully if the CRI is up, which is served by kubelet itself on dockershim.
+	RemoteStopPods = `if crictl info >/dev/null 2>&1; then PODS=$(crictl pods -q) && if [ -n "$PODS" ]; then crictl stopp $PODS >/dev/null; fi; fi`
+	// RemoteStopHost stops the pods while the CRI is up, then kubelet, the pods restarted by kubelet meanwhile,
+	// and the container runtime, which is docker or containerd. Only the units not installed are skipped.
+	RemoteStopHost = RemoteStopPods +
+		` && if systemctl cat kubelet.service >/dev/null 2>&1; then systemctl stop kubelet; fi && ` + RemoteStopPods +
+		` && if systemctl cat docker.service >/dev/null 2>&1; then systemctl stop docker.socket docker; fi` +
+		` && if systemctl cat containerd.service >/dev/null 2>&1; then systemctl stop containerd; fi`
+	RemoteStartHost = `if systemctl cat containerd.service >/dev/null 2>&1; then systemctl start containerd; fi` +
+		` && if systemctl cat docker.service >/dev/null 2>&1; then systemctl start docker; fi && systemctl start kubelet`
+	RemoteStopEtcd = "systemctl stop etcd"
+	// EtcdSnapshotFile is the etcd snapshot saved by cluster stop, it is under the data dir of etcd which is
+	// mounted into the stacked etcd pod.
+	EtcdSnapshotFile   = EtcdDataDir + "/sealer-snapshot-%s.db"
+	RemoteEtcdSnapshot = " snapshot save %s"
+	// DefaultStartTimeout is the timeout of waiting for the cluster to be healthy, powering on hosts and pulling
+	// the pods up may take minutes.
+	DefaultStartTimeout = 10 * time.Minute
+	startPollInterval   = 5 * time.Second
+)
+
+// stop stops the cluster gracefully: cordons all nodes, stops the pods, kubelet and container runtime on
+// workers then masters, and stops the external etcd at last. The etcd is snapshot before all if snapshotEtcd.
+func (k *Runtime) stop(snapshotEtcd bool) error {
+	master, err := k.getOperationalMaster()
+	if err != nil {
+		return err
+	}
+	if snapshotEtcd {
+		if err := k.snapshotEtcd(master); err != nil {
+			return err
+		}
+	}
+	ssh, err := k.getHostSSHClient(master)
+	if err != nil {
+		return fmt.Errorf("failed to get ssh client of master %s: %v", master, err)
+	}
+	if err := ssh.CmdAsync(master, RemoteCordonNodes); err != nil {
+		return fmt.Errorf("failed to cordon nodes: %v", err)
+	}
+
+	for _, hosts := range [][]net.IP{k.cluster.GetNodeIPList(), k.cluster.GetMasterIPList()} {
+		if err := k.runOnHosts(hosts, RemoteStopHost); err != nil {
+			return err
+		}
+		logrus.Infof("Succeeded in stopping %s", hosts)
+	}
+	return k.runOnHosts(k.cluster.GetEtcdIPList(), RemoteStopEtcd)
+}
+
+// start starts the cluster stopped by stop in reverse order: the external etcd, masters and workers, it waits
+// at most timeout in total for the etcd, apiserver and nodes to be healthy, then uncordons the nodes cordoned
+// by stop. DefaultStartTimeout is used if timeout is not positive.
+func (k *Runtime) start(timeout time.Duration) error {
+	if timeout <= 0 {
+		timeout = DefaultStartTimeout
+	}
+	deadline := time.Now().Add(timeout)
+
+	if etcds := k.cluster.GetEtcdIPList(); len(etcds) != 0 {
+		if err := k.runOnHosts(etcds, RemoteStartEtcd); err != nil {
+			return err
+		}
+		if err := waitUntil(deadline, startPollInterval, func() error {
+			return k.runOnHosts(etcds, fmt.Sprintf(RemoteEtcdHealth, clustercert.KubeDefaultCertEtcdPath))
+		}); err != nil {
+			return fmt.Errorf("failed to wait for etcd to be healthy: %v", err)
+		}
+	}
+
+	if err := k.runOnHosts(k.cluster.GetMasterIPList(), RemoteStartHost); err != nil {
+		return err
+	}
+	var master net.IP
+	if err := waitUntil(deadline, startPollInterval, func() error {
+		var err error
+		master, err = k.getOperationalMaster()
+		return err
+	}); err != nil {
+		return fmt.Errorf("failed to wait for masters to be healthy: %v", err)
+	}
+	logrus.Infof("Succeeded in starting masters %s", k.cluster.GetMasterIPList())
+
+	if err := k.runOnHosts(k.cluster.GetNodeIPList(), RemoteStartHost); err != nil {
+		return err
+	}
+	ssh, err := k.getHostSSHClient(master)
+	if err != nil {
+		return fmt.Errorf("failed to get ssh client of master %s: %v", master, err)
+	}
+	if err := waitUntil(deadline, startPollInterval, func() error {
+		out, err := ssh.CmdToString(master, RemoteNotReadyNodes, " ")
+		if err != nil {
+			return err
+		}
+		if out = strings.TrimSpace(out); out != "" {
+			return fmt.Errorf("nodes %s are not ready", out)
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to wait for nodes to be ready: %v", err)
+	}
+
+	if err := ssh.CmdAsync(master, RemoteUncordonNodes); err != nil {
+		return fmt.Errorf("failed to uncordon nodes: %v", err)
+	}
+	return nil
+}
+
+// snapshotEtcd saves the etcd snapshot to EtcdSnapshotFile on the first etcd host, or on master by the stacked
+// etcd pod.
+func (k *Runtime) snapshotEtcd(master net.IP) error {
+	host := master
+	file := fmt.Sprintf(EtcdSnapshotFile, time.Now().Format("20060102150405"))
+	var cmd string
+	if k.isExternalEtcd() {
+		host = k.cluster.GetEtcdIPList()[0]
+		cmd = fmt.Sprintf(RemoteEtcdctl, clustercert.KubeDefaultCertEtcdPath) + fmt.Sprintf(RemoteEtcdSnapshot, file)
+	} else {
+		name, err := k.getRemoteHostName(master)
+		if err != nil {
+			return err
+		}
+		cmd = fmt.Sprintf(RemoteStackedEtcdctl, name) + fmt.Sprintf(RemoteEtcdSnapshot, file)
+	}
+	ssh, err := k.getHostSSHClient(host)
+	if err != nil {
+		return fmt.Errorf("failed to get ssh client of host %s: %v", host, err)
+	}
+	if err := ssh.CmdAsync(host, cmd); err != nil {
+		return fmt.Errorf("failed to snapshot etcd: %v", err)
+	}
+	logrus.Infof("Succeeded in saving etcd snapshot to %s on %s", file, host)
+	return nil
+}
+
+// waitUntil calls condition every interval until it succeeds, the last error is returned if it does not
+// succeed before deadline.
+func waitUntil(deadline time.Time, interval time.Duration, condition func() error) error {
+	for {
+		err := condition()
+		if err == nil {
+			return nil
+		}
+		if time.Now().Add(interval).After(deadline) {
+			return fmt.Errorf("timed out: %v", err)
+		}
+		time.Sleep(interval)
+	}
+}
+
+// runOnHosts runs cmd on hosts concurrently.
+func (k *Runtime) runOnHosts(hosts []net.IP, cmd string) error {
+	if len(hosts) == 0 {
+		return nil
+	}
+	e := exec.NewExecCmd(k.cluster, hosts)
+	return e.RunCmd(cmd)
+}
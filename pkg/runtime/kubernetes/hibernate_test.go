@@ -0,0 +1,165 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWaitUntil(t *testing.T) {
+	tests := []struct {
+		name      string
+		timeout   time.Duration
+		failTimes int
+		wantCalls int
+		wantErr   bool
+	}{
+		{
+			name:      "healthy at once",
+			timeout:   time.Second,
+			wantCalls: 1,
+		},
+		{
+			name:      "healthy after retries",
+			timeout:   time.Second,
+			failTimes: 3,
+			wantCalls: 4,
+		},
+		{
+			name:      "timed out before next poll",
+			timeout:   5 * time.Millisecond,
+			failTimes: 100,
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			name:      "deadline passed",
+			failTimes: 100,
+			wantCalls: 1,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := waitUntil(time.Now().Add(tt.timeout), 10*time.Millisecond, func() error {
+				calls++
+				if calls <= tt.failTimes {
+					return fmt.Errorf("nodes node%d are not ready", calls)
+				}
+				return nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("waitUntil() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("waitUntil() called condition %d times, want %d", calls, tt.wantCalls)
+			}
+			if err != nil && !strings.Contains(err.Error(), fmt.Sprintf("node%d", calls)) {
+				t.Errorf("waitUntil() error = %v, want the last error", err)
+			}
+		})
+	}
+}
+
+// fakeSystemctl reports the units in $UNITS installed, fails to stop $FAIL_UNIT, and marks kubelet stopped.
+const fakeSystemctl = `#!/bin/sh
+case "$1" in
+cat) for u in $UNITS; do [ "$u.service" = "$2" ] && exit 0; done; echo "No files found for $2." >&2; exit 1;;
+stop) shift; echo "systemctl stop $*" >> "$DIR/actions"; [ "$1" = "kubelet" ] && touch "$DIR/kubelet-stopped"
+  [ "$1" = "$FAIL_UNIT" ] && exit 1; exit 0;;
+esac
+`
+
+// fakeCrictl serves pod p1, the CRI is down after kubelet is stopped if $DOCKERSHIM is set.
+const fakeCrictl = `#!/bin/sh
+case "$1" in
+info) [ -n "$DOCKERSHIM" ] && [ -e "$DIR/kubelet-stopped" ] && exit 1; exit 0;;
+pods) echo p1;;
+stopp) echo "crictl stopp $2" >> "$DIR/actions"; [ -n "$FAIL_STOPP" ] && exit 1; exit 0;;
+esac
+`
+
+func TestRemoteStopHost(t *testing.T) {
+	tests := []struct {
+		name        string
+		env         []string
+		wantActions []string
+		wantErr     bool
+	}{
+		{
+			name: "containerd",
+			env:  []string{"UNITS=kubelet containerd"},
+			wantActions: []string{"crictl stopp p1", "systemctl stop kubelet", "crictl stopp p1",
+				"systemctl stop containerd"},
+		},
+		{
+			name: "dockershim",
+			env:  []string{"UNITS=kubelet docker containerd", "DOCKERSHIM=true"},
+			wantActions: []string{"crictl stopp p1", "systemctl stop kubelet", "systemctl stop docker.socket docker",
+				"systemctl stop containerd"},
+		},
+		{
+			name:        "failed to stop kubelet",
+			env:         []string{"UNITS=kubelet containerd", "FAIL_UNIT=kubelet"},
+			wantActions: []string{"crictl stopp p1", "systemctl stop kubelet"},
+			wantErr:     true,
+		},
+		{
+			name:        "failed to stop pods",
+			env:         []string{"UNITS=kubelet containerd", "FAIL_STOPP=true"},
+			wantActions: []string{"crictl stopp p1"},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "sealer-stop")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() {
+				_ = os.RemoveAll(dir)
+			}()
+			for name, script := range map[string]string{"systemctl": fakeSystemctl, "crictl": fakeCrictl} {
+				if err = ioutil.WriteFile(filepath.Join(dir, name), []byte(script), 0700); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			cmd := exec.Command("/bin/sh", "-c", RemoteStopHost)
+			cmd.Env = append([]string{"PATH=" + dir + ":" + os.Getenv("PATH"), "DIR=" + dir}, tt.env...)
+			out, err := cmd.CombinedOutput()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RemoteStopHost error = %v, wantErr %v, output: %s", err, tt.wantErr, out)
+			}
+			data, err := ioutil.ReadFile(filepath.Join(dir, "actions"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Split(strings.TrimSpace(string(data)), "\n"); !reflect.DeepEqual(got, tt.wantActions) {
+				t.Errorf("RemoteStopHost actions = %q, want %q", got, tt.wantActions)
+			}
+		})
+	}
+}
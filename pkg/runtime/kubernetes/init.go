@@ -23,8 +23,11 @@ import (
 
 	"github.com/sealerio/sealer/common"
 	"github.com/sealerio/sealer/pkg/clustercert"
+	"github.com/sealerio/sealer/pkg/runtime/kubernetes/kubeadm"
+	utilsnet "github.com/sealerio/sealer/utils/net"
 	osi "github.com/sealerio/sealer/utils/os"
 	"github.com/sealerio/sealer/utils/ssh"
+	versionUtils "github.com/sealerio/sealer/utils/version"
 	"github.com/sealerio/sealer/utils/yaml"
 
 	"github.com/sirupsen/logrus"
@@ -37,7 +40,6 @@ const (
 	RemoteCmdGetNetworkInterface   = "ls /sys/class/net"
 	RemoteCmdExistNetworkInterface = "ip addr show %s | egrep \"%s\" || true"
 	WriteKubeadmConfigCmd          = `cd %s && echo '%s' > etc/kubeadm.yml`
-	DefaultVIP                     = "10.103.97.2"
 	DefaultAPIserverDomain         = "apiserver.cluster.local"
 	DefaultRegistryPort            = 5000
 	DockerCertDir                  = "/etc/docker/certs.d"
@@ -52,6 +54,10 @@ func (k *Runtime) ConfigKubeadmOnMaster0() error {
 	if err := k.KubeadmConfig.Merge(k.getDefaultKubeadmConfig()); err != nil {
 		return err
 	}
+	if err := k.handleDualStack(); err != nil {
+		return err
+	}
+	k.handleExternalEtcd()
 	bs, err := k.generateConfigs()
 	if err != nil {
 		return err
@@ -72,8 +78,18 @@ func (k *Runtime) generateConfigs() ([]byte, error) {
 	}
 	k.setCgroupDriver(cGroupDriver)
 	k.setKubeadmAPIVersion()
-	return yaml.MarshalWithDelimiter(&k.InitConfiguration,
-		&k.ClusterConfiguration,
+	initConfig := k.InitConfiguration
+	initConfig.NodeRegistration = k.hostNodeRegistration(initConfig.NodeRegistration, k.cluster.GetMaster0IP(), false)
+	versionedInit, err := kubeadm.ConvertTo(&initConfig)
+	if err != nil {
+		return nil, err
+	}
+	versionedCluster, err := kubeadm.ConvertTo(&k.ClusterConfiguration)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.MarshalWithDelimiter(versionedInit,
+		versionedCluster,
 		&k.KubeletConfiguration,
 		&k.KubeProxyConfiguration)
 }
@@ -81,12 +97,57 @@ func (k *Runtime) generateConfigs() ([]byte, error) {
 func (k *Runtime) handleKubeadmConfig() {
 	//The configuration set here does not require merge
 	k.setInitAdvertiseAddress(k.cluster.GetMaster0IP())
-	k.setControlPlaneEndpoint(fmt.Sprintf("%s:6443", k.getAPIServerDomain()))
+	k.setControlPlaneEndpoint(net.JoinHostPort(k.getAPIServerDomain(), common.APIServerPort))
 	if k.APIServer.ExtraArgs == nil {
 		k.APIServer.ExtraArgs = make(map[string]string)
 	}
-	k.APIServer.ExtraArgs[EtcdServers] = getEtcdEndpointsWithHTTPSPrefix(k.cluster.GetMasterIPList())
-	k.IPVS.ExcludeCIDRs = append(k.KubeProxyConfiguration.IPVS.ExcludeCIDRs, fmt.Sprintf("%s/32", k.getVIP()))
+	etcds := k.cluster.GetMasterIPList()
+	if k.isExternalEtcd() {
+		etcds = k.cluster.GetEtcdIPList()
+	}
+	k.APIServer.ExtraArgs[EtcdServers] = getEtcdEndpointsWithHTTPSPrefix(etcds)
+	if k.isLvscare() {
+		k.IPVS.ExcludeCIDRs = append(k.KubeProxyConfiguration.IPVS.ExcludeCIDRs, utilsnet.HostCIDR(k.getVIP()))
+	}
+}
+
+// handleDualStack enables the IPv6DualStack feature gate for the dual-stack cluster, whose pod subnet is a pair
+// of IPv4 and IPv6 CIDRs like "100.64.0.0/10,fd00:100:64::/64", the gate is enabled by default since v1.21.
+func (k *Runtime) handleDualStack() error {
+	if len(utilsnet.SplitCIDRs(k.Networking.PodSubnet)) < 2 {
+		return nil
+	}
+	if len(utilsnet.SplitCIDRs(k.Networking.ServiceSubnet)) < 2 {
+		return fmt.Errorf("the service subnet of dual-stack cluster must be a pair of IPv4 and IPv6 CIDRs: %s", k.Networking.ServiceSubnet)
+	}
+	greatThanKV1210, err := versionUtils.Version(k.getKubeVersion()).Compare(V1210)
+	if err != nil {
+		return fmt.Errorf("failed to compare Kubernetes version: %v", err)
+	}
+	if greatThanKV1210 {
+		return nil
+	}
+
+	gate := DualStackFeatureGate + "=true"
+	for _, args := range []*map[string]string{&k.APIServer.ExtraArgs, &k.ControllerManager.ExtraArgs} {
+		if *args == nil {
+			*args = make(map[string]string)
+		}
+		if gates := (*args)["feature-gates"]; gates == "" {
+			(*args)["feature-gates"] = gate
+		} else if !strings.Contains(gates, DualStackFeatureGate) {
+			(*args)["feature-gates"] = gates + "," + gate
+		}
+	}
+	if k.KubeletConfiguration.FeatureGates == nil {
+		k.KubeletConfiguration.FeatureGates = make(map[string]bool)
+	}
+	k.KubeletConfiguration.FeatureGates[DualStackFeatureGate] = true
+	if k.KubeProxyConfiguration.FeatureGates == nil {
+		k.KubeProxyConfiguration.FeatureGates = make(map[string]bool)
+	}
+	k.KubeProxyConfiguration.FeatureGates[DualStackFeatureGate] = true
+	return nil
 }
 
 //CmdToString is in host exec cmd and replace to spilt str
@@ -138,7 +199,10 @@ func (k *Runtime) GenerateCert() error {
 }
 
 func (k *Runtime) GenerateRegistryCert() error {
-	return GenerateRegistryCert(k.getCertsDir(), k.RegConfig.Domain)
+	if k.RegConfig.External {
+		return nil
+	}
+	return GenerateRegistryCert(k.getCertsDir(), k.RegConfig.Domain, k.RegConfig.CertYears)
 }
 
 func (k *Runtime) SendRegistryCert(host []net.IP) error {
@@ -155,7 +219,7 @@ func (k *Runtime) CreateKubeConfig() error {
 		return err
 	}
 
-	controlPlaneEndpoint := fmt.Sprintf("https://%s:6443", k.getAPIServerDomain())
+	controlPlaneEndpoint := "https://" + net.JoinHostPort(k.getAPIServerDomain(), common.APIServerPort)
 	err = clustercert.CreateJoinControlPlaneKubeConfigFiles(k.getBasePath(), k.getPKIPath(),
 		"ca", hostname, controlPlaneEndpoint, "kubernetes")
 	if err != nil {
@@ -190,19 +254,17 @@ func (k *Runtime) CopyStaticFiles(nodes []net.IP) error {
 	return nil
 }
 
-//decode output to join token hash and key
+// decode output to join token hash and key, the output and join command are not logged as they contain the secrets.
 func (k *Runtime) decodeMaster0Output(output []byte) {
 	s0 := string(output)
-	logrus.Debugf("decodeOutput: %s", s0)
 	slice := strings.Split(s0, "kubeadm join")
 	slice1 := strings.Split(slice[1], "Please note")
-	logrus.Infof("join command is: kubeadm join %s", slice1[0])
 	k.decodeJoinCmd(slice1[0])
+	logrus.Infof("join token %s is created", tokenID(k.getJoinToken()))
 }
 
 //  192.168.0.200:6443 --token 9vr73a.a8uxyaju799qwdjv --discovery-token-ca-cert-hash sha256:7c2e69131a36ae2a042a339b33381c6d0d43887e2de83720eff5359e26aec866 --experimental-control-plane --certificate-key f8902e114ef118304e561c3ecd4d0b543adc226b7a07f675f56564185ffe0c07
 func (k *Runtime) decodeJoinCmd(cmd string) {
-	logrus.Debugf("[globals]decodeJoinCmd: %s", cmd)
 	stringSlice := strings.Split(cmd, " ")
 
 	for i, r := range stringSlice {
@@ -221,7 +283,7 @@ func (k *Runtime) decodeJoinCmd(cmd string) {
 			k.setInitCertificateKey(stringSlice[i+1][:64])
 		}
 	}
-	logrus.Debugf("joinToken: %v\nTokenCaCertHash: %v\nCertificateKey: %v", k.getJoinToken(), k.getTokenCaCertHash(), k.getCertificateKey())
+	logrus.Debugf("join token id: %s, token ca cert hash: %s", tokenID(k.getJoinToken()), k.getTokenCaCertHash())
 }
 
 //InitMaster0 is using kubeadm init to start up the cluster master0.
@@ -258,6 +320,12 @@ func (k *Runtime) InitMaster0() error {
 	if err != nil {
 		return err
 	}
+	// the hosts are joined with the tokens created for each join, so the token of kubeadm init, which is valid
+	// for 24h by default, is deleted once master0 is up.
+	k.deleteJoinToken()
+	if err = k.applyLoadBalancerOnMasters([]net.IP{k.cluster.GetMaster0IP()}); err != nil {
+		return err
+	}
 
 	if client.(*ssh.SSH).User != common.ROOT {
 		err = client.CmdAsync(k.cluster.GetMaster0IP(), RemoteNonRootCopyKubeConfig)
@@ -273,12 +341,16 @@ func (k *Runtime) GetKubectlAndKubeconfig() error {
 	if osi.IsFileExist(common.DefaultKubeConfigFile()) {
 		return nil
 	}
-	client, err := k.getHostSSHClient(k.cluster.GetMaster0IP())
+	master, err := k.getOperationalMaster()
+	if err != nil {
+		return err
+	}
+	client, err := k.getHostSSHClient(master)
 	if err != nil {
-		return fmt.Errorf("failed to get ssh client of master0(%s) when get kubbectl and kubeconfig: %v", k.cluster.GetMaster0IP(), err)
+		return fmt.Errorf("failed to get ssh client of master %s when get kubbectl and kubeconfig: %v", master, err)
 	}
 
-	return GetKubectlAndKubeconfig(client, k.cluster.GetMaster0IP(), k.getImageMountDir())
+	return GetKubectlAndKubeconfig(client, master, k.getImageMountDir())
 }
 
 func (k *Runtime) CopyStaticFilesTomasters() error {
@@ -289,6 +361,7 @@ func (k *Runtime) init() error {
 	pipeline := []func() error{
 		k.ConfigKubeadmOnMaster0,
 		k.GenerateCert,
+		k.InitEtcd,
 		k.CreateKubeConfig,
 		k.CopyStaticFilesTomasters,
 		k.ApplyRegistry,
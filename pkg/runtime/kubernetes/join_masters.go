@@ -24,8 +24,8 @@ import (
 
 	"github.com/sealerio/sealer/common"
 	"github.com/sealerio/sealer/pkg/clustercert"
-	"github.com/sealerio/sealer/pkg/ipvs"
 	"github.com/sealerio/sealer/pkg/runtime"
+	"github.com/sealerio/sealer/pkg/runtime/kubernetes/kubeadm"
 	utilsnet "github.com/sealerio/sealer/utils/net"
 	"github.com/sealerio/sealer/utils/ssh"
 	versionUtils "github.com/sealerio/sealer/utils/version"
@@ -43,7 +43,12 @@ const (
 	V1992 = "v1.19.2"
 	V1150 = "v1.15.0"
 	V1200 = "v1.20.0"
-	V1230 = "v1.23.0"
+	V1210 = "v1.21.0"
+	V1220 = "v1.22.0"
+)
+
+const (
+	DualStackFeatureGate = "IPv6DualStack"
 )
 
 const (
@@ -54,8 +59,8 @@ const (
 	RemoteReplaceKubeConfig     = `grep -qF "apiserver.cluster.local" %s  && sed -i 's/apiserver.cluster.local/%s/' %s && sed -i 's/apiserver.cluster.local/%s/' %s`
 	RemoteJoinMasterConfig      = `echo "%s" > %s/etc/kubeadm.yml`
 	InitMaster115Lower          = `kubeadm init --config=%s/etc/kubeadm.yml --experimental-upload-certs`
-	JoinMaster115Lower          = "kubeadm join %s:6443 --token %s --discovery-token-ca-cert-hash %s --experimental-control-plane --certificate-key %s"
-	JoinNode115Lower            = "kubeadm join %s:6443 --token %s --discovery-token-ca-cert-hash %s"
+	JoinMaster115Lower          = "kubeadm join %s --token %s --discovery-token-ca-cert-hash %s --experimental-control-plane --certificate-key %s"
+	JoinNode115Lower            = "kubeadm join %s --token %s --discovery-token-ca-cert-hash %s"
 	InitMaser115Upper           = `kubeadm init --config=%s/etc/kubeadm.yml --upload-certs`
 	JoinMaster115Upper          = "kubeadm join --config=%s/etc/kubeadm.yml"
 	JoinNode115Upper            = "kubeadm join --config=%s/etc/kubeadm.yml"
@@ -72,10 +77,10 @@ rm -rf /var/lib/etcd && rm -rf /var/etcd
 	RemoteRemoveAPIServerEtcHost = "sed -i \"/%s/d\" /etc/hosts"
 	RemoteRemoveRegistryCerts    = "rm -rf " + DockerCertDir + "/%s*"
 	RemoveLvscareStaticPod       = "rm -rf  /etc/kubernetes/manifests/kube-sealyun-lvscare*"
-	CreateLvscareStaticPod       = "mkdir -p /etc/kubernetes/manifests && echo '%s' > /etc/kubernetes/manifests/kube-sealyun-lvscare.yaml"
+	CreateLBStaticPod            = "mkdir -p /etc/kubernetes/manifests && echo '%s' > /etc/kubernetes/manifests/%s.yaml"
 	KubeDeleteNode               = "kubectl delete node %s"
-	// TODO check kubernetes certs
-	RemoteCheckCerts = "kubeadm alpha certs check-expiration"
+	// RemoteCheckCerts falls back to the alpha command of kubeadm older than v1.20.
+	RemoteCheckCerts = "kubeadm certs check-expiration 2>/dev/null || kubeadm alpha certs check-expiration"
 )
 
 const (
@@ -95,9 +100,9 @@ const (
 	DefaultCgroupDriver        = "cgroupfs"
 
 	// kubeadm api version
-	KubeadmV1beta1 = "kubeadm.k8s.io/v1beta1"
-	KubeadmV1beta2 = "kubeadm.k8s.io/v1beta2"
-	KubeadmV1beta3 = "kubeadm.k8s.io/v1beta3"
+	KubeadmV1beta1 = kubeadm.V1beta1
+	KubeadmV1beta2 = kubeadm.V1beta2
+	KubeadmV1beta3 = kubeadm.V1beta3
 )
 
 const (
@@ -115,23 +120,25 @@ func getAPIServerHost(ipAddr net.IP, APIServer string) (host string) {
 	return fmt.Sprintf("%s %s", ipAddr.String(), APIServer)
 }
 
-func (k *Runtime) JoinMasterCommands(master net.IP, joinCmd, hostname string) []string {
-	apiServerHost := getAPIServerHost(k.cluster.GetMaster0IP(), k.getAPIServerDomain())
-	cmdAddRegistryHosts := k.addRegistryDomainToHosts()
+func (k *Runtime) JoinMasterCommands(master net.IP, joinCmd, hostname string) ([]string, error) {
+	apiServerHost := getAPIServerHost(k.getJoinMasterIP(), k.getAPIServerDomain())
+	cmdAddRegistryHosts := k.addRegistryDomainToHosts(master)
 	certCMD := runtime.RemoteCerts(k.getCertSANS(), master, hostname, k.getSvcCIDR(), "")
 	cmdAddHosts := fmt.Sprintf(RemoteAddEtcHosts, apiServerHost, apiServerHost)
-	if k.RegConfig.Domain != SeaHub {
-		cmdAddSeaHubHosts := fmt.Sprintf(RemoteAddEtcHosts, k.RegConfig.IP.String()+" "+SeaHub, k.RegConfig.IP.String()+" "+SeaHub)
-		cmdAddRegistryHosts = fmt.Sprintf("%s && %s", cmdAddRegistryHosts, cmdAddSeaHubHosts)
+	var joinCommands []string
+	if cmdAddRegistryHosts != "" {
+		joinCommands = append(joinCommands, cmdAddRegistryHosts)
 	}
-	joinCommands := []string{cmdAddRegistryHosts, certCMD, cmdAddHosts}
-	if k.RegConfig.Username != "" && k.RegConfig.Password != "" {
-		joinCommands = append(joinCommands, k.GenLoginCommand())
+	joinCommands = append(joinCommands, certCMD, cmdAddHosts)
+	registryCmds, err := k.registryConfigCommands(master)
+	if err != nil {
+		return nil, err
 	}
+	joinCommands = append(joinCommands, registryCmds...)
 	cmdUpdateHosts := fmt.Sprintf(RemoteUpdateEtcHosts, apiServerHost,
 		getAPIServerHost(master, k.getAPIServerDomain()))
 
-	return append(joinCommands, joinCmd, cmdUpdateHosts, RemoteCopyKubeConfig)
+	return append(joinCommands, joinCmd, cmdUpdateHosts, RemoteCopyKubeConfig), nil
 }
 
 func (k *Runtime) sendKubeConfigFile(hosts []net.IP, kubeFile string) error {
@@ -145,16 +152,23 @@ func (k *Runtime) sendNewCertAndKey(hosts []net.IP) error {
 }
 
 func (k *Runtime) sendRegistryCertAndKey() error {
-	return k.sendFileToHosts(k.cluster.GetMasterIPList()[:1], k.getCertsDir(), filepath.Join(k.getRootfs(), "certs"))
+	return k.sendFileToHosts(k.registryNodes(), k.getCertsDir(), filepath.Join(k.getRootfs(), "certs"))
 }
 
 func (k *Runtime) sendRegistryCert(host []net.IP) error {
 	cf := k.RegConfig
-	err := k.sendFileToHosts(host, fmt.Sprintf("%s/%s.crt", k.getCertsDir(), cf.Domain), fmt.Sprintf("%s/%s:%s/%s.crt", DockerCertDir, cf.Domain, cf.Port, cf.Domain))
+	if cf.External {
+		if cf.CA == "" {
+			return nil
+		}
+		return k.sendFileToHosts(host, cf.CA, fmt.Sprintf("%s/%s/ca.crt", DockerCertDir, cf.Host()))
+	}
+	caCert := k.registryCACert()
+	err := k.sendFileToHosts(host, caCert, fmt.Sprintf("%s/%s:%s/ca.crt", DockerCertDir, cf.Domain, cf.Port))
 	if err != nil {
 		return err
 	}
-	return k.sendFileToHosts(host, fmt.Sprintf("%s/%s.crt", k.getCertsDir(), cf.Domain), fmt.Sprintf("%s/%s:%s/%s.crt", DockerCertDir, SeaHub, cf.Port, cf.Domain))
+	return k.sendFileToHosts(host, caCert, fmt.Sprintf("%s/%s:%s/ca.crt", DockerCertDir, SeaHub, cf.Port))
 }
 
 func (k *Runtime) sendFileToHosts(Hosts []net.IP, src, dst string) error {
@@ -212,14 +226,20 @@ func (k *Runtime) joinMasterConfig(masterIP net.IP) ([]byte, error) {
 	k.Lock()
 	defer k.Unlock()
 	// TODO Using join file instead template
-	k.setAPIServerEndpoint(fmt.Sprintf("%s:6443", k.cluster.GetMaster0IP()))
+	k.setAPIServerEndpoint(net.JoinHostPort(k.getJoinMasterIP().String(), common.APIServerPort))
 	k.setJoinAdvertiseAddress(masterIP)
 	cGroupDriver, err := k.getCgroupDriverFromShell(masterIP)
 	if err != nil {
 		return nil, err
 	}
 	k.setCgroupDriver(cGroupDriver)
-	return yaml.MarshalWithDelimiter(k.JoinConfiguration, k.KubeletConfiguration)
+	joinConfig := k.JoinConfiguration
+	joinConfig.NodeRegistration = k.hostNodeRegistration(joinConfig.NodeRegistration, masterIP, false)
+	versionedJoin, err := kubeadm.ConvertTo(&joinConfig)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.MarshalWithDelimiter(versionedJoin, k.KubeletConfiguration)
 }
 
 // sendJoinCPConfig send join CP nodes configuration
@@ -276,8 +296,8 @@ func (k *Runtime) Command(version string, name CommandType) (cmd string) {
 	// "kubeadm config migrate" command of kubeadm v1.15.x, so v1.14 not support multi network interface.
 	cmds := map[CommandType]string{
 		InitMaster: fmt.Sprintf(InitMaster115Lower, k.getRootfs()),
-		JoinMaster: fmt.Sprintf(JoinMaster115Lower, k.cluster.GetMaster0IP(), k.getJoinToken(), k.getTokenCaCertHash(), k.getCertificateKey()),
-		JoinNode:   fmt.Sprintf(JoinNode115Lower, k.getVIP(), k.getJoinToken(), k.getTokenCaCertHash()),
+		JoinMaster: fmt.Sprintf(JoinMaster115Lower, net.JoinHostPort(k.getJoinMasterIP().String(), common.APIServerPort), k.getJoinToken(), k.getTokenCaCertHash(), k.getCertificateKey()),
+		JoinNode:   fmt.Sprintf(JoinNode115Lower, net.JoinHostPort(k.getVIP().String(), common.APIServerPort), k.getJoinToken(), k.getTokenCaCertHash()),
 	}
 
 	kv := versionUtils.Version(version)
@@ -319,7 +339,11 @@ func (k *Runtime) joinMasters(masters []net.IP) error {
 	if err := k.WaitSSHReady(6, masters...); err != nil {
 		return errors.Wrap(err, "join masters wait for ssh ready time out")
 	}
-	if err := k.GetJoinTokenHashAndKey(); err != nil {
+	if err := k.createJoinToken(); err != nil {
+		return err
+	}
+	defer k.deleteJoinToken()
+	if err := k.uploadControlPlaneCerts(); err != nil {
 		return err
 	}
 	if err := k.CopyStaticFiles(masters); err != nil {
@@ -331,6 +355,9 @@ func (k *Runtime) joinMasters(masters []net.IP) error {
 	if err := k.sendRegistryCert(masters); err != nil {
 		return err
 	}
+	if err := k.applyRegistryOnMasters(masters); err != nil {
+		return err
+	}
 	// TODO only needs send ca?
 	if err := k.sendNewCertAndKey(masters); err != nil {
 		return err
@@ -346,12 +373,19 @@ func (k *Runtime) joinMasters(masters []net.IP) error {
 
 	for _, master := range masters {
 		logrus.Infof("Start to join %s as master", master)
+		// the masters are joined one by one, the certs may expire during a long join operation.
+		if err := k.uploadControlPlaneCerts(); err != nil {
+			return err
+		}
 
 		hostname, err := k.getRemoteHostName(master)
 		if err != nil {
 			return err
 		}
-		cmds := k.JoinMasterCommands(master, cmd, hostname)
+		cmds, err := k.JoinMasterCommands(master, cmd, hostname)
+		if err != nil {
+			return err
+		}
 		client, err := k.getHostSSHClient(master)
 		if err != nil {
 			return err
@@ -367,6 +401,13 @@ func (k *Runtime) joinMasters(masters []net.IP) error {
 
 		logrus.Infof("Succeeded in joining %s as master", master)
 	}
+	if err := k.applyLoadBalancerOnMasters(masters); err != nil {
+		return err
+	}
+	// the etcd servers in kubeadm-config may be stale if the external etcd cluster has been scaled.
+	if k.isExternalEtcd() {
+		return k.setEtcdServersOnMasters(masters, k.cluster.GetEtcdIPList())
+	}
 	return nil
 }
 
@@ -374,6 +415,10 @@ func (k *Runtime) deleteMasters(masters []net.IP) error {
 	if len(masters) == 0 {
 		return nil
 	}
+	// all the masters to delete are excluded, so the deleted masters are not used by each other.
+	if _, err := k.getOperationalMaster(masters...); err != nil {
+		return err
+	}
 	eg, _ := errgroup.WithContext(context.Background())
 	for _, master := range masters {
 		master := master
@@ -422,19 +467,28 @@ func (k *Runtime) deleteMaster(master net.IP) error {
 	if err != nil {
 		return fmt.Errorf("failed to delete master: %v", err)
 	}
-	remoteCleanCmd := []string{fmt.Sprintf(RemoteCleanMasterOrNode, vlogToStr(k.Vlog)),
-		fmt.Sprintf(RemoteRemoveAPIServerEtcHost, k.RegConfig.Domain),
-		fmt.Sprintf(RemoteRemoveAPIServerEtcHost, SeaHub),
-		fmt.Sprintf(RemoteRemoveRegistryCerts, k.RegConfig.Domain),
-		fmt.Sprintf(RemoteRemoveRegistryCerts, SeaHub),
-		fmt.Sprintf(RemoteRemoveAPIServerEtcHost, k.getAPIServerDomain())}
+	masterIPs := []net.IP{}
+	for _, ip := range k.cluster.GetMasterIPList() {
+		if !ip.Equal(master) {
+			masterIPs = append(masterIPs, ip)
+		}
+	}
+	var operational net.IP
+	if len(masterIPs) > 0 {
+		if operational, err = k.getOperationalMaster(master); err != nil {
+			return err
+		}
+	}
+
+	remoteCleanCmd := append([]string{fmt.Sprintf(RemoteCleanMasterOrNode, vlogToStr(k.Vlog))}, k.cleanRegistryCommands()...)
+	remoteCleanCmd = append(remoteCleanCmd, fmt.Sprintf(RemoteRemoveAPIServerEtcHost, k.getAPIServerDomain()))
 
 	//if the master to be removed is the execution machine, kubelet and ~./kube will not be removed and ApiServer host will be added.
 	address, err := utilsnet.GetLocalHostAddresses()
-	if err != nil || !utilsnet.IsLocalIP(master, address) {
+	if err != nil || !utilsnet.IsLocalIP(master, address) || operational == nil {
 		remoteCleanCmd = append(remoteCleanCmd, RemoveKubeConfig)
 	} else {
-		apiServerHost := getAPIServerHost(k.cluster.GetMaster0IP(), k.getAPIServerDomain())
+		apiServerHost := getAPIServerHost(operational, k.getAPIServerDomain())
 		remoteCleanCmd = append(remoteCleanCmd,
 			fmt.Sprintf(RemoteAddEtcHosts, apiServerHost, apiServerHost))
 	}
@@ -443,29 +497,30 @@ func (k *Runtime) deleteMaster(master net.IP) error {
 	}
 
 	// remove master
-	masterIPs := []net.IP{}
-	for _, ip := range k.cluster.GetMasterIPList() {
-		if !ip.Equal(master) {
-			masterIPs = append(masterIPs, ip)
-		}
-	}
-
-	if len(masterIPs) > 0 {
-		hostname, err := k.isHostName(k.cluster.GetMaster0IP(), master)
+	if operational != nil {
+		hostname, err := k.isHostName(operational, master)
 		if err != nil {
 			return err
 		}
-		master0SSH, err := k.getHostSSHClient(k.cluster.GetMaster0IP())
+		operationalSSH, err := k.getHostSSHClient(operational)
 		if err != nil {
-			return fmt.Errorf("failed to get master0 ssh client: %v", err)
+			return fmt.Errorf("failed to get ssh client of master %s: %v", operational, err)
 		}
 
-		if err := master0SSH.CmdAsync(k.cluster.GetMaster0IP(), fmt.Sprintf(KubeDeleteNode, strings.TrimSpace(hostname))); err != nil {
+		if err := operationalSSH.CmdAsync(operational, fmt.Sprintf(KubeDeleteNode, strings.TrimSpace(hostname))); err != nil {
 			return fmt.Errorf("failed to delete node %s: %v", hostname, err)
 		}
 	}
-	lvsImage := k.RegConfig.Repo() + "/fanux/lvscare:latest"
-	yaml := ipvs.LvsStaticPodYaml(k.getVIP(), masterIPs, lvsImage)
+	return k.updateNodeLoadBalancer(masterIPs)
+}
+
+// updateNodeLoadBalancer points the load balancer static pods on nodes to masters, only the load balancer
+// running on nodes needs to know the masters.
+func (k *Runtime) updateNodeLoadBalancer(masters []net.IP) error {
+	yaml, err := k.lb.NodeStaticPod(masters)
+	if err != nil || yaml == "" {
+		return err
+	}
 	eg, _ := errgroup.WithContext(context.Background())
 	for _, node := range k.cluster.GetNodeIPList() {
 		node := node
@@ -473,47 +528,33 @@ func (k *Runtime) deleteMaster(master net.IP) error {
 			ssh, err := k.getHostSSHClient(node)
 			if err != nil {
 				logrus.Errorf("failed to update lvscare static pod on node(%s): %v", node, err)
+				return err
 			}
-			if err := ssh.CmdAsync(node, RemoveLvscareStaticPod, fmt.Sprintf(CreateLvscareStaticPod, yaml)); err != nil {
+			if err := ssh.CmdAsync(node, RemoveLvscareStaticPod, RemoteStaticPodMkdir, fmt.Sprintf(LvscareStaticPodCmd, yaml, LvscareDefaultStaticPodFileName)); err != nil {
 				logrus.Errorf("failed to update lvscare static pod on node(%s): %v", node, err)
+				return err
 			}
-			return err
+			return nil
 		})
 	}
 	return eg.Wait()
 }
 
-func (k *Runtime) GetJoinTokenHashAndKey() error {
-	cmd := fmt.Sprintf(`kubeadm init phase upload-certs --upload-certs -v %d`, k.Vlog)
-	/*
-		I0415 11:45:06.653868   14520 version.go:251] remote version is much newer: v1.21.0; falling back to: stable-1.16
-		[upload-certs] Storing the certificates in Secret "kubeadm-certs" in the "kube-system" Namespace
-		[upload-certs] Using certificate key:
-		8376c70aaaf285b764b3c1a588740728aff493d7c2239684e84a7367c6a437cf
-	*/
-	output, err := k.CmdToString(k.cluster.GetMaster0IP(), cmd, "\r\n")
-	if err != nil {
+// applyLoadBalancerOnMasters writes the static pod of load balancer serving VIP on masters, it must be written
+// after kubeadm init or join, whose preflight checks require the manifests directory to be empty.
+func (k *Runtime) applyLoadBalancerOnMasters(masters []net.IP) error {
+	yaml, err := k.lb.MasterStaticPod()
+	if err != nil || yaml == "" {
 		return err
 	}
-	logrus.Debugf("[globals]decodeCertCmd: %s", output)
-	slice := strings.Split(output, "Using certificate key:")
-	if len(slice) != 2 {
-		return fmt.Errorf("failed to get certifacate key: %s", slice)
-	}
-	key := strings.Replace(slice[1], "\r\n", "", -1)
-	k.CertificateKey = strings.Replace(key, "\n", "", -1)
-	cmd = fmt.Sprintf("kubeadm token create --print-join-command -v %d", k.Vlog)
-
-	ssh, err := k.getHostSSHClient(k.cluster.GetMaster0IP())
-	if err != nil {
-		return fmt.Errorf("failed to get join token hash and key: %v", err)
-	}
-	out, err := ssh.Cmd(k.cluster.GetMaster0IP(), cmd)
-	if err != nil {
-		return fmt.Errorf("failed to create kubeadm join token: %v", err)
+	for _, master := range masters {
+		client, err := k.getHostSSHClient(master)
+		if err != nil {
+			return err
+		}
+		if err := client.CmdAsync(master, fmt.Sprintf(CreateLBStaticPod, yaml, k.lb.Type())); err != nil {
+			return fmt.Errorf("failed to create %s static pod on master(%s): %v", k.lb.Type(), master, err)
+		}
 	}
-
-	k.decodeMaster0Output(out)
-
 	return nil
 }
@@ -20,7 +20,8 @@ import (
 	"net"
 	"strings"
 
-	"github.com/sealerio/sealer/pkg/ipvs"
+	"github.com/sealerio/sealer/common"
+	"github.com/sealerio/sealer/pkg/runtime/kubernetes/kubeadm"
 	utilsnet "github.com/sealerio/sealer/utils/net"
 	"github.com/sealerio/sealer/utils/yaml"
 	"github.com/sirupsen/logrus"
@@ -30,7 +31,7 @@ import (
 )
 
 const (
-	RemoteAddIPVS                   = "seautil ipvs --vs %s:6443 %s --health-path /healthz --health-schem https --run-once"
+	RemoteAddIPVS                   = "seautil ipvs --vs %s %s --health-path /healthz --health-schem https --run-once"
 	RemoteStaticPodMkdir            = "mkdir -p /etc/kubernetes/manifests"
 	RemoteJoinConfig                = `echo "%s" > %s/etc/kubeadm.yml`
 	LvscareDefaultStaticPodFileName = "/etc/kubernetes/manifests/kube-lvscare.yaml"
@@ -43,13 +44,19 @@ const (
 
 func (k *Runtime) joinNodeConfig(nodeIP net.IP) ([]byte, error) {
 	// TODO get join config from config file
-	k.setAPIServerEndpoint(fmt.Sprintf("%s:6443", k.getVIP()))
+	k.setAPIServerEndpoint(net.JoinHostPort(k.getVIP().String(), common.APIServerPort))
 	cGroupDriver, err := k.getCgroupDriverFromShell(nodeIP)
 	if err != nil {
 		return nil, err
 	}
 	k.setCgroupDriver(cGroupDriver)
-	return yaml.MarshalWithDelimiter(k.JoinConfiguration, k.KubeletConfiguration)
+	joinConfig := k.JoinConfiguration
+	joinConfig.NodeRegistration = k.hostNodeRegistration(joinConfig.NodeRegistration, nodeIP, true)
+	versionedJoin, err := kubeadm.ConvertTo(&joinConfig)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.MarshalWithDelimiter(versionedJoin, k.KubeletConfiguration)
 }
 
 func (k *Runtime) joinNodes(nodes []net.IP) error {
@@ -65,26 +72,34 @@ func (k *Runtime) joinNodes(nodes []net.IP) error {
 	if err := k.sendRegistryCert(nodes); err != nil {
 		return err
 	}
-	if err := k.GetJoinTokenHashAndKey(); err != nil {
+	if err := k.createJoinToken(); err != nil {
 		return err
 	}
-	var masters string
+	defer k.deleteJoinToken()
+	var lbCmds []string
 	eg, _ := errgroup.WithContext(context.Background())
-	for _, master := range k.cluster.GetMasterIPList() {
-		masters += fmt.Sprintf(" --rs %s:6443", master)
+	if k.isLvscare() {
+		var masters string
+		for _, master := range k.cluster.GetMasterIPList() {
+			masters += fmt.Sprintf(" --rs %s", net.JoinHostPort(master.String(), common.APIServerPort))
+		}
+		lbCmds = append(lbCmds, fmt.Sprintf(RemoteAddIPVS, net.JoinHostPort(k.getVIP().String(), common.APIServerPort), masters))
+	}
+	staticPodCmds := []string{RemoteStaticPodMkdir}
+	lbYaml, err := k.lb.NodeStaticPod(k.cluster.GetMasterIPList())
+	if err != nil {
+		return err
+	}
+	if lbYaml != "" {
+		staticPodCmds = append(staticPodCmds, fmt.Sprintf(LvscareStaticPodCmd, lbYaml, LvscareDefaultStaticPodFileName))
 	}
-	ipvsCmd := fmt.Sprintf(RemoteAddIPVS, k.getVIP(), masters)
 
-	k.setAPIServerEndpoint(fmt.Sprintf("%s:6443", k.getVIP()))
+	k.setAPIServerEndpoint(net.JoinHostPort(k.getVIP().String(), common.APIServerPort))
 	k.cleanJoinLocalAPIEndPoint()
 
-	addRegistryHostsAndLogin := k.addRegistryDomainToHosts()
-	if k.RegConfig.Domain != SeaHub {
-		addSeaHubHost := fmt.Sprintf(RemoteAddEtcHosts, k.RegConfig.IP.String()+" "+SeaHub, k.RegConfig.IP.String()+" "+SeaHub)
-		addRegistryHostsAndLogin = fmt.Sprintf("%s && %s", addRegistryHostsAndLogin, addSeaHubHost)
-	}
-	if k.RegConfig.Username != "" && k.RegConfig.Password != "" {
-		addRegistryHostsAndLogin = fmt.Sprintf("%s && %s", addRegistryHostsAndLogin, k.GenLoginCommand())
+	var registryHostsCmds []string
+	if cmd := k.addRegistryDomainToHosts(nil); cmd != "" {
+		registryHostsCmds = append(registryHostsCmds, cmd)
 	}
 	for _, node := range nodes {
 		node := node
@@ -102,14 +117,17 @@ func (k *Runtime) joinNodes(nodes []net.IP) error {
 			cmdWriteJoinConfig := fmt.Sprintf(RemoteJoinConfig, string(joinConfig), k.getRootfs())
 			cmdHosts := fmt.Sprintf(RemoteAddIPVSEtcHosts, k.getVIP(), k.getAPIServerDomain())
 			cmd := k.Command(k.getKubeVersion(), JoinNode)
-			lvsImage := k.RegConfig.Repo() + "/fanux/lvscare:latest"
-			yaml := ipvs.LvsStaticPodYaml(k.getVIP(), k.cluster.GetMasterIPList(), lvsImage)
-			lvscareStaticCmd := fmt.Sprintf(LvscareStaticPodCmd, yaml, LvscareDefaultStaticPodFileName)
 			ssh, err := k.getHostSSHClient(node)
 			if err != nil {
 				return fmt.Errorf("failed to join node %s: %v", node, err)
 			}
-			if err := ssh.CmdAsync(node, addRegistryHostsAndLogin, cmdWriteJoinConfig, cmdHosts, ipvsCmd, cmd, RemoteStaticPodMkdir, lvscareStaticCmd); err != nil {
+			registryCmds, err := k.registryConfigCommands(node)
+			if err != nil {
+				return fmt.Errorf("failed to join node %s: %v", node, err)
+			}
+			joinCmds := append(append(append([]string{}, registryHostsCmds...), registryCmds...), cmdWriteJoinConfig, cmdHosts)
+			joinCmds = append(append(append(joinCmds, lbCmds...), cmd), staticPodCmds...)
+			if err := ssh.CmdAsync(node, joinCmds...); err != nil {
 				return fmt.Errorf("failed to join node %s: %v", node, err)
 			}
 			logrus.Infof("Succeeded in joining %s as worker", node)
@@ -147,34 +165,37 @@ func (k *Runtime) deleteNode(node net.IP) error {
 	if err != nil {
 		return fmt.Errorf("failed to delete node: %v", err)
 	}
-	remoteCleanCmds := []string{fmt.Sprintf(RemoteCleanMasterOrNode, vlogToStr(k.Vlog)),
-		fmt.Sprintf(RemoteRemoveAPIServerEtcHost, k.RegConfig.Domain),
-		fmt.Sprintf(RemoteRemoveAPIServerEtcHost, SeaHub),
-		fmt.Sprintf(RemoteRemoveRegistryCerts, k.RegConfig.Domain),
-		fmt.Sprintf(RemoteRemoveRegistryCerts, SeaHub),
-		fmt.Sprintf(RemoteRemoveAPIServerEtcHost, k.getAPIServerDomain())}
+	var operational net.IP
+	if len(k.cluster.GetMasterIPList()) > 0 {
+		if operational, err = k.getOperationalMaster(); err != nil {
+			return err
+		}
+	}
+
+	remoteCleanCmds := append([]string{fmt.Sprintf(RemoteCleanMasterOrNode, vlogToStr(k.Vlog))}, k.cleanRegistryCommands()...)
+	remoteCleanCmds = append(remoteCleanCmds, fmt.Sprintf(RemoteRemoveAPIServerEtcHost, k.getAPIServerDomain()))
 	address, err := utilsnet.GetLocalHostAddresses()
 	//if the node to be removed is the execution machine, kubelet, ~./kube and ApiServer host will be added
-	if err != nil || !utilsnet.IsLocalIP(node, address) {
+	if err != nil || !utilsnet.IsLocalIP(node, address) || operational == nil {
 		remoteCleanCmds = append(remoteCleanCmds, RemoveKubeConfig)
 	} else {
-		apiServerHost := getAPIServerHost(k.cluster.GetMaster0IP(), k.getAPIServerDomain())
+		apiServerHost := getAPIServerHost(operational, k.getAPIServerDomain())
 		remoteCleanCmds = append(remoteCleanCmds, fmt.Sprintf(RemoteAddEtcHosts, apiServerHost, apiServerHost))
 	}
 	if err := ssh.CmdAsync(node, remoteCleanCmds...); err != nil {
 		return err
 	}
 	//remove node
-	if len(k.cluster.GetMasterIPList()) > 0 {
-		hostname, err := k.isHostName(k.cluster.GetMaster0IP(), node)
+	if operational != nil {
+		hostname, err := k.isHostName(operational, node)
 		if err != nil {
 			return err
 		}
-		ssh, err := k.getHostSSHClient(k.cluster.GetMaster0IP())
+		ssh, err := k.getHostSSHClient(operational)
 		if err != nil {
-			return fmt.Errorf("failed to get master0 ssh client(%s): %v", k.cluster.GetMaster0IP(), err)
+			return fmt.Errorf("failed to get ssh client of master %s: %v", operational, err)
 		}
-		if err := ssh.CmdAsync(k.cluster.GetMaster0IP(), fmt.Sprintf(KubeDeleteNode, strings.TrimSpace(hostname))); err != nil {
+		if err := ssh.CmdAsync(operational, fmt.Sprintf(KubeDeleteNode, strings.TrimSpace(hostname))); err != nil {
 			return fmt.Errorf("failed to delete node %s: %v", hostname, err)
 		}
 	}
@@ -182,7 +203,12 @@ func (k *Runtime) deleteNode(node net.IP) error {
 	return nil
 }
 
+// checkMultiNetworkAddVIPRoute adds the route of VIP via node if node has multiple networks, which is only
+// required by lvscare.
 func (k *Runtime) checkMultiNetworkAddVIPRoute(node net.IP) error {
+	if !k.isLvscare() {
+		return nil
+	}
 	sshClient, err := k.getHostSSHClient(node)
 	if err != nil {
 		return err
@@ -199,6 +225,9 @@ func (k *Runtime) checkMultiNetworkAddVIPRoute(node net.IP) error {
 }
 
 func (k *Runtime) deleteVIPRouteIfExist(node net.IP) error {
+	if !k.isLvscare() {
+		return nil
+	}
 	sshClient, err := k.getHostSSHClient(node)
 	if err != nil {
 		return err
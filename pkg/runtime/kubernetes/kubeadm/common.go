@@ -14,6 +14,13 @@
 
 package kubeadm
 
+// kubeadm api versions, v1beta3 is supported since kubernetes v1.22.0.
+const (
+	V1beta1 = "kubeadm.k8s.io/v1beta1"
+	V1beta2 = "kubeadm.k8s.io/v1beta2"
+	V1beta3 = "kubeadm.k8s.io/v1beta3"
+)
+
 const (
 	InitConfiguration      = "InitConfiguration"
 	JoinConfiguration      = "JoinConfiguration"
@@ -15,10 +15,12 @@
 package kubeadm
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 
 	"github.com/sealerio/sealer/pkg/runtime/kubernetes/kubeadm/v1beta2"
+	"github.com/sealerio/sealer/pkg/runtime/kubernetes/kubeadm/v1beta3"
 
 	"github.com/sealerio/sealer/utils"
 	osi "github.com/sealerio/sealer/utils/os"
@@ -38,11 +40,13 @@ import (
 
 //nolint
 type KubeadmConfig struct {
-	v1beta2.InitConfiguration
-	v1beta2.ClusterConfiguration
-	v1alpha1.KubeProxyConfiguration
-	v1beta1.KubeletConfiguration
-	v1beta2.JoinConfiguration
+	// the configurations are decoded and marshaled one by one, KubeadmConfig itself is never serialized, so the
+	// embedded configurations are not inlined in json to avoid their ambiguous fields, like kind and skipPhases.
+	v1beta3.InitConfiguration       `json:"-"`
+	v1beta3.ClusterConfiguration    `json:"-"`
+	v1alpha1.KubeProxyConfiguration `json:"-"`
+	v1beta1.KubeletConfiguration    `json:"-"`
+	v1beta3.JoinConfiguration       `json:"-"`
 }
 
 // LoadFromClusterfile :Load KubeadmConfig from Clusterfile.
@@ -83,19 +87,61 @@ func (k *KubeadmConfig) Merge(kubeadmYamlPath string) error {
 	return k.Merge("")
 }
 
+// SetAPIVersion sets the apiVersion of init, cluster and join configurations, they are converted by ConvertTo
+// before being rendered.
+func (k *KubeadmConfig) SetAPIVersion(apiVersion string) {
+	k.InitConfiguration.APIVersion = apiVersion
+	k.ClusterConfiguration.APIVersion = apiVersion
+	k.JoinConfiguration.APIVersion = apiVersion
+}
+
+// ConvertTo converts the v1beta3 init, cluster or join configuration to the types of its apiVersion. The versions
+// older than v1beta3 are rendered from v1beta2 types, which v1beta1 shares, so the fields introduced by v1beta3,
+// like skipPhases, patches and imagePullPolicy, are dropped, since kubeadm of these versions rejects them.
+func ConvertTo(config interface{}) (interface{}, error) {
+	var (
+		apiVersion string
+		out        interface{}
+	)
+	switch c := config.(type) {
+	case *v1beta3.InitConfiguration:
+		apiVersion, out = c.APIVersion, &v1beta2.InitConfiguration{}
+	case *v1beta3.ClusterConfiguration:
+		apiVersion, out = c.APIVersion, &v1beta2.ClusterConfiguration{}
+	case *v1beta3.JoinConfiguration:
+		apiVersion, out = c.APIVersion, &v1beta2.JoinConfiguration{}
+	default:
+		return config, nil
+	}
+	if apiVersion == V1beta3 {
+		return config, nil
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, out); err != nil {
+		return nil, fmt.Errorf("failed to convert kubeadm config to %s: %v", apiVersion, err)
+	}
+	return out, nil
+}
+
+// LoadKubeadmConfigs loads the kubeadm configurations of v1beta1, v1beta2 or v1beta3, they are all decoded into
+// v1beta3 types, and the fields removed by v1beta3, like useHyperKubeImage and dns.type, are ignored.
 func LoadKubeadmConfigs(arg string, decode func(arg string, kind string) (interface{}, error)) (*KubeadmConfig, error) {
 	kubeadmConfig := &KubeadmConfig{}
 	initConfig, err := decode(arg, InitConfiguration)
 	if err != nil && err != io.EOF {
 		return nil, err
 	} else if initConfig != nil {
-		kubeadmConfig.InitConfiguration = *initConfig.(*v1beta2.InitConfiguration)
+		kubeadmConfig.InitConfiguration = *initConfig.(*v1beta3.InitConfiguration)
 	}
 	clusterConfig, err := decode(arg, ClusterConfiguration)
 	if err != nil && err != io.EOF {
 		return nil, err
 	} else if clusterConfig != nil {
-		kubeadmConfig.ClusterConfiguration = *clusterConfig.(*v1beta2.ClusterConfiguration)
+		kubeadmConfig.ClusterConfiguration = *clusterConfig.(*v1beta3.ClusterConfiguration)
 	}
 	kubeProxyConfig, err := decode(arg, KubeProxyConfiguration)
 	if err != nil && err != io.EOF {
@@ -113,7 +159,7 @@ func LoadKubeadmConfigs(arg string, decode func(arg string, kind string) (interf
 	if err != nil && err != io.EOF {
 		return nil, err
 	} else if joinConfig != nil {
-		kubeadmConfig.JoinConfiguration = *joinConfig.(*v1beta2.JoinConfiguration)
+		kubeadmConfig.JoinConfiguration = *joinConfig.(*v1beta3.JoinConfiguration)
 	}
 	return kubeadmConfig, nil
 }
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/sirupsen/logrus"
@@ -430,3 +431,103 @@ func TestKubeadmConfig_Merge(t *testing.T) {
 		})
 	}
 }
+
+func TestKubeadmConfig_MergeVersioned(t *testing.T) {
+	// the v1beta3 configurations in Clusterfile are merged with the v1beta2 default configurations.
+	clusterfileConfig := `apiVersion: kubeadm.k8s.io/v1beta3
+kind: InitConfiguration
+skipPhases:
+  - addon/kube-proxy
+nodeRegistration:
+  imagePullPolicy: IfNotPresent
+---
+apiVersion: kubeadm.k8s.io/v1beta3
+kind: JoinConfiguration
+skipPhases:
+  - preflight`
+	type args struct {
+		defaultKubeadmConfig []byte
+		apiVersion           string
+	}
+	tests := []struct {
+		name           string
+		args           args
+		wantRendered   []string
+		wantDropped    []string
+		wantRepository string
+		wantErr        bool
+	}{
+		{
+			name: "test kubeadm config merge for v1beta2",
+			args: args{
+				defaultKubeadmConfig: []byte(testKubeadmConfigYaml),
+				apiVersion:           V1beta2,
+			},
+			wantRendered:   []string{"apiVersion: " + V1beta2, "type: \"\""},
+			wantDropped:    []string{"skipPhases", "imagePullPolicy"},
+			wantRepository: "sea.hub:5000/library",
+		},
+		{
+			name: "test kubeadm config merge for v1beta3",
+			args: args{
+				defaultKubeadmConfig: []byte(testKubeadmConfigYaml),
+				apiVersion:           V1beta3,
+			},
+			wantRendered:   []string{"apiVersion: " + V1beta3, "- addon/kube-proxy", "- preflight", "imagePullPolicy: IfNotPresent"},
+			wantRepository: "sea.hub:5000/library",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := LoadKubeadmConfigs(clusterfileConfig, utils.DecodeCRDFromString)
+			if err != nil {
+				t.Fatalf("failed to load v1beta3 kubeadm config: %v", err)
+			}
+			testfile := "test-kubeadm.yml"
+			err = ioutil.WriteFile(testfile, tt.args.defaultKubeadmConfig, 0644)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("WriteFile %s error = %v, wantErr %v", testfile, err, tt.wantErr)
+				return
+			}
+			defer func() {
+				err = os.Remove(testfile)
+				if err != nil {
+					t.Errorf("remove file %s error = %v, wantErr %v", testfile, err, tt.wantErr)
+					return
+				}
+			}()
+			err = k.Merge(testfile)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Merge() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if k.ClusterConfiguration.ImageRepository != tt.wantRepository {
+				t.Errorf("Merge() imageRepository = %s, want %s", k.ClusterConfiguration.ImageRepository, tt.wantRepository)
+			}
+
+			k.SetAPIVersion(tt.args.apiVersion)
+			var configs []interface{}
+			for _, config := range []interface{}{&k.InitConfiguration, &k.ClusterConfiguration, &k.JoinConfiguration} {
+				versioned, err := ConvertTo(config)
+				if err != nil {
+					t.Fatalf("ConvertTo() error = %v", err)
+				}
+				configs = append(configs, versioned)
+			}
+			out, err := yaml.MarshalWithDelimiter(configs...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.wantRendered {
+				if !strings.Contains(string(out), want) {
+					t.Errorf("rendered config should contain %q: %s", want, out)
+				}
+			}
+			for _, dropped := range tt.wantDropped {
+				if strings.Contains(string(out), dropped) {
+					t.Errorf("rendered config should not contain %q: %s", dropped, out)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/sealerio/sealer/pkg/runtime/kubernetes/kubeadm/v1beta3"
+	v2 "github.com/sealerio/sealer/types/api/v2"
+)
+
+const (
+	// KubeletNodeLabelsArg is the kubelet flag registering the node with labels.
+	KubeletNodeLabelsArg = "node-labels"
+	// KubeadmFlagsEnvFile is the environment file written by kubeadm at join time, KUBELET_KUBEADM_ARGS in it
+	// holds the kubelet extra args of the node registration.
+	KubeadmFlagsEnvFile    = "/var/lib/kubelet/kubeadm-flags.env"
+	KubeadmFlagsEnvKey     = "KUBELET_KUBEADM_ARGS"
+	RemoteReadKubeadmFlags = "cat " + KubeadmFlagsEnvFile
+	// RemoteUpdateKubeadmFlags writes the shell quoted content to kubeadm-flags.env and restarts kubelet to apply it.
+	RemoteUpdateKubeadmFlags = "printf '%%s' %s > " + KubeadmFlagsEnvFile + " && systemctl restart kubelet"
+)
+
+// hostNodeRegistration returns registration with the kubelet extra args and labels declared on host ip merged in.
+// The labels in kubernetes.io and k8s.io namespaces are skipped as kubelet is not allowed to set most of them,
+// they are set after join by the applier. The taints are only merged if withTaints, since kubeadm defaults the
+// taints of control-plane only if they are unset.
+func (k *Runtime) hostNodeRegistration(registration v1beta3.NodeRegistrationOptions, ip net.IP, withTaints bool) v1beta3.NodeRegistrationOptions {
+	host := k.cluster.GetHostByIP(ip)
+	if host == nil {
+		return registration
+	}
+
+	args := map[string]string{}
+	for key, value := range registration.KubeletExtraArgs {
+		args[key] = value
+	}
+	for key, value := range host.KubeletExtraArgs {
+		args[key] = value
+	}
+	var labels []string
+	if args[KubeletNodeLabelsArg] != "" {
+		labels = append(labels, args[KubeletNodeLabelsArg])
+	}
+	for key, value := range host.Labels {
+		if !isKubernetesLabel(key) {
+			labels = append(labels, fmt.Sprintf("%s=%s", key, value))
+		}
+	}
+	sort.Strings(labels)
+	if len(labels) != 0 {
+		args[KubeletNodeLabelsArg] = strings.Join(labels, ",")
+	}
+	if len(args) != 0 {
+		registration.KubeletExtraArgs = args
+	}
+
+	if withTaints && len(host.Taints) != 0 {
+		registration.Taints = append(append([]v1.Taint{}, registration.Taints...), host.Taints...)
+	}
+	return registration
+}
+
+// UpdateKubeletExtraArgs applies the kubelet extra args declared on hosts, which have joined the cluster. The
+// flags of the args declared in previous are replaced in KUBELET_KUBEADM_ARGS of kubeadm-flags.env, so the removed
+// args fall back to the ones of node registration, and the flags set by kubeadm itself are kept.
+func (k *Runtime) UpdateKubeletExtraArgs(previous *v2.Cluster, hosts []net.IP) error {
+	eg, _ := errgroup.WithContext(context.Background())
+	for _, ip := range hosts {
+		ip := ip
+		if k.cluster.GetHostByIP(ip) == nil {
+			continue
+		}
+		var previousArgs map[string]string
+		if previous != nil && previous.GetHostByIP(ip) != nil {
+			previousArgs = previous.GetHostByIP(ip).KubeletExtraArgs
+		}
+		registration := k.JoinConfiguration.NodeRegistration
+		if ip.Equal(k.cluster.GetMaster0IP()) {
+			registration = k.InitConfiguration.NodeRegistration
+		}
+		args := k.hostNodeRegistration(registration, ip, false).KubeletExtraArgs
+		eg.Go(func() error {
+			ssh, err := k.getHostSSHClient(ip)
+			if err != nil {
+				return fmt.Errorf("failed to update kubelet extra args of %s: %v", ip, err)
+			}
+			out, err := ssh.Cmd(ip, RemoteReadKubeadmFlags)
+			if err != nil {
+				return fmt.Errorf("failed to read %s of %s: %v", KubeadmFlagsEnvFile, ip, err)
+			}
+			env, err := kubeadmFlagsEnv(string(out), previousArgs, args)
+			if err != nil {
+				return fmt.Errorf("failed to update kubelet extra args of %s: %v", ip, err)
+			}
+			if err = ssh.CmdAsync(ip, fmt.Sprintf(RemoteUpdateKubeadmFlags, shellQuote(env))); err != nil {
+				return fmt.Errorf("failed to update kubelet extra args of %s: %v", ip, err)
+			}
+			return nil
+		})
+	}
+	return eg.Wait()
+}
+
+// kubeadmFlagsEnv returns the content of kubeadm-flags.env, in which the flags of previous and args are removed
+// from KUBELET_KUBEADM_ARGS and the ones of args are appended sorted by name. The values can not contain spaces,
+// quotes or backslashes, since systemd parses the quotes of environment file and splits the flags by spaces.
+func kubeadmFlagsEnv(content string, previous, args map[string]string) (string, error) {
+	var flags []string
+	for key, value := range args {
+		if strings.ContainsAny(value, " \t\n\"\\") {
+			return "", fmt.Errorf("kubelet extra arg %s=%q can not contain spaces, quotes or backslashes", key, value)
+		}
+		flags = append(flags, fmt.Sprintf("--%s=%s", key, value))
+	}
+	sort.Strings(flags)
+
+	prefix := KubeadmFlagsEnvKey + "="
+	var lines []string
+	found := false
+	for _, line := range strings.Split(strings.TrimRight(content, "\n"), "\n") {
+		if !strings.HasPrefix(line, prefix) {
+			if line != "" {
+				lines = append(lines, line)
+			}
+			continue
+		}
+		found = true
+		var kept []string
+		for _, flag := range strings.Fields(strings.Trim(strings.TrimPrefix(line, prefix), `"`)) {
+			name := strings.SplitN(strings.TrimLeft(flag, "-"), "=", 2)[0]
+			if _, ok := previous[name]; ok {
+				continue
+			}
+			if _, ok := args[name]; ok {
+				continue
+			}
+			kept = append(kept, flag)
+		}
+		lines = append(lines, fmt.Sprintf("%s\"%s\"", prefix, strings.Join(append(kept, flags...), " ")))
+	}
+	if !found {
+		lines = append(lines, fmt.Sprintf("%s\"%s\"", prefix, strings.Join(flags, " ")))
+	}
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+// shellQuote quotes s in single quotes for shell, the single quotes in s are escaped.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// isKubernetesLabel returns whether the label key is in the namespaces reserved by kubernetes.
+func isKubernetesLabel(key string) bool {
+	if !strings.Contains(key, "/") {
+		return false
+	}
+	prefix := strings.Split(key, "/")[0]
+	return strings.HasSuffix(prefix, "kubernetes.io") || strings.HasSuffix(prefix, "k8s.io")
+}
@@ -0,0 +1,192 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/sealerio/sealer/pkg/runtime/kubernetes/kubeadm/v1beta3"
+	v2 "github.com/sealerio/sealer/types/api/v2"
+)
+
+func TestHostNodeRegistration(t *testing.T) {
+	masterTaint := v1.Taint{Key: "node-role.kubernetes.io/master", Effect: v1.TaintEffectNoSchedule}
+	gpuTaint := v1.Taint{Key: "gpu", Value: "true", Effect: v1.TaintEffectNoSchedule}
+	cluster := &v2.Cluster{}
+	cluster.Spec.Hosts = []v2.Host{
+		{
+			IPS:              []net.IP{net.ParseIP("192.168.0.2")},
+			Labels:           map[string]string{"zone": "a", "node-role.kubernetes.io/gpu": ""},
+			Taints:           []v1.Taint{gpuTaint},
+			KubeletExtraArgs: map[string]string{"max-pods": "200"},
+		},
+		{
+			IPS: []net.IP{net.ParseIP("192.168.0.3")},
+		},
+	}
+	k := &Runtime{cluster: cluster}
+
+	tests := []struct {
+		name         string
+		registration v1beta3.NodeRegistrationOptions
+		ip           string
+		withTaints   bool
+		want         v1beta3.NodeRegistrationOptions
+	}{
+		{
+			name: "host args and labels are merged",
+			registration: v1beta3.NodeRegistrationOptions{
+				KubeletExtraArgs: map[string]string{"max-pods": "110", KubeletNodeLabelsArg: "disk=ssd", "v": "2"},
+			},
+			ip: "192.168.0.2",
+			want: v1beta3.NodeRegistrationOptions{
+				KubeletExtraArgs: map[string]string{"max-pods": "200", KubeletNodeLabelsArg: "disk=ssd,zone=a", "v": "2"},
+			},
+		},
+		{
+			name:         "taints are appended to the registration",
+			registration: v1beta3.NodeRegistrationOptions{Taints: []v1.Taint{masterTaint}},
+			ip:           "192.168.0.2",
+			withTaints:   true,
+			want: v1beta3.NodeRegistrationOptions{
+				Taints:           []v1.Taint{masterTaint, gpuTaint},
+				KubeletExtraArgs: map[string]string{"max-pods": "200", KubeletNodeLabelsArg: "zone=a"},
+			},
+		},
+		{
+			name:         "taints are not set without withTaints",
+			registration: v1beta3.NodeRegistrationOptions{},
+			ip:           "192.168.0.2",
+			want: v1beta3.NodeRegistrationOptions{
+				KubeletExtraArgs: map[string]string{"max-pods": "200", KubeletNodeLabelsArg: "zone=a"},
+			},
+		},
+		{
+			name:         "host without declarations",
+			registration: v1beta3.NodeRegistrationOptions{CRISocket: DefaultContainerdCRISocket},
+			ip:           "192.168.0.3",
+			withTaints:   true,
+			want:         v1beta3.NodeRegistrationOptions{CRISocket: DefaultContainerdCRISocket},
+		},
+		{
+			name:         "host not in cluster",
+			registration: v1beta3.NodeRegistrationOptions{Name: "node"},
+			ip:           "192.168.0.4",
+			want:         v1beta3.NodeRegistrationOptions{Name: "node"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := k.hostNodeRegistration(tt.registration, net.ParseIP(tt.ip), tt.withTaints); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("hostNodeRegistration() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKubeadmFlagsEnv(t *testing.T) {
+	joined := `KUBELET_KUBEADM_ARGS="--container-runtime=remote --max-pods=200 --pod-infra-container-image=sea.hub:5000/pause:3.6 --node-labels=zone=a"` + "\n"
+	tests := []struct {
+		name     string
+		content  string
+		previous map[string]string
+		args     map[string]string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "changed arg is replaced",
+			content:  joined,
+			previous: map[string]string{"max-pods": "200"},
+			args:     map[string]string{"max-pods": "300", KubeletNodeLabelsArg: "zone=a"},
+			want:     `KUBELET_KUBEADM_ARGS="--container-runtime=remote --pod-infra-container-image=sea.hub:5000/pause:3.6 --max-pods=300 --node-labels=zone=a"` + "\n",
+		},
+		{
+			name:     "removed arg is removed",
+			content:  joined,
+			previous: map[string]string{"max-pods": "200"},
+			args:     map[string]string{KubeletNodeLabelsArg: "zone=a"},
+			want:     `KUBELET_KUBEADM_ARGS="--container-runtime=remote --pod-infra-container-image=sea.hub:5000/pause:3.6 --node-labels=zone=a"` + "\n",
+		},
+		{
+			name:    "value with single quote",
+			content: `KUBELET_KUBEADM_ARGS="--container-runtime=remote"`,
+			args:    map[string]string{"eviction-hard": "memory.available<5%", "provider-id": "it's"},
+			want:    `KUBELET_KUBEADM_ARGS="--container-runtime=remote --eviction-hard=memory.available<5% --provider-id=it's"` + "\n",
+		},
+		{
+			name:    "other lines are kept",
+			content: "# written by kubeadm\n" + joined,
+			args:    map[string]string{"max-pods": "200"},
+			want:    "# written by kubeadm\n" + `KUBELET_KUBEADM_ARGS="--container-runtime=remote --pod-infra-container-image=sea.hub:5000/pause:3.6 --node-labels=zone=a --max-pods=200"` + "\n",
+		},
+		{
+			name: "missing file",
+			args: map[string]string{"max-pods": "200"},
+			want: `KUBELET_KUBEADM_ARGS="--max-pods=200"` + "\n",
+		},
+		{
+			name:    "value with space",
+			content: joined,
+			args:    map[string]string{"system-reserved": "cpu=1, memory=1Gi"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := kubeadmFlagsEnv(tt.content, tt.previous, tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("kubeadmFlagsEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("kubeadmFlagsEnv() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemoteUpdateKubeadmFlags(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sealer-kubelet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	file := filepath.Join(dir, "kubeadm-flags.env")
+	env := `KUBELET_KUBEADM_ARGS="--provider-id=it's --eviction-hard=memory.available<5%"` + "\n"
+	cmd := strings.ReplaceAll(fmt.Sprintf(RemoteUpdateKubeadmFlags, shellQuote(env)), KubeadmFlagsEnvFile, file)
+	cmd = strings.ReplaceAll(cmd, "systemctl restart kubelet", "true")
+	if out, err := exec.Command("/bin/sh", "-c", cmd).CombinedOutput(); err != nil {
+		t.Fatalf("failed to run command: %v, %s", err, out)
+	}
+	got, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != env {
+		t.Errorf("%s = %q, want %q", KubeadmFlagsEnvFile, got, env)
+	}
+}
@@ -15,27 +15,149 @@
 package kubernetes
 
 import (
+	"context"
 	"fmt"
+	"net"
 	"path/filepath"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/sealerio/sealer/common"
+	utilsnet "github.com/sealerio/sealer/utils/net"
 )
 
 const (
 	RegistryName                = "sealer-registry"
+	RegistryCAName              = "registry-ca"
 	SeaHub                      = "sea.hub"
 	DefaultRegistryHtPasswdFile = "registry_htpasswd"
 	DockerLoginCommand          = "nerdctl login -u %s -p %s %s && " + KubeletAuthCommand
 	KubeletAuthCommand          = "cp /root/.docker/config.json /var/lib/kubelet"
+	RestartRegistryCommand      = "if docker inspect %s >/dev/null 2>&1;then docker restart %[1]s;else nerdctl restart %[1]s;fi"
 	DeleteRegistryCommand       = "if docker inspect %s 2>/dev/null;then docker rm -f %[1]s;fi && ((! nerdctl ps -a 2>/dev/null |grep %[1]s) || (nerdctl stop %[1]s && nerdctl rmi -f %[1]s))"
 )
 
-func (k *Runtime) addRegistryDomainToHosts() (host string) {
-	content := fmt.Sprintf("%s %s", k.RegConfig.IP.String(), k.RegConfig.Domain)
-	return fmt.Sprintf(RemoteAddEtcHosts, content, content)
+// registryNodes returns the hosts which run the registry, it is every master or the configured nodes in HA mode.
+func (k *Runtime) registryNodes() []net.IP {
+	return k.RegConfig.GetRegistryNodes(k.cluster.GetMasterIPList())
+}
+
+// addRegistryDomainToHosts resolves the registry domain and sea.hub to all registry nodes, and host itself goes
+// first if it runs the registry. docker and containerd try the next address when the former one is unreachable,
+// so image pulls fail over to another registry node.
+func (k *Runtime) addRegistryDomainToHosts(host net.IP) string {
+	nodes := k.registryNodes()
+	if !utilsnet.NotInIPList(host, nodes) {
+		nodes = append([]net.IP{host}, removeIP(nodes, host)...)
+	}
+
+	var cmds []string
+	for _, ip := range nodes {
+		content := fmt.Sprintf("%s %s", ip.String(), k.RegConfig.Domain)
+		cmds = append(cmds, fmt.Sprintf(RemoteAddEtcHosts, content, content))
+		if k.RegConfig.Domain != SeaHub {
+			content = fmt.Sprintf("%s %s", ip.String(), SeaHub)
+			cmds = append(cmds, fmt.Sprintf(RemoteAddEtcHosts, content, content))
+		}
+	}
+	return strings.Join(cmds, " && ")
 }
 
 // ApplyRegistry Only use this for join and init, due to the initiation operations.
 func (k *Runtime) ApplyRegistry() error {
-	ssh, err := k.getHostSSHClient(k.RegConfig.IP)
+	if k.RegConfig.External {
+		if err := k.pushImagesToExternalRegistry(); err != nil {
+			return err
+		}
+	}
+
+	eg, _ := errgroup.WithContext(context.Background())
+	for _, node := range k.registryNodes() {
+		node := node
+		eg.Go(func() error {
+			return k.startRegistry(node)
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return err
+	}
+
+	ssh, err := k.getHostSSHClient(k.cluster.GetMaster0IP())
+	if err != nil {
+		return fmt.Errorf("failed to get master0 ssh client: %v", err)
+	}
+	if cmd := k.addRegistryDomainToHosts(k.cluster.GetMaster0IP()); cmd != "" {
+		if err = ssh.CmdAsync(k.cluster.GetMaster0IP(), cmd); err != nil {
+			return err
+		}
+	}
+	cmds, err := k.registryConfigCommands(k.cluster.GetMaster0IP())
+	if err != nil {
+		return err
+	}
+	return ssh.CmdAsync(k.cluster.GetMaster0IP(), cmds...)
+}
+
+// pushImagesToExternalRegistry pushes the images cached in ClusterImage of every platform into the external registry,
+// instead of starting the built-in registry.
+func (k *Runtime) pushImagesToExternalRegistry() error {
+	registryDirs, err := filepath.Glob(filepath.Join(filepath.Dir(k.getImageMountDir()), "*", common.RegistryDirName))
+	if err != nil {
+		return err
+	}
+	for _, dir := range registryDirs {
+		if err = k.RegConfig.PushImages(dir); err != nil {
+			return fmt.Errorf("failed to push images to external registry %s: %v", k.RegConfig.Repo(), err)
+		}
+	}
+	return nil
+}
+
+// applyRegistryOnMasters starts the registry on the joining masters which are registry nodes in HA mode.
+func (k *Runtime) applyRegistryOnMasters(masters []net.IP) error {
+	if !k.RegConfig.HA {
+		return nil
+	}
+
+	var nodes []net.IP
+	for _, master := range masters {
+		if !utilsnet.NotInIPList(master, k.registryNodes()) {
+			nodes = append(nodes, master)
+		}
+	}
+	if len(nodes) == 0 {
+		return nil
+	}
+	if err := k.sendFileToHosts(nodes, k.getCertsDir(), filepath.Join(k.getRootfs(), "certs")); err != nil {
+		return err
+	}
+
+	eg, _ := errgroup.WithContext(context.Background())
+	for _, node := range nodes {
+		node := node
+		eg.Go(func() error {
+			return k.startRegistry(node)
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return err
+	}
+	// the joining masters only have the registry data of ClusterImage, sync the images pushed to
+	// the running registry nodes onto them.
+	return k.syncRegistryReplicas()
+}
+
+// syncRegistryReplicas syncs the images among registry nodes in HA mode.
+func (k *Runtime) syncRegistryReplicas() error {
+	if !k.RegConfig.HA {
+		return nil
+	}
+	return k.RegConfig.SyncReplicas(k.registryNodes())
+}
+
+func (k *Runtime) startRegistry(host net.IP) error {
+	ssh, err := k.getHostSSHClient(host)
 	if err != nil {
 		return fmt.Errorf("failed to get registry ssh client: %v", err)
 	}
@@ -45,40 +167,64 @@ func (k *Runtime) ApplyRegistry() error {
 		if err != nil {
 			return err
 		}
-		err = ssh.CmdAsync(k.RegConfig.IP, fmt.Sprintf("echo '%s' > %s", htpasswd, filepath.Join(k.getRootfs(), "etc", DefaultRegistryHtPasswdFile)))
+		err = ssh.CmdAsync(host, fmt.Sprintf("echo '%s' > %s", htpasswd, filepath.Join(k.getRootfs(), "etc", DefaultRegistryHtPasswdFile)))
 		if err != nil {
 			return err
 		}
 	}
 	initRegistry := fmt.Sprintf("cd %s/scripts && ./init-registry.sh %s %s %s", k.getRootfs(), k.RegConfig.Port, fmt.Sprintf("%s/registry", k.getRootfs()), k.RegConfig.Domain)
-	addRegistryHosts := k.addRegistryDomainToHosts()
-	if k.RegConfig.Domain != SeaHub {
-		addSeaHubHosts := fmt.Sprintf(RemoteAddEtcHosts, k.RegConfig.IP.String()+" "+SeaHub, k.RegConfig.IP.String()+" "+SeaHub)
-		addRegistryHosts = fmt.Sprintf("%s && %s", addRegistryHosts, addSeaHubHosts)
-	}
-	if err = ssh.CmdAsync(k.RegConfig.IP, initRegistry); err != nil {
-		return err
-	}
-	if err = ssh.CmdAsync(k.cluster.GetMaster0IP(), addRegistryHosts); err != nil {
-		return err
-	}
-	if k.RegConfig.Username == "" || k.RegConfig.Password == "" {
-		return nil
-	}
-	return ssh.CmdAsync(k.cluster.GetMaster0IP(), k.GenLoginCommand())
+	return ssh.CmdAsync(host, initRegistry)
 }
 
 func (k *Runtime) GenLoginCommand() string {
+	if k.RegConfig.External {
+		return fmt.Sprintf(DockerLoginCommand, k.RegConfig.Username, k.RegConfig.Password, k.RegConfig.Host())
+	}
 	return fmt.Sprintf("%s && %s",
 		fmt.Sprintf(DockerLoginCommand, k.RegConfig.Username, k.RegConfig.Password, k.RegConfig.Domain+":"+k.RegConfig.Port),
 		fmt.Sprintf(DockerLoginCommand, k.RegConfig.Username, k.RegConfig.Password, SeaHub+":"+k.RegConfig.Port))
 }
 
 func (k *Runtime) DeleteRegistry() error {
-	ssh, err := k.getHostSSHClient(k.RegConfig.IP)
-	if err != nil {
-		return fmt.Errorf("failed to delete registry: %v", err)
+	eg, _ := errgroup.WithContext(context.Background())
+	for _, node := range k.registryNodes() {
+		node := node
+		eg.Go(func() error {
+			ssh, err := k.getHostSSHClient(node)
+			if err != nil {
+				return fmt.Errorf("failed to delete registry: %v", err)
+			}
+			return ssh.CmdAsync(node, fmt.Sprintf(DeleteRegistryCommand, RegistryName))
+		})
+	}
+	return eg.Wait()
+}
+
+// cleanRegistryCommands removes the registry hosts and certs written by sealer,
+// the hosts of external registry are not written by sealer, so they are kept.
+func (k *Runtime) cleanRegistryCommands() []string {
+	if k.RegConfig.External {
+		return []string{fmt.Sprintf(RemoteRemoveRegistryCerts, k.RegConfig.Host()),
+			fmt.Sprintf(RemoteRemoveContainerdHosts, k.RegConfig.Host()),
+			fmt.Sprintf(RemoteRemoveContainerdHosts, containerdDefaultHostDir)}
 	}
+	return []string{
+		fmt.Sprintf(RemoteRemoveAPIServerEtcHost, k.RegConfig.Domain),
+		fmt.Sprintf(RemoteRemoveAPIServerEtcHost, SeaHub),
+		fmt.Sprintf(RemoteRemoveRegistryCerts, k.RegConfig.Domain),
+		fmt.Sprintf(RemoteRemoveRegistryCerts, SeaHub),
+		fmt.Sprintf(RemoteRemoveContainerdHosts, k.RegConfig.Domain),
+		fmt.Sprintf(RemoteRemoveContainerdHosts, SeaHub),
+		fmt.Sprintf(RemoteRemoveContainerdHosts, containerdDefaultHostDir),
+	}
+}
 
-	return ssh.CmdAsync(k.RegConfig.IP, fmt.Sprintf(DeleteRegistryCommand, RegistryName))
+func removeIP(ips []net.IP, ip net.IP) []net.IP {
+	var res []net.IP
+	for _, i := range ips {
+		if !i.Equal(ip) {
+			res = append(res, i)
+		}
+	}
+	return res
 }
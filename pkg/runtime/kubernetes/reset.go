@@ -28,6 +28,7 @@ import (
 func (k *Runtime) reset() error {
 	k.resetNodes(k.cluster.GetNodeIPList())
 	k.resetMasters(k.cluster.GetMasterIPList())
+	k.resetEtcds(k.cluster.GetEtcdIPList())
 	//if the executing machine is not in the cluster
 	if _, err := exec.RunSimpleCmd(fmt.Sprintf(RemoteRemoveAPIServerEtcHost, k.getAPIServerDomain())); err != nil {
 		return err
@@ -70,13 +71,10 @@ func (k *Runtime) resetNode(node net.IP) error {
 	if err != nil {
 		return fmt.Errorf("failed to reset node: %v", err)
 	}
-	if err := ssh.CmdAsync(node, fmt.Sprintf(RemoteCleanMasterOrNode, vlogToStr(k.Vlog)),
+	cmds := []string{fmt.Sprintf(RemoteCleanMasterOrNode, vlogToStr(k.Vlog)),
 		RemoveKubeConfig,
-		fmt.Sprintf(RemoteRemoveAPIServerEtcHost, k.getAPIServerDomain()),
-		fmt.Sprintf(RemoteRemoveAPIServerEtcHost, SeaHub),
-		fmt.Sprintf(RemoteRemoveAPIServerEtcHost, k.RegConfig.Domain),
-		fmt.Sprintf(RemoteRemoveRegistryCerts, k.RegConfig.Domain),
-		fmt.Sprintf(RemoteRemoveRegistryCerts, SeaHub)); err != nil {
+		fmt.Sprintf(RemoteRemoveAPIServerEtcHost, k.getAPIServerDomain())}
+	if err := ssh.CmdAsync(node, append(cmds, k.cleanRegistryCommands()...)...); err != nil {
 		return err
 	}
 	return nil
@@ -30,9 +30,11 @@ import (
 	"time"
 
 	"github.com/sealerio/sealer/common"
+	"github.com/sealerio/sealer/pkg/loadbalancer"
 	"github.com/sealerio/sealer/pkg/runtime"
-	"github.com/sealerio/sealer/pkg/runtime/kubernetes/kubeadm/v1beta2"
+	"github.com/sealerio/sealer/pkg/runtime/kubernetes/kubeadm/v1beta3"
 	v2 "github.com/sealerio/sealer/types/api/v2"
+	utilsnet "github.com/sealerio/sealer/utils/net"
 	"github.com/sealerio/sealer/utils/platform"
 	"github.com/sealerio/sealer/utils/ssh"
 	strUtils "github.com/sealerio/sealer/utils/strings"
@@ -58,6 +60,12 @@ type Runtime struct {
 	cluster *v2.Cluster
 	*kubeadm.KubeadmConfig
 	*Config
+	lb loadbalancer.Interface
+	// operationalMaster is the master running kubectl and kubeadm for the operations after init, see getOperationalMaster.
+	operationalMaster     net.IP
+	operationalMasterLock sync.Mutex
+	// certificateKeyExpiry is when the control-plane certs uploaded by uploadControlPlaneCerts expire.
+	certificateKeyExpiry time.Time
 }
 
 // NewDefaultRuntime arg "clusterfileKubeConfig" is the Clusterfile path/name, runtime need read kubeadm config from it
@@ -75,7 +83,12 @@ func newKubernetesRuntime(cluster *v2.Cluster, clusterFileKubeConfig *kubeadm.Ku
 		},
 		KubeadmConfig: &kubeadm.KubeadmConfig{},
 	}
-	k.Config.RegConfig = registry.GetConfig(k.getImageMountDir(), k.cluster.GetMaster0IP())
+	k.Config.RegConfig = registry.GetClusterConfig(k.getImageMountDir(), k.cluster)
+	lb, err := loadbalancer.NewLoadBalancer(k.cluster.Spec.LoadBalancer, k.cluster.GetMaster0IP(), k.RegConfig.Repo())
+	if err != nil {
+		return nil, err
+	}
+	k.lb = lb
 	k.setCertSANS(append(
 		[]string{"127.0.0.1", k.getAPIServerDomain(), k.getVIP().String()},
 		k.cluster.GetMasterIPStrList()...),
@@ -121,6 +134,13 @@ func (k *Runtime) JoinNodes(newNodesIPList []net.IP) error {
 	return k.joinNodes(newNodesIPList)
 }
 
+func (k *Runtime) JoinEtcds(newEtcdsIPList []net.IP) error {
+	if len(newEtcdsIPList) != 0 {
+		logrus.Infof("%s will be added as etcd", newEtcdsIPList)
+	}
+	return k.joinEtcds(newEtcdsIPList)
+}
+
 func (k *Runtime) DeleteMasters(mastersIPList []net.IP) error {
 	if len(mastersIPList) != 0 {
 		logrus.Infof("master %s will be deleted", mastersIPList)
@@ -141,6 +161,54 @@ func (k *Runtime) DeleteNodes(nodesIPList []net.IP) error {
 	return k.deleteNodes(nodesIPList)
 }
 
+func (k *Runtime) DeleteEtcds(etcdsIPList []net.IP) error {
+	if len(etcdsIPList) != 0 {
+		logrus.Infof("etcd %s will be deleted", etcdsIPList)
+		if err := k.confirmDeleteNodes(); err != nil {
+			return err
+		}
+	}
+	return k.deleteEtcds(etcdsIPList)
+}
+
+func (k *Runtime) ForceDeleteMasters(mastersIPList []net.IP) error {
+	if len(mastersIPList) != 0 {
+		logrus.Infof("master %s will be deleted by force", mastersIPList)
+		if err := k.confirmDeleteNodes(); err != nil {
+			return err
+		}
+	}
+	return k.forceDeleteMasters(mastersIPList)
+}
+
+func (k *Runtime) ForceDeleteNodes(nodesIPList []net.IP) error {
+	if len(nodesIPList) != 0 {
+		logrus.Infof("worker %s will be deleted by force", nodesIPList)
+		if err := k.confirmDeleteNodes(); err != nil {
+			return err
+		}
+	}
+	return k.forceDeleteNodes(nodesIPList)
+}
+
+func (k *Runtime) Stop(snapshotEtcd bool) error {
+	logrus.Infof("Start to stop cluster: master %s, node %s", k.cluster.GetMasterIPList(), k.cluster.GetNodeIPList())
+	if err := k.stop(snapshotEtcd); err != nil {
+		return err
+	}
+	logrus.Infof("Succeeded in stopping cluster %s", k.cluster.Name)
+	return nil
+}
+
+func (k *Runtime) Start(timeout time.Duration) error {
+	logrus.Infof("Start to start cluster: master %s, node %s", k.cluster.GetMasterIPList(), k.cluster.GetNodeIPList())
+	if err := k.start(timeout); err != nil {
+		return err
+	}
+	logrus.Infof("Succeeded in starting cluster %s, all nodes are ready", k.cluster.Name)
+	return nil
+}
+
 func (k *Runtime) confirmDeleteNodes() error {
 	if !ForceDelete {
 		if pass, err := utils.ConfirmOperation("Are you sure to delete these nodes? "); err != nil {
@@ -248,8 +316,44 @@ func (k *Runtime) getKubeVersion() string {
 	return k.KubernetesVersion
 }
 
+// getVIP returns the VIP of apiserver on nodes, which is served by the control-plane load balancer.
 func (k *Runtime) getVIP() net.IP {
-	return net.ParseIP(DefaultVIP)
+	return k.lb.VIP()
+}
+
+// getOperationalMaster returns a master with healthy apiserver and etcd to run kubectl and kubeadm on, which is
+// master0 unless it is down. The masters in excluded, which are being deleted, are skipped. The result is cached.
+func (k *Runtime) getOperationalMaster(excluded ...net.IP) (net.IP, error) {
+	k.operationalMasterLock.Lock()
+	defer k.operationalMasterLock.Unlock()
+	if k.operationalMaster != nil && utilsnet.NotInIPList(k.operationalMaster, excluded) {
+		return k.operationalMaster, nil
+	}
+	master, err := runtime.GetOperationalMaster(k.cluster, excluded...)
+	if err != nil {
+		return nil, err
+	}
+	if !master.Equal(k.cluster.GetMaster0IP()) {
+		logrus.Infof("master0 %s is not operational, %s is used instead", k.cluster.GetMaster0IP(), master)
+	}
+	k.operationalMaster = master
+	return master, nil
+}
+
+// getJoinMasterIP returns the master which the new masters join to, it is the operational master which creates
+// the join token, or master0 before that.
+func (k *Runtime) getJoinMasterIP() net.IP {
+	k.operationalMasterLock.Lock()
+	defer k.operationalMasterLock.Unlock()
+	if k.operationalMaster != nil {
+		return k.operationalMaster
+	}
+	return k.cluster.GetMaster0IP()
+}
+
+// isLvscare returns true if the VIP is balanced by lvscare on each node, which requires the IPVS rules and routes of VIP.
+func (k *Runtime) isLvscare() bool {
+	return k.lb.Type() == loadbalancer.LVSCare
 }
 
 func (k *Runtime) getJoinToken() string {
@@ -261,7 +365,7 @@ func (k *Runtime) getJoinToken() string {
 
 func (k *Runtime) setJoinToken(token string) {
 	if k.Discovery.BootstrapToken == nil {
-		k.Discovery.BootstrapToken = &v1beta2.BootstrapTokenDiscovery{}
+		k.Discovery.BootstrapToken = &v1beta3.BootstrapTokenDiscovery{}
 	}
 	k.Discovery.BootstrapToken.Token = token
 }
@@ -275,14 +379,14 @@ func (k *Runtime) getTokenCaCertHash() string {
 
 func (k *Runtime) setTokenCaCertHash(tokenCaCertHash []string) {
 	if k.Discovery.BootstrapToken == nil {
-		k.Discovery.BootstrapToken = &v1beta2.BootstrapTokenDiscovery{}
+		k.Discovery.BootstrapToken = &v1beta3.BootstrapTokenDiscovery{}
 	}
 	k.Discovery.BootstrapToken.CACertHashes = tokenCaCertHash
 }
 
 func (k *Runtime) getCertificateKey() string {
-	if k.JoinConfiguration.ControlPlane == nil {
-		return ""
+	if k.JoinConfiguration.ControlPlane == nil || k.JoinConfiguration.ControlPlane.CertificateKey == "" {
+		return k.CertificateKey
 	}
 	return k.JoinConfiguration.ControlPlane.CertificateKey
 }
@@ -301,7 +405,7 @@ func (k *Runtime) setInitAdvertiseAddress(advertiseAddress net.IP) {
 
 func (k *Runtime) setJoinAdvertiseAddress(advertiseAddress net.IP) {
 	if k.JoinConfiguration.ControlPlane == nil {
-		k.JoinConfiguration.ControlPlane = &v1beta2.JoinControlPlane{}
+		k.JoinConfiguration.ControlPlane = &v1beta3.JoinControlPlane{}
 	}
 	k.JoinConfiguration.ControlPlane.LocalAPIEndpoint.AdvertiseAddress = advertiseAddress
 }
@@ -318,30 +422,24 @@ func (k *Runtime) setCgroupDriver(cGroup string) {
 	k.KubeletConfiguration.CgroupDriver = cGroup
 }
 
-func (k *Runtime) setAPIVersion(apiVersion string) {
-	k.InitConfiguration.APIVersion = apiVersion
-	k.ClusterConfiguration.APIVersion = apiVersion
-	k.JoinConfiguration.APIVersion = apiVersion
-}
-
 func (k *Runtime) setKubeadmAPIVersion() {
 	kv := versionUtils.Version(k.getKubeVersion())
 	greatThanKV1150, err := kv.Compare(V1150)
 	if err != nil {
 		logrus.Errorf("compare kubernetes version failed: %s", err)
 	}
-	greatThanKV1230, err := kv.Compare(V1230)
+	greatThanKV1220, err := kv.Compare(V1220)
 	if err != nil {
 		logrus.Errorf("compare kubernetes version failed: %s", err)
 	}
 	switch {
-	case greatThanKV1150 && !greatThanKV1230:
-		k.setAPIVersion(KubeadmV1beta2)
-	case greatThanKV1230:
-		k.setAPIVersion(KubeadmV1beta3)
+	case greatThanKV1150 && !greatThanKV1220:
+		k.SetAPIVersion(KubeadmV1beta2)
+	case greatThanKV1220:
+		k.SetAPIVersion(KubeadmV1beta3)
 	default:
 		// Compatible with versions 1.14 and 1.13. but do not recommend.
-		k.setAPIVersion(KubeadmV1beta1)
+		k.SetAPIVersion(KubeadmV1beta1)
 	}
 }
 
@@ -380,9 +478,25 @@ func (k *Runtime) MergeKubeadmConfig() error {
 		return fmt.Errorf("failed to merge kubeadm config: %v", err)
 	}
 	k.setKubeadmAPIVersion()
+	k.setImageRepository()
 	return nil
 }
 
+// setImageRepository points kubeadm to the external registry, which the images of ClusterImage are pushed into.
+func (k *Runtime) setImageRepository() {
+	if !k.RegConfig.External {
+		return
+	}
+	domain, path := k.ClusterConfiguration.ImageRepository, ""
+	if i := strings.Index(domain, "/"); i >= 0 {
+		domain, path = domain[:i], domain[i:]
+	}
+	if strings.Split(domain, ":")[0] != SeaHub {
+		return
+	}
+	k.ClusterConfiguration.ImageRepository = k.RegConfig.Repo() + path
+}
+
 func (k *Runtime) WaitSSHReady(tryTimes int, hosts ...net.IP) error {
 	eg, _ := errgroup.WithContext(context.Background())
 	for _, h := range hosts {
@@ -0,0 +1,205 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/sealerio/sealer/pkg/runtime/kubernetes/kubeadm"
+	"github.com/sealerio/sealer/utils/yaml"
+)
+
+const (
+	// JoinTokenTTL is the ttl of the bootstrap token created for each join operation, the token is deleted
+	// once the hosts are joined, the ttl only limits its exposure if the deletion fails.
+	JoinTokenTTL = time.Hour
+	// CertificateKeyTTL is the ttl of the control-plane certs uploaded to secret kubeadm-certs, kubeadm deletes
+	// the secret after it.
+	CertificateKeyTTL = 2 * time.Hour
+	// certificateKeyRefreshMargin re-uploads the control-plane certs if they expire within it, so a master join
+	// never starts with certs about to expire.
+	certificateKeyRefreshMargin = 15 * time.Minute
+
+	JoinTokenDescription  = "created by sealer for joining hosts"
+	RemoteCreateJoinToken = `kubeadm token create --print-join-command --ttl %s --description "%s" -v %d`
+	RemoteDeleteJoinToken = "kubeadm token delete %s -v %d"
+	// RemoteUploadCerts reads the certificate key from the config file rather than the command line, which is
+	// visible in the process list and the logs of failed commands, the file is removed whether the upload fails.
+	RemoteUploadCerts = "kubeadm init phase upload-certs --upload-certs --config %[1]s -v %[2]d && rm -f %[1]s || (rm -f %[1]s; false)"
+)
+
+// bootstrapTokenRegexp matches the bootstrap token "[a-z0-9]{6}.[a-z0-9]{16}", the id before "." is public.
+var bootstrapTokenRegexp = regexp.MustCompile(`^([a-z0-9]{6})\.[a-z0-9]{16}$`)
+
+// createJoinToken creates a bootstrap token with JoinTokenTTL on the operational master for a join operation,
+// and sets the token and ca cert hash to join with. deleteJoinToken should be called once the hosts are joined.
+func (k *Runtime) createJoinToken() error {
+	master, err := k.getOperationalMaster()
+	if err != nil {
+		return err
+	}
+	ssh, err := k.getHostSSHClient(master)
+	if err != nil {
+		return fmt.Errorf("failed to get ssh client of master %s: %v", master, err)
+	}
+	out, err := ssh.Cmd(master, fmt.Sprintf(RemoteCreateJoinToken, JoinTokenTTL, JoinTokenDescription, k.Vlog))
+	if err != nil {
+		return fmt.Errorf("failed to create kubeadm join token: %v", err)
+	}
+	if !strings.Contains(string(out), "kubeadm join") {
+		return fmt.Errorf("failed to get join command from output: %s", out)
+	}
+	k.decodeMaster0Output(out)
+	return nil
+}
+
+// deleteJoinToken deletes the token created by createJoinToken, the failure is only logged as the token expires
+// after JoinTokenTTL anyway.
+func (k *Runtime) deleteJoinToken() {
+	id := tokenID(k.getJoinToken())
+	if id == "" {
+		return
+	}
+	master, err := k.getOperationalMaster()
+	if err != nil {
+		logrus.Warnf("failed to delete join token %s: %v", id, err)
+		return
+	}
+	ssh, err := k.getHostSSHClient(master)
+	if err != nil {
+		logrus.Warnf("failed to delete join token %s: %v", id, err)
+		return
+	}
+	if err := ssh.CmdAsync(master, fmt.Sprintf(RemoteDeleteJoinToken, id, k.Vlog)); err != nil {
+		logrus.Warnf("failed to delete join token %s, it expires in %s: %v", id, JoinTokenTTL, err)
+	}
+}
+
+// uploadControlPlaneCerts uploads the control-plane certs encrypted by the certificate key to secret kubeadm-certs.
+// The key is generated once, and the certs are re-uploaded with it only if they expire within
+// certificateKeyRefreshMargin, so the join configs sent to masters stay valid.
+func (k *Runtime) uploadControlPlaneCerts() error {
+	if !certificateKeyExpiring(k.certificateKeyExpiry, time.Now()) {
+		return nil
+	}
+	key := k.getCertificateKey()
+	if key == "" {
+		var err error
+		if key, err = newCertificateKey(); err != nil {
+			return err
+		}
+	}
+	master, err := k.getOperationalMaster()
+	if err != nil {
+		return err
+	}
+	ssh, err := k.getHostSSHClient(master)
+	if err != nil {
+		return fmt.Errorf("failed to get ssh client of master %s: %v", master, err)
+	}
+	config, err := k.uploadCertsConfig(master, key)
+	if err != nil {
+		return err
+	}
+	tmpFile, err := ioutil.TempFile("", "sealer-upload-certs")
+	if err != nil {
+		return fmt.Errorf("failed to create upload-certs config: %v", err)
+	}
+	defer func() {
+		_ = os.Remove(tmpFile.Name())
+	}()
+	_, err = tmpFile.Write(config)
+	if cErr := tmpFile.Close(); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write upload-certs config: %v", err)
+	}
+	remoteConfig := filepath.Join(k.getRootfs(), "etc", "upload-certs.yml")
+	if err = ssh.Copy(master, tmpFile.Name(), remoteConfig); err != nil {
+		return fmt.Errorf("failed to copy upload-certs config to master %s: %v", master, err)
+	}
+	// the upload time is taken before the command, so the expiry is never later than the secret's.
+	uploadTime := time.Now()
+	// the output is not printed as it contains the key, and the key is redacted from the error.
+	if out, err := ssh.Cmd(master, fmt.Sprintf(RemoteUploadCerts, remoteConfig, k.Vlog)); err != nil {
+		return fmt.Errorf("failed to upload control-plane certs: %s, %s", redactKey(err.Error(), key), redactKey(string(out), key))
+	}
+	k.setInitCertificateKey(key)
+	k.certificateKeyExpiry = uploadTime.Add(CertificateKeyTTL)
+	return nil
+}
+
+// uploadCertsConfig returns the kubeadm config of "kubeadm init phase upload-certs" on master with the key, the
+// cluster configuration tells kubeadm the certs to upload, like the ones of the external etcd.
+func (k *Runtime) uploadCertsConfig(master net.IP, key string) ([]byte, error) {
+	initConfig := k.InitConfiguration
+	initConfig.LocalAPIEndpoint.AdvertiseAddress = master
+	initConfig.BootstrapTokens = nil
+	initConfig.CertificateKey = key
+	versionedInit, err := kubeadm.ConvertTo(&initConfig)
+	if err != nil {
+		return nil, err
+	}
+	versionedCluster, err := kubeadm.ConvertTo(&k.ClusterConfiguration)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.MarshalWithDelimiter(versionedInit, versionedCluster)
+}
+
+// certificateKeyExpiring reports whether the control-plane certs expire within certificateKeyRefreshMargin at now,
+// the zero expiry means the certs are not uploaded yet.
+func certificateKeyExpiring(expiry, now time.Time) bool {
+	return expiry.Sub(now) <= certificateKeyRefreshMargin
+}
+
+// tokenID returns the public id of the bootstrap token, or "" if it is not a bootstrap token, so the secret is
+// never logged or passed to commands.
+func tokenID(token string) string {
+	match := bootstrapTokenRegexp.FindStringSubmatch(strings.TrimSpace(token))
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
+// newCertificateKey returns a random AES-256 key in hex like "kubeadm certs certificate-key".
+func newCertificateKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("failed to generate certificate key: %v", err)
+	}
+	return hex.EncodeToString(key), nil
+}
+
+// redactKey replaces the key in s, so the certificate key does not leak into errors and logs.
+func redactKey(s, key string) string {
+	if key == "" {
+		return s
+	}
+	return strings.ReplaceAll(s, key, "<redacted>")
+}
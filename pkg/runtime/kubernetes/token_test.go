@@ -0,0 +1,101 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRedactKey(t *testing.T) {
+	key := "f8902e114ef118304e561c3ecd4d0b543adc226b7a07f675f56564185ffe0c07"
+	tests := []struct {
+		name string
+		s    string
+		key  string
+		want string
+	}{
+		{
+			name: "key in command of error",
+			s:    "[ssh][192.168.0.2]run command failed [kubeadm init phase upload-certs --upload-certs --certificate-key " + key + "]",
+			key:  key,
+			want: "[ssh][192.168.0.2]run command failed [kubeadm init phase upload-certs --upload-certs --certificate-key <redacted>]",
+		},
+		{
+			name: "key repeated in output",
+			s:    "[upload-certs] Using certificate key:\n" + key + "\n" + key,
+			key:  key,
+			want: "[upload-certs] Using certificate key:\n<redacted>\n<redacted>",
+		},
+		{
+			name: "empty key",
+			s:    "error execution phase upload-certs",
+			want: "error execution phase upload-certs",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redactKey(tt.s, tt.key); got != tt.want {
+				t.Errorf("redactKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCertificateKeyExpiring(t *testing.T) {
+	now := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		expiry time.Time
+		want   bool
+	}{
+		{"not uploaded", time.Time{}, true},
+		{"expired", now.Add(-time.Minute), true},
+		{"expires within the margin", now.Add(certificateKeyRefreshMargin - time.Second), true},
+		{"expires at the margin", now.Add(certificateKeyRefreshMargin), true},
+		{"expires after the margin", now.Add(certificateKeyRefreshMargin + time.Second), false},
+		{"just uploaded", now.Add(CertificateKeyTTL), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := certificateKeyExpiring(tt.expiry, now); got != tt.want {
+				t.Errorf("certificateKeyExpiring() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenID(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string
+		want  string
+	}{
+		{"bootstrap token", "9vr73a.a8uxyaju799qwdjv", "9vr73a"},
+		{"trailing newline", "9vr73a.a8uxyaju799qwdjv\n", "9vr73a"},
+		{"empty", "", ""},
+		{"token without secret", "9vr73a", ""},
+		{"short secret", "9vr73a.a8uxyaju", ""},
+		{"upper case", "9VR73A.A8UXYAJU799QWDJV", ""},
+		{"flag after token", "9vr73a.a8uxyaju799qwdjv --discovery-token-ca-cert-hash", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tokenID(tt.token); got != tt.want {
+				t.Errorf("tokenID(%q) = %q, want %q", tt.token, got, tt.want)
+			}
+		})
+	}
+}
@@ -37,11 +37,21 @@ func (k *Runtime) upgrade() error {
 	var err error
 	binPath := filepath.Join(k.getRootfs(), `bin`)
 
-	err = k.upgradeFirstMaster(k.cluster.GetMaster0IP(), binPath, k.getKubeVersion())
+	firstMaster, err := k.getOperationalMaster()
 	if err != nil {
 		return err
 	}
-	err = k.upgradeOtherMasters(k.cluster.GetMasterIPList()[1:], binPath, k.getKubeVersion())
+	err = k.upgradeFirstMaster(firstMaster, binPath, k.getKubeVersion())
+	if err != nil {
+		return err
+	}
+	var otherMasters []net.IP
+	for _, master := range k.cluster.GetMasterIPList() {
+		if !master.Equal(firstMaster) {
+			otherMasters = append(otherMasters, master)
+		}
+	}
+	err = k.upgradeOtherMasters(otherMasters, binPath, k.getKubeVersion())
 	if err != nil {
 		return err
 	}
@@ -76,7 +86,7 @@ func (k *Runtime) upgradeFirstMaster(IP net.IP, binPath, version string) error {
 	}
 	ssh, err := k.getHostSSHClient(IP)
 	if err != nil {
-		return fmt.Errorf("failed to get master0 ssh client: %v", err)
+		return fmt.Errorf("failed to get ssh client of master %s: %v", IP, err)
 	}
 	return ssh.CmdAsync(IP, firstMasterCmds...)
 }
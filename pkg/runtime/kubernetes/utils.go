@@ -15,11 +15,13 @@
 package kubernetes
 
 import (
+	"crypto/x509"
 	"fmt"
 	"net"
 	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/sealerio/sealer/pkg/clustercert/cert"
 
@@ -56,25 +58,45 @@ func GetKubectlAndKubeconfig(ssh ssh.Interface, host net.IP, rootfs string) erro
 	return nil
 }
 
-func GenerateRegistryCert(registryCertPath string, baseName string) error {
+// GenerateRegistryCert issues the registry cert signed by the registry CA, which is generated if it does not exist,
+// the CA is kept when the registry cert is renewed, so the nodes trusting the CA trust the new cert.
+func GenerateRegistryCert(registryCertPath string, baseName string, years int) error {
+	caCert, caKey, err := cert.NewCertificateFileManger(registryCertPath, RegistryCAName).Read()
+	if err != nil {
+		caGenerator := cert.NewAuthorityCertificateGenerator(cert.CertificateDescriptor{
+			CommonName:   RegistryCAName,
+			Organization: []string{common.ExecBinaryFileName},
+			Year:         100,
+		})
+		caCert, caKey, err = caGenerator.Generate()
+		if err != nil {
+			return fmt.Errorf("unable to generate registry CA: %v", err)
+		}
+		if err = cert.NewCertificateFileManger(registryCertPath, RegistryCAName).Write(caCert, caKey); err != nil {
+			return fmt.Errorf("unable to save registry CA: %v", err)
+		}
+	}
+
 	regCertConfig := cert.CertificateDescriptor{
 		CommonName:   baseName,
-		DNSNames:     []string{baseName},
 		Organization: []string{common.ExecBinaryFileName},
-		Year:         100,
+		Year:         time.Duration(years),
+		AltNames: cert.AltNames{
+			DNSNames: map[string]string{baseName: baseName, SeaHub: SeaHub},
+		},
+		Usages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
 	}
-	if baseName != SeaHub {
-		regCertConfig.DNSNames = append(regCertConfig.DNSNames, SeaHub)
+	generator, err := cert.NewCommonCertificateGenerator(regCertConfig, caCert, caKey)
+	if err != nil {
+		return err
 	}
-
-	caGenerator := cert.NewAuthorityCertificateGenerator(regCertConfig)
-	caCert, caKey, err := caGenerator.Generate()
+	regCert, regKey, err := generator.Generate()
 	if err != nil {
 		return fmt.Errorf("unable to generate %s cert: %v", baseName, err)
 	}
 
 	// write cert file to disk
-	err = cert.NewCertificateFileManger(registryCertPath, baseName).Write(caCert, caKey)
+	err = cert.NewCertificateFileManger(registryCertPath, baseName).Write(regCert, regKey)
 	if err != nil {
 		return fmt.Errorf("unable to save %s cert: %v", baseName, err)
 	}
@@ -82,10 +104,14 @@ func GenerateRegistryCert(registryCertPath string, baseName string) error {
 	return nil
 }
 
-func getEtcdEndpointsWithHTTPSPrefix(masters []net.IP) string {
-	var tmpSlice []string
-	for _, ip := range masters {
-		tmpSlice = append(tmpSlice, fmt.Sprintf("https://%s:2379", ip))
+func getEtcdEndpointsWithHTTPSPrefix(hosts []net.IP) string {
+	return strings.Join(getEtcdEndpoints(hosts), ",")
+}
+
+func getEtcdEndpoints(hosts []net.IP) []string {
+	var endpoints []string
+	for _, ip := range hosts {
+		endpoints = append(endpoints, "https://"+net.JoinHostPort(ip.String(), EtcdClientPort))
 	}
-	return strings.Join(tmpSlice, ",")
+	return endpoints
 }
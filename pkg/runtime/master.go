@@ -0,0 +1,52 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/sealerio/sealer/common"
+	v2 "github.com/sealerio/sealer/types/api/v2"
+	utilsnet "github.com/sealerio/sealer/utils/net"
+	"github.com/sealerio/sealer/utils/ssh"
+)
+
+// RemoteCheckMasterHealth checks the local apiserver of master, and the etcd it connects to by /healthz/etcd.
+const RemoteCheckMasterHealth = "kubectl --kubeconfig=/etc/kubernetes/admin.conf --server=https://127.0.0.1:" + common.APIServerPort + " get --raw=/healthz/etcd"
+
+// GetOperationalMaster returns the first master whose apiserver and etcd are healthy, the masters in excluded are
+// skipped. Master0 is the bootstrap master of cluster init only, and any operational master drives the operations
+// of a running cluster, so they do not fail when master0 is down.
+func GetOperationalMaster(cluster *v2.Cluster, excluded ...net.IP) (net.IP, error) {
+	for _, master := range cluster.GetMasterIPList() {
+		if !utilsnet.NotInIPList(master, excluded) {
+			continue
+		}
+		client, err := ssh.GetHostSSHClient(master, cluster)
+		if err != nil {
+			logrus.Warnf("master %s is not operational: failed to get ssh client: %v", master, err)
+			continue
+		}
+		if out, err := client.Cmd(master, RemoteCheckMasterHealth); err != nil {
+			logrus.Warnf("master %s is not operational: %v, %s", master, err, out)
+			continue
+		}
+		return master, nil
+	}
+	return nil, fmt.Errorf("failed to find an operational master in %s, the apiserver or etcd of all masters is unhealthy", cluster.GetMasterIPList())
+}
@@ -19,6 +19,7 @@ package v2
 import (
 	"net"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/sealerio/sealer/common"
@@ -41,6 +42,19 @@ type ClusterSpec struct {
 	CMD     []string `json:"cmd,omitempty"`
 	Hosts   []Host   `json:"hosts,omitempty"`
 	SSH     v1.SSH   `json:"ssh,omitempty"`
+	// LoadBalancer is the load balancer of control-plane endpoint, lvscare is used if it is not set.
+	LoadBalancer LoadBalancer `json:"loadBalancer,omitempty"`
+}
+
+type LoadBalancer struct {
+	// Type is one of lvscare, kube-vip and external, default is lvscare.
+	Type string `json:"type,omitempty"`
+	// VIP is the address of control-plane endpoint, it is required by kube-vip and external load balancer.
+	VIP string `json:"vip,omitempty"`
+	// Interface is the network interface kube-vip advertises VIP on by ARP.
+	Interface string `json:"interface,omitempty"`
+	// Image overwrites the image of lvscare or kube-vip static pod.
+	Image string `json:"image,omitempty"`
 }
 
 type Host struct {
@@ -50,6 +64,15 @@ type Host struct {
 	SSH v1.SSH `json:"ssh,omitempty"`
 	//overwrite env
 	Env []string `json:"env,omitempty"`
+	// Labels are set to the nodes of host on every apply, the labels removed from here are removed from the nodes.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Taints are set to the nodes of host on every apply, the taints removed from here are removed from the nodes.
+	Taints []corev1.Taint `json:"taints,omitempty"`
+	// KubeletExtraArgs overwrites the kubelet flags of the host, like "max-pods", it is applied at join time,
+	// and the flags of the joined host are updated and kubelet is restarted when they are changed or removed.
+	// There is no per-host kubelet config, since kubeadm shares one KubeletConfiguration among all nodes,
+	// the fields of it are overwritten by the flags here instead.
+	KubeletExtraArgs map[string]string `json:"kubeletExtraArgs,omitempty"`
 }
 
 // ClusterStatus defines the observed state of Cluster
@@ -89,6 +112,11 @@ func (in *Cluster) GetNodeIPList() []net.IP {
 	return in.GetIPSByRole(common.NODE)
 }
 
+// GetEtcdIPList returns the hosts of external etcd cluster, etcd is stacked on masters if there is none.
+func (in *Cluster) GetEtcdIPList() []net.IP {
+	return in.GetIPSByRole(common.ETCD)
+}
+
 func (in *Cluster) GetAllIPList() []net.IP {
 	return append(in.GetIPSByRole(common.MASTER), in.GetIPSByRole(common.NODE)...)
 }
@@ -113,6 +141,19 @@ func (in *Cluster) GetIPSByRole(role string) []net.IP {
 	}
 	return hosts
 }
+
+// GetHostByIP returns the host having ip, nil is returned if there is none.
+func (in *Cluster) GetHostByIP(ip net.IP) *Host {
+	for i := range in.Spec.Hosts {
+		for _, hostIP := range in.Spec.Hosts[i].IPS {
+			if hostIP.Equal(ip) {
+				return &in.Spec.Hosts[i]
+			}
+		}
+	}
+	return nil
+}
+
 func (in *Cluster) GetAnnotationsByKey(key string) string {
 	return in.Annotations[key]
 }
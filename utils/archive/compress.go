@@ -224,6 +224,7 @@ func writeToTarWriter(path string, tarWriter *tar.Writer, bufWriter *bufio.Write
 		if walkErr != nil {
 			return walkErr
 		}
+		normalizeHeader(header)
 		// root dir
 		if file != dir {
 			absPath := filepath.ToSlash(file)
@@ -0,0 +1,90 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"archive/tar"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// SourceDateEpochEnv is the environment variable defined by https://reproducible-builds.org/specs/source-date-epoch/
+const SourceDateEpochEnv = "SOURCE_DATE_EPOCH"
+
+var (
+	epochMu         sync.RWMutex
+	sourceDateEpoch *time.Time
+)
+
+// SetSourceDateEpoch makes the tar streams reproducible, the timestamps of all entries are set to epoch,
+// and the ownership of all entries is reset to root, so identical content yields identical digest.
+func SetSourceDateEpoch(epoch time.Time) {
+	epochMu.Lock()
+	defer epochMu.Unlock()
+	e := epoch.UTC()
+	sourceDateEpoch = &e
+}
+
+// UnsetSourceDateEpoch makes the tar streams keep the original timestamps and ownership.
+func UnsetSourceDateEpoch() {
+	epochMu.Lock()
+	defer epochMu.Unlock()
+	sourceDateEpoch = nil
+}
+
+// SourceDateEpoch returns the epoch set by SetSourceDateEpoch, or SOURCE_DATE_EPOCH if it is not set, it returns
+// nil if neither is set.
+func SourceDateEpoch() (*time.Time, error) {
+	epochMu.RLock()
+	defer epochMu.RUnlock()
+	if sourceDateEpoch != nil {
+		e := *sourceDateEpoch
+		return &e, nil
+	}
+	return SourceDateEpochFromEnv()
+}
+
+// SourceDateEpochFromEnv parses SOURCE_DATE_EPOCH, it returns nil if the env is not set.
+func SourceDateEpochFromEnv() (*time.Time, error) {
+	v, ok := os.LookupEnv(SourceDateEpochEnv)
+	if !ok || v == "" {
+		return nil, nil
+	}
+
+	sec, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %s: %v", SourceDateEpochEnv, v, err)
+	}
+	epoch := time.Unix(sec, 0).UTC()
+	return &epoch, nil
+}
+
+// normalizeHeader resets the fields of header which differ between machines and builds.
+func normalizeHeader(header *tar.Header) {
+	epochMu.RLock()
+	defer epochMu.RUnlock()
+	if sourceDateEpoch == nil {
+		return
+	}
+
+	header.ModTime = *sourceDateEpoch
+	header.AccessTime = time.Time{}
+	header.ChangeTime = time.Time{}
+	header.Uid, header.Gid = 0, 0
+	header.Uname, header.Gname = "", ""
+}
@@ -0,0 +1,129 @@
+// Copyright © 2022 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"archive/tar"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTarCanonicalDigestWithSourceDateEpoch(t *testing.T) {
+	root, err := ioutil.TempDir("", "reproducible")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	for _, d := range filesToCreate {
+		if err = makeDir(root, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	SetSourceDateEpoch(time.Unix(0, 0))
+	defer UnsetSourceDateEpoch()
+
+	before, _, err := TarCanonicalDigest(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	touched := time.Now().Add(time.Hour)
+	if err = os.Chtimes(filepath.Join(root, "testDirA", "testFileA"), touched, touched); err != nil {
+		t.Fatal(err)
+	}
+
+	after, _, err := TarCanonicalDigest(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if before != after {
+		t.Errorf("digest changed with mtime: %s != %s", before, after)
+	}
+
+	UnsetSourceDateEpoch()
+	original, _, err := TarCanonicalDigest(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if original == after {
+		t.Errorf("digest should keep mtime without source date epoch")
+	}
+}
+
+func TestNormalizeHeader(t *testing.T) {
+	modTime := time.Now()
+	newHeader := func() *tar.Header {
+		return &tar.Header{
+			Name:       "testDirA/testFileA",
+			Uid:        1000,
+			Gid:        1000,
+			Uname:      "builder",
+			Gname:      "builder",
+			ModTime:    modTime,
+			AccessTime: modTime,
+			ChangeTime: modTime,
+		}
+	}
+
+	header := newHeader()
+	normalizeHeader(header)
+	if !reflect.DeepEqual(header, newHeader()) {
+		t.Errorf("header should be kept without source date epoch: %+v", header)
+	}
+
+	epoch := time.Unix(0, 0)
+	SetSourceDateEpoch(epoch)
+	defer UnsetSourceDateEpoch()
+	normalizeHeader(header)
+	if header.Uid != 0 || header.Gid != 0 {
+		t.Errorf("uid/gid should be normalized to 0/0, got %d/%d", header.Uid, header.Gid)
+	}
+	if header.Uname != "" || header.Gname != "" {
+		t.Errorf("uname/gname should be empty, got %s/%s", header.Uname, header.Gname)
+	}
+	if !header.ModTime.Equal(epoch) || !header.AccessTime.IsZero() || !header.ChangeTime.IsZero() {
+		t.Errorf("timestamps should be normalized to epoch, got %+v", header)
+	}
+}
+
+func TestSourceDateEpoch(t *testing.T) {
+	if err := os.Setenv(SourceDateEpochEnv, "1640995200"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv(SourceDateEpochEnv)
+
+	got, err := SourceDateEpoch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Unix(1640995200, 0); got == nil || !got.Equal(want) {
+		t.Errorf("SourceDateEpoch() = %v, want %v from env", got, want)
+	}
+
+	SetSourceDateEpoch(time.Unix(0, 0))
+	defer UnsetSourceDateEpoch()
+	got, err = SourceDateEpoch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Unix(0, 0); got == nil || !got.Equal(want) {
+		t.Errorf("SourceDateEpoch() = %v, want %v set by build", got, want)
+	}
+}
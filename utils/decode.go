@@ -31,7 +31,7 @@ import (
 	"k8s.io/kubelet/config/v1beta1"
 
 	"github.com/sealerio/sealer/common"
-	"github.com/sealerio/sealer/pkg/runtime/kubernetes/kubeadm/v1beta2"
+	"github.com/sealerio/sealer/pkg/runtime/kubernetes/kubeadm/v1beta3"
 	v1 "github.com/sealerio/sealer/types/api/v1"
 	v2 "github.com/sealerio/sealer/types/api/v2"
 )
@@ -202,17 +202,17 @@ func decodePluginListFunc(reader io.Reader) (interface{}, error) {
 }
 
 func decodeInitConfigurationFunc(reader io.Reader) (out interface{}, err error) {
-	switchVersion := func(version string) interface{} { return &v1beta2.InitConfiguration{} }
+	switchVersion := func(version string) interface{} { return &v1beta3.InitConfiguration{} }
 	return decodeCRDFromReader(NewK8sYamlDecoder(reader), common.InitConfiguration, switchVersion)
 }
 
 func decodeJoinConfigurationFunc(reader io.Reader) (out interface{}, err error) {
-	switchVersion := func(version string) interface{} { return &v1beta2.JoinConfiguration{} }
+	switchVersion := func(version string) interface{} { return &v1beta3.JoinConfiguration{} }
 	return decodeCRDFromReader(NewK8sYamlDecoder(reader), common.JoinConfiguration, switchVersion)
 }
 
 func decodeClusterConfigurationFunc(reader io.Reader) (out interface{}, err error) {
-	switchVersion := func(version string) interface{} { return &v1beta2.ClusterConfiguration{} }
+	switchVersion := func(version string) interface{} { return &v1beta3.ClusterConfiguration{} }
 	return decodeCRDFromReader(NewK8sYamlDecoder(reader), common.ClusterConfiguration, switchVersion)
 }
 
@@ -74,6 +74,31 @@ func RunSimpleCmd(cmd string) (string, error) {
 	return string(result), err
 }
 
+// RunSimpleCmdWithEnv runs cmd with extra environment variables like "KEY=VALUE",
+// the variables are passed through process environment rather than the command line.
+func RunSimpleCmdWithEnv(cmd string, env []string) (string, error) {
+	username, err := GetCurrentUserName()
+	if err != nil {
+		return "", err
+	}
+	var c *exec.Cmd
+	if username != common.ROOT {
+		var keys []string
+		for _, e := range env {
+			keys = append(keys, strings.SplitN(e, "=", 2)[0])
+		}
+		c = exec.Command(SUDO, "--preserve-env="+strings.Join(keys, ","), "/bin/sh", "-c", cmd) // #nosec
+	} else {
+		c = exec.Command("/bin/sh", "-c", cmd) // #nosec
+	}
+	c.Env = append(os.Environ(), env...)
+	result, err := c.CombinedOutput()
+	if err != nil {
+		logrus.Debugf("failed to execute command(%s): error(%v)", cmd, err)
+	}
+	return string(result), err
+}
+
 func CheckCmdIsExist(cmd string) (string, bool) {
 	cmd = fmt.Sprintf("type %s", cmd)
 	out, err := RunSimpleCmd(cmd)
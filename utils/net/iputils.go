@@ -27,11 +27,13 @@ import (
 	k8snet "k8s.io/apimachinery/pkg/util/net"
 )
 
+// GetHostIP returns the IP of host, which is either an IP or an IP:port,
+// the IPv6 address with port is bracketed like [fd00::1]:22.
 func GetHostIP(host string) string {
-	if !strings.ContainsRune(host, ':') {
-		return host
+	if ip, _, err := net.SplitHostPort(host); err == nil {
+		return ip
 	}
-	return strings.Split(host, ":")[0]
+	return strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
 }
 
 func GetHostIPSlice(hosts []string) (res []string) {
@@ -99,7 +101,7 @@ func GetLocalHostAddresses() ([]net.Addr, error) {
 
 func IsLocalIP(ip net.IP, addrs []net.Addr) bool {
 	for _, address := range addrs {
-		if ipnet, ok := address.(*net.IPNet); ok && !ipnet.IP.IsLoopback() && ipnet.IP.To4() != nil && ipnet.IP.Equal(ip) {
+		if ipnet, ok := address.(*net.IPNet); ok && !ipnet.IP.IsLoopback() && ipnet.IP.Equal(ip) {
 			return true
 		}
 	}
@@ -119,8 +121,31 @@ func GetLocalIP(master0IPPort string) (net.IP, error) {
 	if err != nil {
 		return nil, err
 	}
-	localAddr := conn.LocalAddr().String()
-	return net.ParseIP(strings.Split(localAddr, ":")[0]), err
+	defer conn.Close()
+	localAddr, _, err := net.SplitHostPort(conn.LocalAddr().String())
+	if err != nil {
+		return nil, err
+	}
+	return net.ParseIP(localAddr), nil
+}
+
+// HostCIDR returns the CIDR only containing ip, it is ip/32 for IPv4 and ip/128 for IPv6.
+func HostCIDR(ip net.IP) string {
+	if ip.To4() != nil {
+		return fmt.Sprintf("%s/32", ip)
+	}
+	return fmt.Sprintf("%s/128", ip)
+}
+
+// SplitCIDRs splits the comma separated CIDRs of dual-stack cluster, like "10.96.0.0/22,fd00:10:96::/108".
+func SplitCIDRs(cidrs string) []string {
+	var res []string
+	for _, c := range strings.Split(cidrs, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			res = append(res, c)
+		}
+	}
+	return res
 }
 
 func AssemblyIPList(ipStr string) (string, error) {
@@ -191,3 +191,42 @@ func equalIPStrs(a, b []string) bool {
 	}
 	return true
 }
+
+func TestGetHostIP(t *testing.T) {
+	tests := []struct {
+		name   string
+		host   string
+		wanted string
+	}{
+		{name: "IPv4", host: "10.110.101.1", wanted: "10.110.101.1"},
+		{name: "IPv4 with port", host: "10.110.101.1:22", wanted: "10.110.101.1"},
+		{name: "IPv6", host: "fd00::1", wanted: "fd00::1"},
+		{name: "bracketed IPv6", host: "[fd00::1]", wanted: "fd00::1"},
+		{name: "IPv6 with port", host: "[fd00::1]:22", wanted: "fd00::1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetHostIP(tt.host); got != tt.wanted {
+				t.Errorf("wanted host IP is (%s), but got (%s)", tt.wanted, got)
+			}
+		})
+	}
+}
+
+func TestHostCIDR(t *testing.T) {
+	tests := []struct {
+		name   string
+		ip     net.IP
+		wanted string
+	}{
+		{name: "IPv4", ip: net.ParseIP("10.103.97.2"), wanted: "10.103.97.2/32"},
+		{name: "IPv6", ip: net.ParseIP("1248:4003:10bb:6a01:83b9:6360:c66d:0002"), wanted: "1248:4003:10bb:6a01:83b9:6360:c66d:2/128"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HostCIDR(tt.ip); got != tt.wanted {
+				t.Errorf("wanted CIDR is (%s), but got (%s)", tt.wanted, got)
+			}
+		})
+	}
+}
@@ -48,7 +48,7 @@ const (
 	RouteFailed = "failed"
 )
 
-var ErrNotIPV4 = errors.New("IP addresses are not IPV4 rules")
+var ErrNotSameFamily = errors.New("IP addresses of host and gateway are not the same family")
 
 type Route struct {
 	Host    net.IP
@@ -75,8 +75,8 @@ func CheckIsDefaultRoute(host net.IP) error {
 
 // SetRoute ip route add $route
 func (r *Route) SetRoute() error {
-	if !k8sutilsnet.IsIPv4(r.Gateway) || !k8sutilsnet.IsIPv4(r.Host) {
-		return ErrNotIPV4
+	if k8sutilsnet.IsIPv4(r.Gateway) != k8sutilsnet.IsIPv4(r.Host) {
+		return ErrNotSameFamily
 	}
 	err := addRouteGatewayViaHost(r.Host, r.Gateway, 50)
 	if err != nil && !errors.Is(err, os.ErrExist) /* return if route already exist */ {
@@ -100,8 +100,8 @@ func (r *Route) SetRoute() error {
 
 // DelRoute ip route del $route
 func (r *Route) DelRoute() error {
-	if !k8sutilsnet.IsIPv4(r.Gateway) || !k8sutilsnet.IsIPv4(r.Host) {
-		return ErrNotIPV4
+	if k8sutilsnet.IsIPv4(r.Gateway) != k8sutilsnet.IsIPv4(r.Host) {
+		return ErrNotSameFamily
 	}
 	err := delRouteGatewayViaHost(r.Host, r.Gateway)
 	if err != nil && !errors.Is(err, syscall.ESRCH) /* return if route does not exist */ {
@@ -133,12 +133,8 @@ func isDefaultRouteIP(host net.IP) (bool, error) {
 }
 
 func addRouteGatewayViaHost(host, gateway net.IP, priority int) error {
-	Dst := &net.IPNet{
-		IP:   host,
-		Mask: net.CIDRMask(32, 32),
-	}
 	r := &netlink.Route{
-		Dst:      Dst,
+		Dst:      hostIPNet(host),
 		Gw:       gateway,
 		Priority: priority,
 	}
@@ -146,17 +142,21 @@ func addRouteGatewayViaHost(host, gateway net.IP, priority int) error {
 }
 
 func delRouteGatewayViaHost(host, gateway net.IP) error {
-	Dst := &net.IPNet{
-		IP:   host,
-		Mask: net.CIDRMask(32, 32),
-	}
 	r := &netlink.Route{
-		Dst: Dst,
+		Dst: hostIPNet(host),
 		Gw:  gateway,
 	}
 	return netlink.RouteDel(r)
 }
 
+// hostIPNet returns the IPNet only containing host, whose mask is /32 for IPv4 and /128 for IPv6.
+func hostIPNet(host net.IP) *net.IPNet {
+	if host.To4() != nil {
+		return &net.IPNet{IP: host, Mask: net.CIDRMask(32, 32)}
+	}
+	return &net.IPNet{IP: host, Mask: net.CIDRMask(128, 128)}
+}
+
 func IsIpv4(ip string) bool {
 	arr := strings.Split(ip, ".")
 	if len(arr) != 4 {